@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/nektos/act/pkg/artifacts"
+	"github.com/nektos/act/pkg/filestore"
+	"github.com/spf13/cobra"
+)
+
+type artifactsInput struct {
+	runID  string
+	output string
+}
+
+func newArtifactsCommand(input *Input) *cobra.Command {
+	artifactsArgs := &artifactsInput{}
+	cmd := &cobra.Command{
+		Use:   "artifacts",
+		Short: "List, download and remove the artifacts stored by the artifact server in --artifact-server-path",
+	}
+	cmd.PersistentFlags().StringVar(&artifactsArgs.runID, "run-id", "", "only use the artifacts of the run with this id")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the artifacts with their run id, api version, size and expiration",
+		Args:  cobra.NoArgs,
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			storage, err := openArtifactStorage(input)
+			if err != nil {
+				return err
+			}
+			list, err := artifacts.ListArtifacts(storage, artifactsArgs.runID, input.artifactRetentionDays)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "RUN ID\tNAME\tVERSION\tSIZE\tUPDATED\tEXPIRES")
+			for _, artifact := range list {
+				expires := "never"
+				if !artifact.ExpiresAt.IsZero() {
+					expires = artifact.ExpiresAt.Local().Format("2006-01-02 15:04:05")
+				}
+				fmt.Fprintf(w, "%s\t%s\tv%d\t%s\t%s\t%s\n", artifact.RunID, artifact.Name, artifact.Version, formatSize(artifact.Size), artifact.ModTime.Local().Format("2006-01-02 15:04:05"), expires)
+			}
+			return w.Flush()
+		},
+	}
+
+	download := &cobra.Command{
+		Use:   "download NAME...",
+		Short: "Download artifacts into <output>/<name>, v4 zip files are extracted",
+		Long:  "Download artifacts into <output>/<name>, v4 zip files are extracted. Without --run-id the artifact of the latest run is downloaded.",
+		Args:  cobra.MinimumNArgs(1),
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, names []string) error {
+			storage, err := openArtifactStorage(input)
+			if err != nil {
+				return err
+			}
+			list, err := artifacts.ListArtifacts(storage, artifactsArgs.runID, input.artifactRetentionDays)
+			if err != nil {
+				return err
+			}
+			selected, err := selectArtifacts(list, names, true)
+			if err != nil {
+				return err
+			}
+			for _, artifact := range selected {
+				dest := filepath.Join(artifactsArgs.output, artifact.Name)
+				if err := artifacts.DownloadArtifact(storage, artifact, dest); err != nil {
+					return fmt.Errorf("failed to download artifact '%s' of run %s: %w", artifact.Name, artifact.RunID, err)
+				}
+				fmt.Printf("Downloaded artifact '%s' of run %s to %s\n", artifact.Name, artifact.RunID, dest)
+			}
+			return nil
+		},
+	}
+	download.Flags().StringVarP(&artifactsArgs.output, "output", "o", ".", "the directory to download the artifacts into")
+
+	rm := &cobra.Command{
+		Use:   "rm NAME...",
+		Short: "Remove artifacts, without --run-id they are removed from all runs",
+		Args:  cobra.MinimumNArgs(1),
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, names []string) error {
+			storage, err := openArtifactStorage(input)
+			if err != nil {
+				return err
+			}
+			list, err := artifacts.ListArtifacts(storage, artifactsArgs.runID, input.artifactRetentionDays)
+			if err != nil {
+				return err
+			}
+			selected, err := selectArtifacts(list, names, false)
+			if err != nil {
+				return err
+			}
+			for _, artifact := range selected {
+				if err := artifacts.RemoveArtifact(storage, artifact); err != nil {
+					return fmt.Errorf("failed to remove artifact '%s' of run %s: %w", artifact.Name, artifact.RunID, err)
+				}
+				fmt.Printf("Removed artifact '%s' of run %s\n", artifact.Name, artifact.RunID)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(list, download, rm)
+	return cmd
+}
+
+func openArtifactStorage(input *Input) (filestore.Storage, error) {
+	if input.artifactServerPath == "" {
+		return nil, fmt.Errorf("the artifact storage is not configured, set --artifact-server-path")
+	}
+	return filestore.Open(input.artifactServerPath)
+}
+
+// selectArtifacts returns the artifacts with the names, with latestOnly only the artifact of the latest run is returned per name
+func selectArtifacts(list []artifacts.Artifact, names []string, latestOnly bool) ([]artifacts.Artifact, error) {
+	selected := []artifacts.Artifact{}
+	for _, name := range names {
+		found := []artifacts.Artifact{}
+		for _, artifact := range list {
+			if artifact.Name == name {
+				found = append(found, artifact)
+			}
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("artifact '%s' not found", name)
+		}
+		if latestOnly {
+			// the artifacts are sorted by run id
+			found = found[len(found)-1:]
+		}
+		selected = append(selected, found...)
+	}
+	return selected, nil
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/nektos/act/pkg/artifacts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectArtifacts(t *testing.T) {
+	list := []artifacts.Artifact{
+		{RunID: "1", Name: "dist"},
+		{RunID: "1", Name: "logs"},
+		{RunID: "2", Name: "dist"},
+	}
+
+	selected, err := selectArtifacts(list, []string{"dist"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []artifacts.Artifact{{RunID: "2", Name: "dist"}}, selected)
+
+	selected, err = selectArtifacts(list, []string{"dist", "logs"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []artifacts.Artifact{list[0], list[2], list[1]}, selected)
+
+	_, err = selectArtifacts(list, []string{"missing"}, false)
+	assert.EqualError(t, err, "artifact 'missing' not found")
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KiB", formatSize(1536))
+	assert.Equal(t, "3.0 MiB", formatSize(3*1024*1024))
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nektos/act/pkg/artifactcache"
+	"github.com/spf13/cobra"
+)
+
+type cacheInput struct {
+	unused  time.Duration
+	maxSize int64
+}
+
+func newCacheCommand(input *Input) *cobra.Command {
+	cacheArgs := &cacheInput{}
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "List, show, delete and prune the caches stored by the cache server in --cache-server-path",
+	}
+
+	list := &cobra.Command{
+		Use:   "list [PREFIX]",
+		Short: "List the caches with their id, key, version, size and last use, optionally only those whose key starts with PREFIX",
+		Args:  cobra.MaximumNArgs(1),
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, args []string) error {
+			manager, err := openCacheManager(input)
+			if err != nil {
+				return err
+			}
+			prefix := ""
+			if len(args) > 0 {
+				prefix = args[0]
+			}
+			list, err := manager.List(prefix)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tKEY\tVERSION\tREPOSITORY\tSCOPE\tSIZE\tLAST USED")
+			for _, cache := range list {
+				size := formatSize(cache.Size)
+				if !cache.Complete {
+					size = "uploading"
+				}
+				version := cache.Version
+				if len(version) > 12 {
+					version = version[:12]
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", cache.ID, cache.Key, version, cache.Repository, cache.Scope, size, formatUnix(cache.UsedAt))
+			}
+			return w.Flush()
+		},
+	}
+
+	show := &cobra.Command{
+		Use:   "show ID",
+		Short: "Show all fields of a cache as JSON",
+		Args:  cobra.ExactArgs(1),
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, args []string) error {
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid cache id '%s'", args[0])
+			}
+			manager, err := openCacheManager(input)
+			if err != nil {
+				return err
+			}
+			cache, err := manager.Get(id)
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("cache '%d' not found", id)
+			} else if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(cache)
+		},
+	}
+
+	del := &cobra.Command{
+		Use:     "delete KEY|ID...",
+		Aliases: []string{"rm"},
+		Short:   "Delete the caches with the ids or keys, a key deletes the caches of all versions",
+		Args:    cobra.MinimumNArgs(1),
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, args []string) error {
+			manager, err := openCacheManager(input)
+			if err != nil {
+				return err
+			}
+			list, err := manager.List("")
+			if err != nil {
+				return err
+			}
+			selected, err := selectCaches(list, args)
+			if err != nil {
+				return err
+			}
+			if err := manager.Remove(selected...); err != nil {
+				return err
+			}
+			for _, cache := range selected {
+				fmt.Printf("Deleted cache %d '%s'\n", cache.ID, cache.Key)
+			}
+			return nil
+		},
+	}
+
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete the complete caches not used for --unused, then the least recently used ones until they fit in --max-size",
+		Args:  cobra.NoArgs,
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if cacheArgs.unused <= 0 && cacheArgs.maxSize <= 0 {
+				return fmt.Errorf("set --unused or --max-size")
+			}
+			manager, err := openCacheManager(input)
+			if err != nil {
+				return err
+			}
+			removed, err := manager.Prune(cacheArgs.unused, cacheArgs.maxSize<<20)
+			for _, cache := range removed {
+				fmt.Printf("Deleted cache %d '%s'\n", cache.ID, cache.Key)
+			}
+			return err
+		},
+	}
+	prune.Flags().DurationVar(&cacheArgs.unused, "unused", 0, "delete the caches not used for this duration, e.g. 168h")
+	prune.Flags().Int64Var(&cacheArgs.maxSize, "max-size", 0, "delete the least recently used caches until the total size in MiB doesn't exceed this")
+
+	cmd.AddCommand(list, show, del, prune)
+	return cmd
+}
+
+func openCacheManager(input *Input) (*artifactcache.Manager, error) {
+	if input.cacheServerPath == "" {
+		return nil, fmt.Errorf("the cache storage is not configured, set --cache-server-path")
+	}
+	return artifactcache.NewManager(input.cacheServerPath, input.cacheServerStorage)
+}
+
+// selectCaches returns the caches whose id or key is one of args
+func selectCaches(list []*artifactcache.Cache, args []string) ([]*artifactcache.Cache, error) {
+	selected := []*artifactcache.Cache{}
+	for _, arg := range args {
+		found := false
+		for _, cache := range list {
+			// the keys are stored in lower case
+			if strconv.FormatUint(cache.ID, 10) == arg || cache.Key == strings.ToLower(arg) {
+				selected = append(selected, cache)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("cache '%s' not found", arg)
+		}
+	}
+	return selected, nil
+}
+
+func formatUnix(sec int64) string {
+	if sec == 0 {
+		return "never"
+	}
+	return time.Unix(sec, 0).Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/nektos/act/pkg/artifactcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectCaches(t *testing.T) {
+	list := []*artifactcache.Cache{
+		{ID: 1, Key: "linux-deps", Version: "v1"},
+		{ID: 2, Key: "linux-deps", Version: "v2"},
+		{ID: 3, Key: "windows-deps", Version: "v1"},
+	}
+
+	selected, err := selectCaches(list, []string{"Linux-Deps"})
+	assert.NoError(t, err)
+	assert.Equal(t, list[:2], selected)
+
+	selected, err = selectCaches(list, []string{"3"})
+	assert.NoError(t, err)
+	assert.Equal(t, list[2:], selected)
+
+	_, err = selectCaches(list, []string{"missing"})
+	assert.EqualError(t, err, "cache 'missing' not found")
+}
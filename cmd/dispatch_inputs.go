@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/nektos/act/pkg/model"
+)
+
+// dispatchInputsSurvey prompts for every workflow_dispatch input of the planned workflows
+// which hasn't been passed already and stores the answers in inputs
+func dispatchInputsSurvey(plan *model.Plan, inputs map[string]string) error {
+	asked := map[*model.Workflow]bool{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if asked[run.Workflow] {
+				continue
+			}
+			asked[run.Workflow] = true
+
+			config := run.Workflow.WorkflowDispatchConfig()
+			if config == nil || len(config.Inputs) == 0 {
+				continue
+			}
+
+			names := make([]string, 0, len(config.Inputs))
+			for name := range config.Inputs {
+				if _, ok := inputs[name]; !ok {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				answer, err := askDispatchInput(name, config.Inputs[name])
+				if err != nil {
+					return err
+				}
+				inputs[name] = answer
+			}
+		}
+	}
+	return nil
+}
+
+func askDispatchInput(name string, input model.WorkflowDispatchInput) (string, error) {
+	prompt := newDispatchInputPrompt(name, input)
+
+	switch prompt := prompt.(type) {
+	case *survey.Confirm:
+		var answer bool
+		if err := survey.AskOne(prompt, &answer); err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(answer), nil
+	case *survey.Select:
+		var answer string
+		if err := survey.AskOne(prompt, &answer); err != nil {
+			return "", err
+		}
+		return answer, nil
+	}
+
+	var answer string
+	opts := []survey.AskOpt{
+		survey.WithValidator(func(ans interface{}) error {
+			if ans == "" {
+				if input.Required && input.Default == "" {
+					return fmt.Errorf("input '%s' is required", name)
+				}
+				return nil
+			}
+			_, err := input.Coerce(ans)
+			return err
+		}),
+	}
+	if err := survey.AskOne(prompt, &answer, opts...); err != nil {
+		return "", err
+	}
+	if answer == "" {
+		answer = input.Default
+	}
+	return answer, nil
+}
+
+// newDispatchInputPrompt creates the prompt matching the type of the input
+func newDispatchInputPrompt(name string, input model.WorkflowDispatchInput) survey.Prompt {
+	inputType := input.Type
+	if inputType == "" {
+		inputType = "string"
+	}
+	message := fmt.Sprintf("%s [%s]", name, inputType)
+	if input.Required {
+		message += " *"
+	}
+
+	switch input.Type {
+	case "boolean":
+		def, _ := strconv.ParseBool(input.Default)
+		return &survey.Confirm{
+			Message: message,
+			Help:    input.Description,
+			Default: def,
+		}
+	case "choice":
+		sel := &survey.Select{
+			Message: message,
+			Help:    input.Description,
+			Options: input.Options,
+		}
+		for _, option := range input.Options {
+			if option == input.Default {
+				sel.Default = input.Default
+			}
+		}
+		return sel
+	default:
+		return &survey.Input{
+			Message: message,
+			Help:    input.Description,
+			Default: input.Default,
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestNewDispatchInputPrompt(t *testing.T) {
+	prompt := newDispatchInputPrompt("debug", model.WorkflowDispatchInput{
+		Description: "enable debug logging",
+		Type:        "boolean",
+		Default:     "true",
+	})
+	assert.Equal(t, &survey.Confirm{
+		Message: "debug [boolean]",
+		Help:    "enable debug logging",
+		Default: true,
+	}, prompt)
+
+	prompt = newDispatchInputPrompt("level", model.WorkflowDispatchInput{
+		Required: true,
+		Type:     "choice",
+		Default:  "warning",
+		Options:  []string{"info", "warning"},
+	})
+	assert.Equal(t, &survey.Select{
+		Message: "level [choice] *",
+		Options: []string{"info", "warning"},
+		Default: "warning",
+	}, prompt)
+
+	prompt = newDispatchInputPrompt("name", model.WorkflowDispatchInput{
+		Description: "who to greet",
+		Default:     "world",
+	})
+	assert.Equal(t, &survey.Input{
+		Message: "name [string]",
+		Help:    "who to greet",
+		Default: "world",
+	}, prompt)
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type evalInput struct {
+	job       string
+	eventName string
+	needsFile string
+}
+
+func newEvalCommand(ctx context.Context, input *Input) *cobra.Command {
+	eval := &evalInput{}
+	cmd := &cobra.Command{
+		Use:   "eval [expression...]",
+		Short: "Evaluate expressions with the contexts of a job, reads expressions from stdin if none are passed",
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runEval(common.WithLogger(ctx, log.StandardLogger()), input, eval, args)
+		},
+	}
+	cmd.Flags().StringVarP(&eval.job, "job", "j", "", "job ID whose contexts are used")
+	cmd.Flags().StringVar(&eval.eventName, "event", "", "name of the event that triggered the workflow, defaults to the only event of the workflow or push")
+	cmd.Flags().StringVar(&eval.needsFile, "needs", "", "JSON file with the needs context, e.g. {\"build\": {\"result\": \"success\", \"outputs\": {\"version\": \"1.0.0\"}}}")
+	cmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret to make available to actions with optional value (e.g. -s mysecret=foo or -s mysecret)")
+	cmd.Flags().StringArrayVar(&input.vars, "var", []string{}, "variable to make available to actions with optional value (e.g. --var myvar=foo or --var myvar)")
+	cmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env myenv=foo or --env myenv)")
+	cmd.Flags().StringArrayVarP(&input.inputs, "input", "", []string{}, "action input to make available to actions (e.g. --input myinput=foo)")
+	cmd.Flags().StringVarP(&input.eventPath, "eventpath", "e", "", "path to event JSON file")
+	cmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "specify which matrix configuration to include (e.g. --matrix java:13")
+	_ = cmd.MarkFlagRequired("job")
+	return cmd
+}
+
+func runEval(ctx context.Context, input *Input, eval *evalInput, args []string) error {
+	planner, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse)
+	if err != nil {
+		return err
+	}
+	plan, err := planner.PlanJob(eval.job)
+	if plan == nil {
+		return err
+	}
+	var run *model.Run
+	for _, stage := range plan.Stages {
+		for _, r := range stage.Runs {
+			if r.JobID != eval.job {
+				continue
+			}
+			if run != nil {
+				return fmt.Errorf("job '%s' is defined in %s and %s, select the workflow with --workflows", eval.job, run.Workflow.File, r.Workflow.File)
+			}
+			run = r
+		}
+	}
+	if run == nil {
+		return fmt.Errorf("job '%s' not found in %s", eval.job, input.WorkflowsPath())
+	}
+
+	eventName := eval.eventName
+	if eventName == "" {
+		eventName = "push"
+		if events := run.Workflow.On(); len(events) == 1 {
+			eventName = events[0]
+		}
+	}
+
+	needs := map[string]exprparser.Needs{}
+	if eval.needsFile != "" {
+		content, err := os.ReadFile(eval.needsFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(content, &needs); err != nil {
+			return fmt.Errorf("failed to read needs from %s: %w", eval.needsFile, err)
+		}
+	}
+
+	envs := parseEnvs(input.envs)
+	_ = readEnvs(input.Envfile(), envs)
+	inputs := parseEnvs(input.inputs)
+	_ = readEnvs(input.Inputfile(), inputs)
+	secrets := newSecrets(input.secrets)
+	_ = readEnvsEx(input.Secretfile(), secrets, true)
+	vars := newSecrets(input.vars)
+	_ = readEnvs(input.Varfile(), vars)
+
+	config := &runner.Config{
+		Actor:          input.actor,
+		EventName:      eventName,
+		EventPath:      input.EventPath(),
+		Workdir:        input.Workdir(),
+		Env:            envs,
+		Secrets:        secrets,
+		Vars:           vars,
+		Inputs:         inputs,
+		GitHubInstance: input.githubInstance,
+		RemoteName:     "origin",
+		Matrix:         parseMatrix(input.matrix),
+	}
+	environments, err := runner.NewJobEvaluationEnvironments(ctx, config, run, needs)
+	if err != nil {
+		return err
+	}
+
+	interpreters := make([]exprparser.Interpreter, len(environments))
+	for i, env := range environments {
+		interpreters[i] = exprparser.NewInterpeter(env.EvaluationEnvironment, exprparser.Config{
+			Run:        run,
+			WorkingDir: config.Workdir,
+			Context:    "job",
+		})
+	}
+	evaluate := func(w io.Writer, expression string) error {
+		var lastErr error
+		for i, interpreter := range interpreters {
+			if len(interpreters) > 1 {
+				fmt.Fprintf(w, "%s: ", formatMatrix(environments[i].Matrix))
+			}
+			result, err := evaluateExpression(interpreter, expression)
+			if err != nil {
+				fmt.Fprintf(w, "error: %v\n", err)
+				lastErr = err
+				continue
+			}
+			fmt.Fprintln(w, formatEvalResult(result))
+		}
+		return lastErr
+	}
+
+	if len(args) > 0 {
+		failed := 0
+		for _, expression := range args {
+			if err := evaluate(os.Stdout, expression); err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to evaluate %d of %d expressions", failed, len(args))
+		}
+		return nil
+	}
+
+	interactive := isatty.IsTerminal(os.Stdin.Fd())
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if interactive {
+			fmt.Print("> ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		if expression := strings.TrimSpace(scanner.Text()); expression != "" {
+			_ = evaluate(os.Stdout, expression)
+		}
+	}
+	if interactive {
+		fmt.Println()
+	}
+	return scanner.Err()
+}
+
+// evaluateExpression evaluates an expression with or without the ${{ }} delimiters
+func evaluateExpression(interpreter exprparser.Interpreter, expression string) (interface{}, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "${{") && strings.HasSuffix(expression, "}}") {
+		expression = strings.TrimSuffix(strings.TrimPrefix(expression, "${{"), "}}")
+	}
+	return interpreter.Evaluate(expression, exprparser.DefaultStatusCheckNone)
+}
+
+// formatEvalResult formats a value as JSON followed by its expression type
+func formatEvalResult(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	kind := "object"
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Bool:
+		kind = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		kind = "number"
+	case reflect.String:
+		kind = "string"
+	case reflect.Slice, reflect.Array:
+		kind = "array"
+	}
+	formatted, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v (%s)", value, kind)
+	}
+	return fmt.Sprintf("%s (%s)", formatted, kind)
+}
+
+func formatMatrix(matrix map[string]interface{}) string {
+	keys := make([]string, 0, len(matrix))
+	for key := range matrix {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = fmt.Sprintf("%s:%v", key, matrix[key])
+	}
+	return strings.Join(values, " ")
+}
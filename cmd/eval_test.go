@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatEvalResult(t *testing.T) {
+	table := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true (boolean)"},
+		{float64(1.5), "1.5 (number)"},
+		{"text", "\"text\" (string)"},
+		{[]interface{}{"a"}, "[\n  \"a\"\n] (array)"},
+		{map[string]interface{}{"a": 1}, "{\n  \"a\": 1\n} (object)"},
+	}
+	for _, tt := range table {
+		assert.Equal(t, tt.expected, formatEvalResult(tt.value))
+	}
+}
+
+func TestEvaluateExpression(t *testing.T) {
+	interpreter := exprparser.NewInterpeter(&exprparser.EvaluationEnvironment{
+		Matrix: map[string]interface{}{"os": "linux"},
+	}, exprparser.Config{})
+
+	for _, expression := range []string{"matrix.os", "${{ matrix.os }}", "  ${{matrix.os}} "} {
+		result, err := evaluateExpression(interpreter, expression)
+		assert.NoError(t, err)
+		assert.Equal(t, "linux", result)
+	}
+
+	_, err := evaluateExpression(interpreter, "matrix.os ==")
+	assert.Error(t, err)
+}
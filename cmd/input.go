@@ -19,6 +19,8 @@ type Input struct {
 	vars                               []string
 	envs                               []string
 	inputs                             []string
+	noExpressionCheck                  bool
+	interactiveInputs                  bool
 	platforms                          []string
 	dryrun                             bool
 	forcePull                          bool
@@ -34,6 +36,8 @@ type Input struct {
 	usernsMode                         string
 	containerArchitecture              string
 	containerDaemonSocket              string
+	containerEngine                    string
+	kubernetesNamespace                string
 	containerOptions                   string
 	noWorkflowRecurse                  bool
 	useGitIgnore                       bool
@@ -44,11 +48,18 @@ type Input struct {
 	artifactServerPath                 string
 	artifactServerAddr                 string
 	artifactServerPort                 string
+	artifactRetentionDays              int
+	artifactServerMaxSize              int64
+	artifactServerGitHubAPI            bool
 	noCacheServer                      bool
 	cacheServerPath                    string
+	cacheServerStorage                 string
+	cacheServerMaxSize                 int64
 	cacheServerExternalURL             string
 	cacheServerAddr                    string
 	cacheServerPort                    uint16
+	cacheServiceV2                     bool
+	runtimeTokenKey                    string
 	jsonLogger                         bool
 	noSkipCheckout                     bool
 	remoteName                         string
@@ -62,6 +73,7 @@ type Input struct {
 	useNewActionCache                  bool
 	localRepository                    []string
 	listOptions                        bool
+	lockFile                           string
 }
 
 func (i *Input) resolve(path string) string {
@@ -111,3 +123,8 @@ func (i *Input) EventPath() string {
 func (i *Input) Inputfile() string {
 	return i.resolve(i.inputfile)
 }
+
+// LockFile returns the path to the action lock file
+func (i *Input) LockFile() string {
+	return i.resolve(i.lockFile)
+}
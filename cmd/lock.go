@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/gh"
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newLockCommand(ctx context.Context, input *Input) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lock",
+		Short: "Pin the refs of all used actions and reusable workflows to commit shas in the action lock file",
+		Args:  cobra.NoArgs,
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			workflows, err := loadWorkflows(input)
+			if err != nil {
+				return err
+			}
+
+			lock := runner.ActionLock{}
+			walker := &runner.UsesWalker{
+				Cache:     newBaseActionCache(input),
+				Workdir:   input.Workdir(),
+				ServerURL: serverURL(input),
+				Token:     readToken(ctx, input),
+				Visit: func(_ context.Context, uses runner.RemoteUses) error {
+					log.Infof("Pinned %s to %s", uses.Key(), uses.SHA)
+					lock[uses.Key()] = uses.SHA
+					return nil
+				},
+			}
+			if err := walker.Walk(common.WithLogger(ctx, log.StandardLogger()), workflows); err != nil {
+				return err
+			}
+
+			if err := lock.Write(input.LockFile()); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %d entries to %s\n", len(lock), input.LockFile())
+			return nil
+		},
+	}
+}
+
+// loadWorkflows reads all workflows of the workflows path
+func loadWorkflows(input *Input) ([]*model.Workflow, error) {
+	planner, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planner.PlanAll()
+	if plan == nil {
+		return nil, err
+	}
+	workflows := []*model.Workflow{}
+	seen := map[*model.Workflow]bool{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if !seen[run.Workflow] {
+				seen[run.Workflow] = true
+				workflows = append(workflows, run.Workflow)
+			}
+		}
+	}
+	return workflows, err
+}
+
+// newBaseActionCache returns the git based action cache honoring --action-offline-mode
+func newBaseActionCache(input *Input) runner.ActionCache {
+	cache := runner.GoGitActionCache{
+		Path: input.actionCachePath,
+	}
+	if input.actionOfflineMode {
+		return &runner.GoGitActionCacheOfflineMode{
+			Parent: cache,
+		}
+	}
+	return &cache
+}
+
+func serverURL(input *Input) string {
+	if input.githubInstance != "" && input.githubInstance != "github.com" {
+		return fmt.Sprintf("https://%s", input.githubInstance)
+	}
+	return "https://github.com"
+}
+
+// readToken looks up the GITHUB_TOKEN in the secret file, the environment and the gh cli
+func readToken(ctx context.Context, input *Input) string {
+	secrets := newSecrets(nil)
+	_ = readEnvsEx(input.Secretfile(), secrets, true)
+	if token, ok := secrets["GITHUB_TOKEN"]; ok {
+		return token
+	}
+	if token, ok := os.LookupEnv("GITHUB_TOKEN"); ok {
+		return token
+	}
+	ctx, cancel := common.EarlyCancelContext(ctx)
+	defer cancel()
+	token, _ := gh.GetToken(ctx, "")
+	return token
+}
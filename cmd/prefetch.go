@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/runner"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type prefetchInput struct {
+	pullImages bool
+	saveImages string
+}
+
+func newPrefetchCommand(ctx context.Context, input *Input) *cobra.Command {
+	prefetch := &prefetchInput{}
+	cmd := &cobra.Command{
+		Use:   "prefetch",
+		Short: "Fetch all used actions and reusable workflows into the action cache for later runs with --action-offline-mode",
+		Args:  cobra.NoArgs,
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runPrefetch(common.WithLogger(ctx, log.StandardLogger()), input, prefetch)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
+	cmd.Flags().BoolVar(&prefetch.pullImages, "pull-images", false, "pull the docker images used by the workflows")
+	cmd.Flags().StringVar(&prefetch.saveImages, "save-images", "", "save the docker images used by the workflows to a tarball, implies --pull-images")
+	return cmd
+}
+
+func runPrefetch(ctx context.Context, input *Input, prefetch *prefetchInput) error {
+	workflows, err := loadWorkflows(input)
+	if err != nil {
+		return err
+	}
+
+	// the refs are stored by the offline mode cache, so later offline runs can resolve them without network access
+	var cache runner.ActionCache = &runner.GoGitActionCacheOfflineMode{
+		Parent: runner.GoGitActionCache{
+			Path: input.actionCachePath,
+		},
+		Refresh: true,
+	}
+	lock, err := runner.ReadActionLock(input.LockFile())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if lock != nil {
+		cache = &runner.LockedActionCache{
+			Parent: cache,
+			Lock:   lock,
+		}
+	}
+
+	actions := 0
+	images := []string{}
+	walker := &runner.UsesWalker{
+		Cache:     cache,
+		Workdir:   input.Workdir(),
+		ServerURL: serverURL(input),
+		Token:     readToken(ctx, input),
+		Platforms: input.newPlatforms(),
+		Visit: func(_ context.Context, uses runner.RemoteUses) error {
+			log.Infof("Fetched %s (%s)", uses.Uses, uses.SHA)
+			actions++
+			return nil
+		},
+		VisitImage: func(_ context.Context, image string) error {
+			images = append(images, image)
+			return nil
+		},
+	}
+	if err := walker.Walk(ctx, workflows); err != nil {
+		return err
+	}
+	fmt.Printf("Fetched %d actions and reusable workflows into %s\n", actions, input.actionCachePath)
+
+	if prefetch.pullImages || prefetch.saveImages != "" {
+		pulls := make([]common.Executor, 0, len(images))
+		for _, image := range images {
+			pulls = append(pulls, container.NewDockerPullExecutor(container.NewDockerPullExecutorInput{
+				Image:    image,
+				Platform: input.containerArchitecture,
+			}))
+		}
+		if err := common.NewPipelineExecutor(pulls...)(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("Pulled %d images\n", len(images))
+	}
+	if prefetch.saveImages != "" && len(images) > 0 {
+		if err := container.NewDockerImageSaveExecutor(images, prefetch.saveImages)(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %d images to %s, load them with `docker load -i %s`\n", len(images), prefetch.saveImages, prefetch.saveImages)
+	}
+	return nil
+}
@@ -4,8 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -77,6 +82,7 @@ func createRootCommand(ctx context.Context, input *Input, version string) *cobra
 	rootCmd.Flags().StringArrayVar(&input.vars, "var", []string{}, "variable to make available to actions with optional value (e.g. --var myvar=foo or --var myvar)")
 	rootCmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env myenv=foo or --env myenv)")
 	rootCmd.Flags().StringArrayVarP(&input.inputs, "input", "", []string{}, "action input to make available to actions (e.g. --input myinput=foo)")
+	rootCmd.Flags().BoolVarP(&input.interactiveInputs, "interactive-inputs", "", false, "prompt for the workflow_dispatch inputs not passed with --input or --input-file")
 	rootCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
 	rootCmd.Flags().BoolVarP(&input.reuseContainers, "reuse", "r", false, "don't remove container(s) on successfully completed workflow(s) to maintain state between runs")
 	rootCmd.Flags().BoolVarP(&input.bindWorkdir, "bind", "b", false, "bind working directory to container, rather than copy")
@@ -94,6 +100,7 @@ func createRootCommand(ctx context.Context, input *Input, version string) *cobra
 	rootCmd.Flags().StringArrayVarP(&input.replaceGheActionWithGithubCom, "replace-ghe-action-with-github-com", "", []string{}, "If you are using GitHub Enterprise Server and allow specified actions from GitHub (github.com), you can set actions on this. (e.g. --replace-ghe-action-with-github-com =github/super-linter)")
 	rootCmd.Flags().StringVar(&input.replaceGheActionTokenWithGithubCom, "replace-ghe-action-token-with-github-com", "", "If you are using replace-ghe-action-with-github-com  and you want to use private actions on GitHub, you have to set personal access token")
 	rootCmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "specify which matrix configuration to include (e.g. --matrix java:13")
+	rootCmd.Flags().BoolVar(&input.noExpressionCheck, "no-expression-check", false, "Disable the check for unknown contexts, functions, step ids and needs in expressions before running")
 	rootCmd.PersistentFlags().StringVarP(&input.actor, "actor", "a", "nektos/act", "user that triggered the event")
 	rootCmd.PersistentFlags().StringVarP(&input.workflowsPath, "workflows", "W", "./.github/workflows/", "path to workflow file(s)")
 	rootCmd.PersistentFlags().BoolVarP(&input.noWorkflowRecurse, "no-recurse", "", false, "Flag to disable running workflows from subdirectories of specified path in '--workflows'/'-W' flag")
@@ -110,23 +117,40 @@ func createRootCommand(ctx context.Context, input *Input, version string) *cobra
 	rootCmd.PersistentFlags().StringVarP(&input.inputfile, "input-file", "", ".input", "input file to read and use as action input")
 	rootCmd.PersistentFlags().StringVarP(&input.containerArchitecture, "container-architecture", "", "", "Architecture which should be used to run containers, e.g.: linux/amd64. If not specified, will use host default architecture. Requires Docker server API Version 1.41+. Ignored on earlier Docker server platforms.")
 	rootCmd.PersistentFlags().StringVarP(&input.containerDaemonSocket, "container-daemon-socket", "", "", "URI to Docker Engine socket (e.g.: unix://~/.docker/run/docker.sock or - to disable bind mounting the socket)")
+	rootCmd.PersistentFlags().StringVarP(&input.containerEngine, "container-engine", "", "auto", "container engine to look for if DOCKER_HOST isn't set: auto, docker or podman, podman also honors CONTAINER_HOST. The owner of a bound working directory isn't changed on a rootless engine, a rootless podman maps the user of the host to a numeric user of the job container. namespaces runs the jobs without a daemon in Linux namespaces, in images of OCI image layouts like -P ubuntu-latest=oci:DIR:REF. kubernetes runs the jobs as pods in the cluster of the current kubeconfig context")
+	rootCmd.PersistentFlags().StringVarP(&input.kubernetesNamespace, "kubernetes-namespace", "", "", "namespace of the pods of the kubernetes container engine, defaults to the one of the kubeconfig context")
 	rootCmd.PersistentFlags().StringVarP(&input.containerOptions, "container-options", "", "", "Custom docker container options for the job container without an options property in the job definition")
 	rootCmd.PersistentFlags().StringVarP(&input.githubInstance, "github-instance", "", "github.com", "GitHub instance to use. Only use this when using GitHub Enterprise Server.")
-	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPath, "artifact-server-path", "", "", "Defines the path where the artifact server stores uploads and retrieves downloads from. If not specified the artifact server will not start.")
+	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPath, "artifact-server-path", "", "", "Defines the path where the artifact server stores uploads and retrieves downloads from, or the url of a storage backend: file://{path}, memory://, s3://{bucket}/{prefix}?endpoint={url}&region={region} or a WebDAV server http(s)://{host}/{path}. If not specified the artifact server will not start.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerAddr, "artifact-server-addr", "", common.GetOutboundIP().String(), "Defines the address to which the artifact server binds.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPort, "artifact-server-port", "", "34567", "Defines the port where the artifact server listens.")
+	rootCmd.PersistentFlags().IntVarP(&input.artifactRetentionDays, "artifact-retention-days", "", 0, "Defines the number of days after which artifacts uploaded without retention-days are deleted. 0 means artifacts are kept forever.")
+	rootCmd.PersistentFlags().BoolVarP(&input.artifactServerGitHubAPI, "artifact-server-github-api", "", false, "Sets GITHUB_API_URL to the artifact server, which serves the artifacts of earlier runs to actions/download-artifact with run-id and forwards all other requests of the GitHub API to the GitHub instance. The requests have to pass the GITHUB_TOKEN secret or the runtime token of the run.")
+	rootCmd.PersistentFlags().Int64VarP(&input.artifactServerMaxSize, "artifact-server-max-size", "", 0, "Defines the quota in MiB for the total size of the artifacts, the oldest artifacts are deleted if it is exceeded. 0 means no quota.")
 	rootCmd.PersistentFlags().BoolVarP(&input.noSkipCheckout, "no-skip-checkout", "", false, "Use actions/checkout instead of copying local files into container")
-	rootCmd.PersistentFlags().BoolVarP(&input.noCacheServer, "no-cache-server", "", false, "Disable cache server")
+	rootCmd.PersistentFlags().BoolVarP(&input.noCacheServer, "no-cache-server", "", false, "Disable cache server, the caches are stored by the server at --cache-server-external-url instead if it is set, e.g. one started by act serve")
 	rootCmd.PersistentFlags().StringVarP(&input.cacheServerPath, "cache-server-path", "", filepath.Join(CacheHomeDir, "actcache"), "Defines the path where the cache server stores caches.")
+	rootCmd.PersistentFlags().StringVarP(&input.cacheServerStorage, "cache-server-storage", "", "", "Defines a storage shared by several cache servers, s3://[key:secret@]bucket/prefix[?endpoint=...&region=...] or a WebDAV server http(s)://[user:password@]host/path. The index and uploading caches are kept in --cache-server-path.")
+	rootCmd.PersistentFlags().Int64VarP(&input.cacheServerMaxSize, "cache-server-max-size", "", 10240, "Defines the quota in MiB for the caches of each repository like the 10 GB of GitHub, the least recently used caches are evicted if it is exceeded. 0 means no quota. The archives of --cache-server-storage are only forgotten, not deleted.")
 	rootCmd.PersistentFlags().StringVarP(&input.cacheServerExternalURL, "cache-server-external-url", "", "", "Defines the external URL for if the cache server is behind a proxy. e.g.: https://act-cache-server.example.com. Be careful that there is no trailing slash.")
 	rootCmd.PersistentFlags().StringVarP(&input.cacheServerAddr, "cache-server-addr", "", common.GetOutboundIP().String(), "Defines the address to which the cache server binds.")
 	rootCmd.PersistentFlags().Uint16VarP(&input.cacheServerPort, "cache-server-port", "", 0, "Defines the port where the artifact server listens. 0 means a randomly available port.")
+	rootCmd.PersistentFlags().BoolVarP(&input.cacheServiceV2, "cache-service-v2", "", false, "Sets ACTIONS_RESULTS_URL to the cache server and ACTIONS_CACHE_SERVICE_V2, the cache server serves the cache service v2 and forwards the requests of the artifact service v4 to the artifact server.")
+	rootCmd.PersistentFlags().StringVarP(&input.runtimeTokenKey, "runtime-token-key", "", "", "Defines the key signing the ACTIONS_RUNTIME_TOKEN of the jobs instead of a random key per run. Pass the same key to act serve and the runs using it, so the shared server verifies the repository and the branch of the caches.")
 	rootCmd.PersistentFlags().StringVarP(&input.actionCachePath, "action-cache-path", "", filepath.Join(CacheHomeDir, "act"), "Defines the path where the actions get cached and host workspaces created.")
 	rootCmd.PersistentFlags().BoolVarP(&input.actionOfflineMode, "action-offline-mode", "", false, "If action contents exists, it will not be fetch and pull again. If turn on this, will turn off force pull")
 	rootCmd.PersistentFlags().StringVarP(&input.networkName, "network", "", "host", "Sets a docker network name. Defaults to host.")
 	rootCmd.PersistentFlags().BoolVarP(&input.useNewActionCache, "use-new-action-cache", "", false, "Enable using the new Action Cache for storing Actions locally")
+	_ = rootCmd.PersistentFlags().MarkDeprecated("use-new-action-cache", "the actions are always stored in the action cache")
 	rootCmd.PersistentFlags().StringArrayVarP(&input.localRepository, "local-repository", "", []string{}, "Replaces the specified repository and ref with a local folder (e.g. https://github.com/test/test@v0=/home/act/test or test/test@v0=/home/act/test, the latter matches any hosts or protocols)")
 	rootCmd.PersistentFlags().BoolVar(&input.listOptions, "list-options", false, "Print a json structure of compatible options")
+	rootCmd.PersistentFlags().StringVarP(&input.lockFile, "lock-file", "", "act.lock", "action lock file pinning the refs of actions and reusable workflows, it is used if it exists and written by `act lock`")
+	rootCmd.AddCommand(newLockCommand(ctx, input))
+	rootCmd.AddCommand(newPrefetchCommand(ctx, input))
+	rootCmd.AddCommand(newEvalCommand(ctx, input))
+	rootCmd.AddCommand(newArtifactsCommand(input))
+	rootCmd.AddCommand(newCacheCommand(input))
+	rootCmd.AddCommand(newServeCommand(ctx, input))
 	rootCmd.SetArgs(args())
 	return rootCmd
 }
@@ -383,6 +407,29 @@ func parseMatrix(matrix []string) map[string]map[string]bool {
 	return matrixes
 }
 
+// checkPlanExpressions statically checks the expressions of all workflows of the plan,
+// it logs the warnings and returns the errors
+func checkPlanExpressions(plan *model.Plan) error {
+	var allErrors error
+	checked := map[*model.Workflow]bool{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if checked[run.Workflow] {
+				continue
+			}
+			checked[run.Workflow] = true
+			warnings, err := run.Workflow.CheckExpressions()
+			for _, warning := range warnings {
+				log.Warnf("workflow '%s': %s", run.Workflow.File, warning)
+			}
+			if err != nil {
+				allErrors = errors.Join(allErrors, fmt.Errorf("invalid expressions in workflow '%s':\n%w", run.Workflow.File, err))
+			}
+		}
+	}
+	return allErrors
+}
+
 //nolint:gocyclo
 func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
@@ -402,12 +449,38 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			return listOptions(cmd)
 		}
 
-		if ret, err := container.GetSocketAndHost(input.containerDaemonSocket); err != nil {
+		engine, err := container.ParseEngine(input.containerEngine)
+		if err != nil {
+			return err
+		}
+		var kubernetes *container.KubernetesConfig
+		if engine == container.EngineNamespaces {
+			if runtime.GOOS != "linux" {
+				return fmt.Errorf("the namespaces container engine is only supported on Linux")
+			}
+			// there is no daemon to bind into the job containers
+			input.containerDaemonSocket = "-"
+		} else if engine == container.EngineKubernetes {
+			input.containerDaemonSocket = "-"
+			if kubernetes, err = container.NewKubernetesConfig(input.kubernetesNamespace); err != nil {
+				return err
+			}
+			log.Infof("Using the kubernetes namespace '%s'", kubernetes.Namespace)
+		} else if ret, err := container.GetEngineSocketAndHost(engine, input.containerDaemonSocket); err != nil {
 			log.Warnf("Couldn't get a valid docker connection: %+v", err)
 		} else {
 			os.Setenv("DOCKER_HOST", ret.Host)
 			input.containerDaemonSocket = ret.Socket
 			log.Infof("Using docker host '%s', and daemon socket '%s'", ret.Host, ret.Socket)
+			if engine != container.EngineAuto {
+				if info, err := container.GetEngineInfo(ctx); err != nil {
+					log.Warnf("Couldn't get the container engine info: %v", err)
+				} else if info.Engine != engine {
+					log.Warnf("The container engine at '%s' is %s, not %s", ret.Host, info.Engine, engine)
+				} else if info.Rootless {
+					log.Infof("Using rootless %s", info.Engine)
+				}
+			}
 		}
 
 		if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" && input.containerArchitecture == "" {
@@ -555,6 +628,20 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			return plannerErr
 		}
 
+		if !input.noExpressionCheck && plan != nil {
+			if err := checkPlanExpressions(plan); err != nil {
+				return err
+			}
+		}
+
+		if input.interactiveInputs && eventName == "workflow_dispatch" && plan != nil {
+			if input.eventPath != "" {
+				log.Warnf("--interactive-inputs is ignored when an event file is passed with --eventpath")
+			} else if err := dispatchInputsSurvey(plan, inputs); err != nil {
+				return err
+			}
+		}
+
 		// check to see if the main branch was defined
 		defaultbranch, err := cmd.Flags().GetString("defaultbranch")
 		if err != nil {
@@ -593,6 +680,35 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			log.Warnf(deprecationWarning, "container-cap-drop", fmt.Sprintf("--cap-drop=%s", input.containerCapDrop))
 		}
 
+		if !input.dryrun {
+			if err := assignRunIDs(runCountersPath(), input.Workdir(), envs); err != nil {
+				log.Warnf("Unable to assign a unique run id: %v", err)
+			}
+		}
+
+		// the artifact server serves the artifacts of earlier runs to actions/download-artifact with run-id,
+		// it only takes over the GitHub API if asked to since it sees all requests of the API
+		upstreamAPIURL := ""
+		if input.artifactServerGitHubAPI && input.artifactServerPath != "" && envs["GITHUB_API_URL"] == "" {
+			upstreamAPIURL = "https://api.github.com"
+			if input.githubInstance != "github.com" {
+				upstreamAPIURL = fmt.Sprintf("https://%s/api/v3", input.githubInstance)
+			}
+			envs["GITHUB_API_URL"] = fmt.Sprintf("http://%s:%s", input.artifactServerAddr, input.artifactServerPort)
+		}
+
+		// the servers of the run only accept the runtime tokens of its jobs, unless the token is passed by the
+		// environment, e.g. the token of a server started by act serve, or the key is shared with act serve
+		var runtimeTokenKey []byte
+		if input.runtimeTokenKey != "" {
+			runtimeTokenKey = []byte(input.runtimeTokenKey)
+		} else if os.Getenv("ACTIONS_RUNTIME_TOKEN") == "" {
+			runtimeTokenKey = make([]byte, 32)
+			if _, err := rand.Read(runtimeTokenKey); err != nil {
+				return err
+			}
+		}
+
 		// run the plan
 		config := &runner.Config{
 			Actor:                              input.actor,
@@ -620,6 +736,8 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			UsernsMode:                         input.usernsMode,
 			ContainerArchitecture:              input.containerArchitecture,
 			ContainerDaemonSocket:              input.containerDaemonSocket,
+			ContainerEngine:                    engine,
+			Kubernetes:                         kubernetes,
 			ContainerOptions:                   input.containerOptions,
 			UseGitIgnore:                       input.useGitIgnore,
 			GitHubInstance:                     input.githubInstance,
@@ -629,6 +747,7 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			ArtifactServerPath:                 input.artifactServerPath,
 			ArtifactServerAddr:                 input.artifactServerAddr,
 			ArtifactServerPort:                 input.artifactServerPort,
+			RuntimeTokenKey:                    runtimeTokenKey,
 			NoSkipCheckout:                     input.noSkipCheckout,
 			RemoteName:                         input.remoteName,
 			ReplaceGheActionWithGithubCom:      input.replaceGheActionWithGithubCom,
@@ -636,29 +755,28 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			Matrix:                             matrixes,
 			ContainerNetworkMode:               docker_container.NetworkMode(input.networkName),
 		}
-		if input.useNewActionCache || len(input.localRepository) > 0 {
-			if input.actionOfflineMode {
-				config.ActionCache = &runner.GoGitActionCacheOfflineMode{
-					Parent: runner.GoGitActionCache{
-						Path: config.ActionCacheDir,
-					},
-				}
-			} else {
-				config.ActionCache = &runner.GoGitActionCache{
-					Path: config.ActionCacheDir,
-				}
+		lock, err := runner.ReadActionLock(input.LockFile())
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		config.ActionCache = newBaseActionCache(input)
+		if lock != nil {
+			log.Debugf("Using action lock file %s", input.LockFile())
+			config.ActionCache = &runner.LockedActionCache{
+				Parent: config.ActionCache,
+				Lock:   lock,
 			}
-			if len(input.localRepository) > 0 {
-				localRepositories := map[string]string{}
-				for _, l := range input.localRepository {
-					k, v, _ := strings.Cut(l, "=")
-					localRepositories[k] = v
-				}
-				config.ActionCache = &runner.LocalRepositoryCache{
-					Parent:            config.ActionCache,
-					LocalRepositories: localRepositories,
-					CacheDirCache:     map[string]string{},
-				}
+		}
+		if len(input.localRepository) > 0 {
+			localRepositories := map[string]string{}
+			for _, l := range input.localRepository {
+				k, v, _ := strings.Cut(l, "=")
+				localRepositories[k] = v
+			}
+			config.ActionCache = &runner.LocalRepositoryCache{
+				Parent:            config.ActionCache,
+				LocalRepositories: localRepositories,
+				CacheDirCache:     map[string]string{},
 			}
 		}
 		r, err := runner.New(config)
@@ -666,17 +784,42 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			return err
 		}
 
-		cancel := artifacts.Serve(ctx, input.artifactServerPath, input.artifactServerAddr, input.artifactServerPort)
+		cancel, err := artifacts.Serve(ctx, input.artifactServerPath, input.artifactServerAddr, input.artifactServerPort, artifacts.Limits{
+			RetentionDays: input.artifactRetentionDays,
+			MaxSize:       input.artifactServerMaxSize << 20,
+		}, upstreamAPIURL, secrets["GITHUB_TOKEN"], runtimeTokenKey)
+		if err != nil {
+			return err
+		}
 
 		const cacheURLKey = "ACTIONS_CACHE_URL"
 		var cacheHandler *artifactcache.Handler
 		if !input.noCacheServer && envs[cacheURLKey] == "" {
 			var err error
-			cacheHandler, err = artifactcache.StartHandler(input.cacheServerPath, input.cacheServerExternalURL, input.cacheServerAddr, input.cacheServerPort, common.Logger(ctx))
+			cacheHandler, err = artifactcache.StartHandler(input.cacheServerPath, input.cacheServerStorage, input.cacheServerMaxSize<<20, input.cacheServerExternalURL, input.cacheServerAddr, input.cacheServerPort, common.Logger(ctx))
 			if err != nil {
 				return err
 			}
+			if runtimeTokenKey != nil {
+				cacheHandler.RequireRuntimeToken(runtimeTokenKey)
+			}
 			envs[cacheURLKey] = cacheHandler.ExternalURL() + "/"
+			// the cache service v2 is served at the results url, which the cache server shares with the artifact server
+			if input.cacheServiceV2 && envs["ACTIONS_RESULTS_URL"] == "" {
+				if input.artifactServerPath != "" {
+					cacheHandler.ProxyResults(&url.URL{Scheme: "http", Host: net.JoinHostPort(input.artifactServerAddr, input.artifactServerPort)})
+				}
+				envs["ACTIONS_RESULTS_URL"] = cacheHandler.ExternalURL() + "/"
+				setDefaultEnv(envs, "ACTIONS_CACHE_SERVICE_V2", "true")
+			}
+		} else if input.noCacheServer && input.cacheServerExternalURL != "" && envs[cacheURLKey] == "" {
+			// a shared server started by `act serve`, which also serves the artifact service v4 at the results url
+			serverURL := strings.TrimSuffix(input.cacheServerExternalURL, "/") + "/"
+			envs[cacheURLKey] = serverURL
+			if input.cacheServiceV2 && envs["ACTIONS_RESULTS_URL"] == "" {
+				envs["ACTIONS_RESULTS_URL"] = serverURL
+				setDefaultEnv(envs, "ACTIONS_CACHE_SERVICE_V2", "true")
+			}
 		}
 
 		ctx = common.WithDryrun(ctx, input.dryrun)
@@ -3,8 +3,10 @@ package cmd
 import (
 	"context"
 	"path"
+	"strings"
 	"testing"
 
+	"github.com/nektos/act/pkg/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -123,3 +125,23 @@ func TestReadArgsFile(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckPlanExpressions(t *testing.T) {
+	planner, err := model.NewSingleWorkflowPlanner("test.yml", strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo ${{ steps.missing.outputs.value }}
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	plan, err := planner.PlanEvent("push")
+	if !assert.NoError(t, err) {
+		return
+	}
+	// undefined step ids evaluate to null, they are only logged as warnings
+	assert.NoError(t, checkPlanExpressions(plan))
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxRunRecords limits the runs remembered to count the attempts of a run
+	maxRunRecords = 1000
+	// runCountersLockTimeout is the time to wait for other invocations of act to release the lock of the run counters
+	runCountersLockTimeout = 30 * time.Second
+	// staleRunCountersLock is the age of a lock which was left behind by a killed invocation
+	staleRunCountersLock = 10 * time.Second
+)
+
+type runRecord struct {
+	Number  int64 `json:"number"`
+	Attempt int64 `json:"attempt"`
+}
+
+// runCounters are persisted to give every invocation of act a unique run id, so artifacts of different runs don't collide
+type runCounters struct {
+	LastRunID  int64                `json:"last_run_id"`
+	RunNumbers map[string]int64     `json:"run_numbers"` // the last run number per repository
+	Runs       map[string]runRecord `json:"runs"`        // the run number and the last attempt per run id
+}
+
+func runCountersPath() string {
+	return filepath.Join(CacheHomeDir, "act", "runs.json")
+}
+
+// assignRunIDs sets GITHUB_RUN_ID, GITHUB_RUN_NUMBER and GITHUB_RUN_ATTEMPT if they aren't set by --env or the env file.
+// A new run id is assigned to every invocation, passing the id of an earlier run with --env GITHUB_RUN_ID re-runs it
+// with the next attempt.
+func assignRunIDs(path string, repository string, envs map[string]string) error {
+	unlock, err := lockRunCounters(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	counters := runCounters{}
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &counters); err != nil {
+			return err
+		}
+	}
+	if counters.RunNumbers == nil {
+		counters.RunNumbers = map[string]int64{}
+	}
+	if counters.Runs == nil {
+		counters.Runs = map[string]runRecord{}
+	}
+
+	runID, ok := envs["GITHUB_RUN_ID"]
+	if !ok {
+		counters.LastRunID++
+		runID = strconv.FormatInt(counters.LastRunID, 10)
+	} else if id, err := strconv.ParseInt(runID, 10, 64); err == nil && id > counters.LastRunID {
+		counters.LastRunID = id
+	}
+	record, ok := counters.Runs[runID]
+	if ok {
+		record.Attempt++
+	} else {
+		record = runRecord{Attempt: 1}
+	}
+	if number, err := strconv.ParseInt(envs["GITHUB_RUN_NUMBER"], 10, 64); err == nil {
+		// an explicit run number is recorded, so that re-runs of the run keep it
+		record.Number = number
+		if number > counters.RunNumbers[repository] {
+			counters.RunNumbers[repository] = number
+		}
+	} else if !ok {
+		counters.RunNumbers[repository]++
+		record.Number = counters.RunNumbers[repository]
+	}
+	counters.Runs[runID] = record
+	pruneRunRecords(counters.Runs)
+
+	setDefaultEnv(envs, "GITHUB_RUN_ID", runID)
+	setDefaultEnv(envs, "GITHUB_RUN_NUMBER", strconv.FormatInt(record.Number, 10))
+	setDefaultEnv(envs, "GITHUB_RUN_ATTEMPT", strconv.FormatInt(record.Attempt, 10))
+
+	if content, err = json.Marshal(counters); err != nil {
+		return err
+	}
+	// replace the file at once, an interrupted write must not reset the counters
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".runs-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockRunCounters creates the lock file of the run counters, so that invocations of act running at the same time
+// don't assign the same run id. It returns the function removing the lock.
+func lockRunCounters(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return nil, err
+	}
+	lock := path + ".lock"
+	deadline := time.Now().Add(runCountersLockTimeout)
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o666)
+		if err == nil {
+			file.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleRunCountersLock {
+			_ = os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock %s of the run counters", lock)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// pruneRunRecords removes the oldest runs, runs with numeric ids are ordered by their id
+func pruneRunRecords(runs map[string]runRecord) {
+	if len(runs) <= maxRunRecords {
+		return
+	}
+	ids := make([]string, 0, len(runs))
+	for id := range runs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, erra := strconv.ParseInt(ids[i], 10, 64)
+		b, errb := strconv.ParseInt(ids[j], 10, 64)
+		if erra == nil && errb == nil {
+			return a < b
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids[:len(ids)-maxRunRecords] {
+		delete(runs, id)
+	}
+}
+
+func setDefaultEnv(envs map[string]string, key, value string) {
+	if _, ok := envs[key]; !ok {
+		envs[key] = value
+	}
+}
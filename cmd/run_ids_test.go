@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignRunIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "act", "runs.json")
+	assign := func(repository string, envs map[string]string) map[string]string {
+		assert.NoError(t, assignRunIDs(path, repository, envs))
+		return envs
+	}
+	run := func(id, number, attempt string) map[string]string {
+		return map[string]string{"GITHUB_RUN_ID": id, "GITHUB_RUN_NUMBER": number, "GITHUB_RUN_ATTEMPT": attempt}
+	}
+
+	assert.Equal(t, run("1", "1", "1"), assign("/repo", map[string]string{}))
+	assert.Equal(t, run("2", "2", "1"), assign("/repo", map[string]string{}))
+	assert.Equal(t, run("3", "1", "1"), assign("/other", map[string]string{}))
+
+	// re-run an earlier run
+	assert.Equal(t, run("2", "2", "2"), assign("/repo", map[string]string{"GITHUB_RUN_ID": "2"}))
+
+	// explicit ids are kept and are not assigned again
+	assert.Equal(t, run("100", "7", "1"), assign("/repo", map[string]string{"GITHUB_RUN_ID": "100", "GITHUB_RUN_NUMBER": "7"}))
+	assert.Equal(t, run("101", "8", "1"), assign("/repo", map[string]string{}))
+
+	// re-run the explicit run, its run number was recorded
+	assert.Equal(t, run("100", "7", "2"), assign("/repo", map[string]string{"GITHUB_RUN_ID": "100"}))
+}
+
+func TestAssignRunIDsConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "act", "runs.json")
+	ids := make(chan string, 20)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			envs := map[string]string{}
+			assert.NoError(t, assignRunIDs(path, "/repo", envs))
+			ids <- envs["GITHUB_RUN_ID"]
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	unique := map[string]bool{}
+	for id := range ids {
+		unique[id] = true
+	}
+	assert.Len(t, unique, cap(ids))
+	assert.NoFileExists(t, path+".lock")
+}
+
+func TestPruneRunRecords(t *testing.T) {
+	runs := map[string]runRecord{}
+	for i := 1; i <= maxRunRecords+2; i++ {
+		runs[strconv.Itoa(i)] = runRecord{Number: int64(i), Attempt: 1}
+	}
+	pruneRunRecords(runs)
+	assert.Len(t, runs, maxRunRecords)
+	assert.NotContains(t, runs, "1")
+	assert.NotContains(t, runs, "2")
+	assert.Contains(t, runs, "3")
+}
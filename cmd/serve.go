@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/nektos/act/pkg/artifactcache"
+	"github.com/nektos/act/pkg/artifacts"
+	"github.com/nektos/act/pkg/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+type serveInput struct {
+	config  string
+	token   string
+	tlsCert string
+	tlsKey  string
+}
+
+func newServeCommand(ctx context.Context, input *Input) *cobra.Command {
+	serveArgs := &serveInput{}
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the cache server and the artifact server as a long-running process shared by several act invocations",
+		Long: `Run the cache server and the artifact server as a long-running process shared by several act invocations.
+
+Both servers listen on --cache-server-port, the artifact server is started if --artifact-server-path is set.
+Other invocations use the server with --no-cache-server --cache-server-external-url=<url>, and pass the token
+by the ACTIONS_RUNTIME_TOKEN environment variable if --token is set. If --runtime-token-key is set instead, the
+invocations pass the same key, the server verifies the runtime tokens of their jobs and scopes the caches by the
+repository and the branch of the tokens. Without the key all invocations share the caches of a single repository.
+GET /healthz reports whether the server is up.`,
+		Args: cobra.NoArgs,
+		// flags of the root command may be passed by an .actrc file
+		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if serveArgs.config != "" {
+				if err := loadServeConfig(cmd.Flags(), serveArgs.config); err != nil {
+					return err
+				}
+			}
+			return runServe(common.WithLogger(ctx, log.StandardLogger()), input, serveArgs)
+		},
+	}
+	cmd.Flags().StringVar(&serveArgs.config, "config", "", "a YAML file with the values of the flags by name like cache-server-port: 8080, flags passed on the command line take precedence")
+	cmd.Flags().StringVar(&serveArgs.token, "token", "", "the token the requests have to pass as bearer token, the signed urls of the archives are exempt")
+	cmd.Flags().StringVar(&serveArgs.tlsCert, "tls-cert", "", "the certificate file to serve HTTPS with, requires --tls-key")
+	cmd.Flags().StringVar(&serveArgs.tlsKey, "tls-key", "", "the private key file of --tls-cert")
+	return cmd
+}
+
+// loadServeConfig sets the flags which haven't been passed on the command line to the values of the config file
+func loadServeConfig(flags *pflag.FlagSet, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	values := map[string]string{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse config file '%s': %w", file, err)
+	}
+	for name, value := range values {
+		flag := flags.Lookup(name)
+		if flag == nil || name == "config" {
+			return fmt.Errorf("unknown option '%s' in config file '%s'", name, file)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid option '%s' in config file '%s': %w", name, file, err)
+		}
+	}
+	return nil
+}
+
+func runServe(ctx context.Context, input *Input, serveArgs *serveInput) error {
+	logger := common.Logger(ctx)
+	if (serveArgs.tlsCert == "") != (serveArgs.tlsKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key have to be set together")
+	}
+	if serveArgs.token != "" && input.runtimeTokenKey != "" {
+		return fmt.Errorf("--token and --runtime-token-key can't be set together, the clients pass runtime tokens signed with the key")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", input.cacheServerPort)) // listen on all interfaces
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	externalURL := input.cacheServerExternalURL
+	if externalURL == "" {
+		scheme := "http"
+		if serveArgs.tlsCert != "" {
+			scheme = "https"
+		}
+		externalURL = fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(input.cacheServerAddr, fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)))
+	}
+
+	handler, err := newServeServers(ctx, input, serveArgs, externalURL)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		ReadHeaderTimeout: 2 * time.Second,
+		Handler:           handler,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if input.artifactServerPath != "" {
+		logger.Infof("Serving caches and artifacts at %s", externalURL)
+	} else {
+		logger.Infof("Serving caches at %s", externalURL)
+	}
+	if serveArgs.tlsCert != "" {
+		err = server.ServeTLS(listener, serveArgs.tlsCert, serveArgs.tlsKey)
+	} else {
+		err = server.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// newServeServers creates the cache server and the artifact server if --artifact-server-path is set. The servers
+// verify the runtime tokens of the requests if --runtime-token-key is set.
+func newServeServers(ctx context.Context, input *Input, serveArgs *serveInput, externalURL string) (http.Handler, error) {
+	var tokenKey []byte
+	if input.runtimeTokenKey != "" {
+		tokenKey = []byte(input.runtimeTokenKey)
+	}
+	cacheHandler, err := artifactcache.NewHandler(input.cacheServerPath, input.cacheServerStorage, input.cacheServerMaxSize<<20, externalURL, common.Logger(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if tokenKey != nil {
+		cacheHandler.RequireRuntimeToken(tokenKey)
+	}
+	if input.artifactServerPath != "" {
+		artifactHandler, err := artifacts.NewHandler(ctx, input.artifactServerPath, artifacts.Limits{
+			RetentionDays: input.artifactRetentionDays,
+			MaxSize:       input.artifactServerMaxSize << 20,
+		}, "", "", tokenKey)
+		if err != nil {
+			return nil, err
+		}
+		// the artifact service v4 and the cache service v2 share the results url
+		cacheHandler.ServeResults(artifactHandler)
+	}
+	return newServeHandler(cacheHandler, serveArgs.token), nil
+}
+
+// newServeHandler adds the health endpoint and checks the token of the requests, except for the signed urls of the
+// archives which the toolkit fetches without the runtime token. Their signatures are verified by the servers.
+func newServeHandler(handler http.Handler, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && !isSignedArchiveURL(r) {
+			bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// isSignedArchiveURL reports whether a request uses a signed url to upload or download an archive, the blobs of the
+// cache server or the artifacts of the artifact service v4
+func isSignedArchiveURL(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/_apis/artifactcache/blobs/") {
+		return true
+	}
+	switch r.URL.Path {
+	case path.Join(artifacts.ArtifactV4RouteBase, "UploadArtifact"), path.Join(artifacts.ArtifactV4RouteBase, "DownloadArtifact"):
+		return r.URL.Query().Get("sig") != ""
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadServeConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "serve.yml")
+	require.NoError(t, os.WriteFile(file, []byte("cache-server-port: 8080\ncache-server-path: /var/lib/act\ntoken: secret\n"), 0o600))
+
+	flags := pflag.NewFlagSet("serve", pflag.ContinueOnError)
+	port := flags.Uint16("cache-server-port", 0, "")
+	path := flags.String("cache-server-path", "", "")
+	token := flags.String("token", "", "")
+	require.NoError(t, flags.Parse([]string{"--token=flag"}))
+
+	require.NoError(t, loadServeConfig(flags, file))
+	assert.Equal(t, uint16(8080), *port)
+	assert.Equal(t, "/var/lib/act", *path)
+	// flags passed on the command line take precedence
+	assert.Equal(t, "flag", *token)
+
+	require.NoError(t, os.WriteFile(file, []byte("unknown: 1\n"), 0o600))
+	assert.EqualError(t, loadServeConfig(flags, file), "unknown option 'unknown' in config file '"+file+"'")
+}
+
+func TestServeHandler(t *testing.T) {
+	handler := newServeHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), "secret")
+
+	serve := func(method, target, token string) int {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/healthz", ""))
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodGet, "/_apis/artifactcache/cache?keys=deps", "secret"))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/_apis/artifactcache/cache?keys=deps", "wrong"))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/twirp/github.actions.results.api.v1.ArtifactService/ListArtifacts", ""))
+	// the archives are uploaded and downloaded from the signed urls without the token
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPut, "/_apis/artifactcache/blobs/1?expires=1&sig=abc", ""))
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodGet, "/twirp/github.actions.results.api.v1.ArtifactService/DownloadArtifact?sig=abc", ""))
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPut, "/twirp/github.actions.results.api.v1.ArtifactService/UploadArtifact?sig=abc", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPut, "/twirp/github.actions.results.api.v1.ArtifactService/UploadArtifact", ""))
+	// a signature doesn't exempt other requests
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/twirp/github.actions.results.api.v1.ArtifactService/ListArtifacts?sig=abc", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/_apis/artifactcache/cache?keys=deps&sig=abc", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/_apis/artifactcache/artifacts/1", ""))
+}
+
+func TestServeServersRuntimeTokenKey(t *testing.T) {
+	input := &Input{
+		cacheServerPath: t.TempDir(),
+		runtimeTokenKey: "shared key",
+	}
+	handler, err := newServeServers(context.Background(), input, &serveInput{}, "http://localhost")
+	require.NoError(t, err)
+
+	find := func(key []byte) int {
+		req := httptest.NewRequest(http.MethodGet, "/_apis/artifactcache/cache?keys=deps&version=1", nil)
+		if key != nil {
+			token, err := common.CreateCacheAuthorizationToken(1, 1, 1, common.CacheAccess{Repository: "owner/repo"}, key)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, find([]byte("shared key")))
+	assert.Equal(t, http.StatusUnauthorized, find([]byte("other key")))
+	assert.Equal(t, http.StatusUnauthorized, find(nil))
+}
@@ -0,0 +1,605 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.25.2
+// source: cache.proto
+
+package artifactcache
+
+import (
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CacheScope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
+	Permission    int64                  `protobuf:"varint,2,opt,name=permission,proto3" json:"permission,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CacheScope) Reset() {
+	*x = CacheScope{}
+	mi := &file_cache_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheScope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheScope) ProtoMessage() {}
+
+func (x *CacheScope) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheScope.ProtoReflect.Descriptor instead.
+func (*CacheScope) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CacheScope) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *CacheScope) GetPermission() int64 {
+	if x != nil {
+		return x.Permission
+	}
+	return 0
+}
+
+type CacheMetadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RepositoryId  int64                  `protobuf:"varint,1,opt,name=repository_id,json=repositoryId,proto3" json:"repository_id,omitempty"`
+	Scope         []*CacheScope          `protobuf:"bytes,2,rep,name=scope,proto3" json:"scope,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CacheMetadata) Reset() {
+	*x = CacheMetadata{}
+	mi := &file_cache_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheMetadata) ProtoMessage() {}
+
+func (x *CacheMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheMetadata.ProtoReflect.Descriptor instead.
+func (*CacheMetadata) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CacheMetadata) GetRepositoryId() int64 {
+	if x != nil {
+		return x.RepositoryId
+	}
+	return 0
+}
+
+func (x *CacheMetadata) GetScope() []*CacheScope {
+	if x != nil {
+		return x.Scope
+	}
+	return nil
+}
+
+type CreateCacheEntryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *CacheMetadata         `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCacheEntryRequest) Reset() {
+	*x = CreateCacheEntryRequest{}
+	mi := &file_cache_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCacheEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCacheEntryRequest) ProtoMessage() {}
+
+func (x *CreateCacheEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCacheEntryRequest.ProtoReflect.Descriptor instead.
+func (*CreateCacheEntryRequest) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateCacheEntryRequest) GetMetadata() *CacheMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CreateCacheEntryRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CreateCacheEntryRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type CreateCacheEntryResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Ok              bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	SignedUploadUrl string                 `protobuf:"bytes,2,opt,name=signed_upload_url,json=signedUploadUrl,proto3" json:"signed_upload_url,omitempty"`
+	Message         string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreateCacheEntryResponse) Reset() {
+	*x = CreateCacheEntryResponse{}
+	mi := &file_cache_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCacheEntryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCacheEntryResponse) ProtoMessage() {}
+
+func (x *CreateCacheEntryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCacheEntryResponse.ProtoReflect.Descriptor instead.
+func (*CreateCacheEntryResponse) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateCacheEntryResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *CreateCacheEntryResponse) GetSignedUploadUrl() string {
+	if x != nil {
+		return x.SignedUploadUrl
+	}
+	return ""
+}
+
+func (x *CreateCacheEntryResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type FinalizeCacheEntryUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *CacheMetadata         `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	SizeBytes     int64                  `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FinalizeCacheEntryUploadRequest) Reset() {
+	*x = FinalizeCacheEntryUploadRequest{}
+	mi := &file_cache_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinalizeCacheEntryUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalizeCacheEntryUploadRequest) ProtoMessage() {}
+
+func (x *FinalizeCacheEntryUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalizeCacheEntryUploadRequest.ProtoReflect.Descriptor instead.
+func (*FinalizeCacheEntryUploadRequest) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FinalizeCacheEntryUploadRequest) GetMetadata() *CacheMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *FinalizeCacheEntryUploadRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *FinalizeCacheEntryUploadRequest) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *FinalizeCacheEntryUploadRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type FinalizeCacheEntryUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	EntryId       int64                  `protobuf:"varint,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FinalizeCacheEntryUploadResponse) Reset() {
+	*x = FinalizeCacheEntryUploadResponse{}
+	mi := &file_cache_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinalizeCacheEntryUploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalizeCacheEntryUploadResponse) ProtoMessage() {}
+
+func (x *FinalizeCacheEntryUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalizeCacheEntryUploadResponse.ProtoReflect.Descriptor instead.
+func (*FinalizeCacheEntryUploadResponse) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FinalizeCacheEntryUploadResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *FinalizeCacheEntryUploadResponse) GetEntryId() int64 {
+	if x != nil {
+		return x.EntryId
+	}
+	return 0
+}
+
+func (x *FinalizeCacheEntryUploadResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type GetCacheEntryDownloadURLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *CacheMetadata         `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	RestoreKeys   []string               `protobuf:"bytes,3,rep,name=restore_keys,json=restoreKeys,proto3" json:"restore_keys,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCacheEntryDownloadURLRequest) Reset() {
+	*x = GetCacheEntryDownloadURLRequest{}
+	mi := &file_cache_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCacheEntryDownloadURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCacheEntryDownloadURLRequest) ProtoMessage() {}
+
+func (x *GetCacheEntryDownloadURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCacheEntryDownloadURLRequest.ProtoReflect.Descriptor instead.
+func (*GetCacheEntryDownloadURLRequest) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetCacheEntryDownloadURLRequest) GetMetadata() *CacheMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *GetCacheEntryDownloadURLRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetCacheEntryDownloadURLRequest) GetRestoreKeys() []string {
+	if x != nil {
+		return x.RestoreKeys
+	}
+	return nil
+}
+
+func (x *GetCacheEntryDownloadURLRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type GetCacheEntryDownloadURLResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Ok                bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	SignedDownloadUrl string                 `protobuf:"bytes,2,opt,name=signed_download_url,json=signedDownloadUrl,proto3" json:"signed_download_url,omitempty"`
+	MatchedKey        string                 `protobuf:"bytes,3,opt,name=matched_key,json=matchedKey,proto3" json:"matched_key,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GetCacheEntryDownloadURLResponse) Reset() {
+	*x = GetCacheEntryDownloadURLResponse{}
+	mi := &file_cache_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCacheEntryDownloadURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCacheEntryDownloadURLResponse) ProtoMessage() {}
+
+func (x *GetCacheEntryDownloadURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCacheEntryDownloadURLResponse.ProtoReflect.Descriptor instead.
+func (*GetCacheEntryDownloadURLResponse) Descriptor() ([]byte, []int) {
+	return file_cache_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetCacheEntryDownloadURLResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *GetCacheEntryDownloadURLResponse) GetSignedDownloadUrl() string {
+	if x != nil {
+		return x.SignedDownloadUrl
+	}
+	return ""
+}
+
+func (x *GetCacheEntryDownloadURLResponse) GetMatchedKey() string {
+	if x != nil {
+		return x.MatchedKey
+	}
+	return ""
+}
+
+var File_cache_proto protoreflect.FileDescriptor
+
+const file_cache_proto_rawDesc = "" +
+	"\n" +
+	"\vcache.proto\x12\x1dgithub.actions.results.api.v1\"B\n" +
+	"\n" +
+	"CacheScope\x12\x14\n" +
+	"\x05scope\x18\x01 \x01(\tR\x05scope\x12\x1e\n" +
+	"\n" +
+	"permission\x18\x02 \x01(\x03R\n" +
+	"permission\"u\n" +
+	"\rCacheMetadata\x12#\n" +
+	"\rrepository_id\x18\x01 \x01(\x03R\frepositoryId\x12?\n" +
+	"\x05scope\x18\x02 \x03(\v2).github.actions.results.api.v1.CacheScopeR\x05scope\"\x8f\x01\n" +
+	"\x17CreateCacheEntryRequest\x12H\n" +
+	"\bmetadata\x18\x01 \x01(\v2,.github.actions.results.api.v1.CacheMetadataR\bmetadata\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\"p\n" +
+	"\x18CreateCacheEntryResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12*\n" +
+	"\x11signed_upload_url\x18\x02 \x01(\tR\x0fsignedUploadUrl\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xb6\x01\n" +
+	"\x1fFinalizeCacheEntryUploadRequest\x12H\n" +
+	"\bmetadata\x18\x01 \x01(\v2,.github.actions.results.api.v1.CacheMetadataR\bmetadata\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x03 \x01(\x03R\tsizeBytes\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\"g\n" +
+	" FinalizeCacheEntryUploadResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\x03R\aentryId\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xba\x01\n" +
+	"\x1fGetCacheEntryDownloadURLRequest\x12H\n" +
+	"\bmetadata\x18\x01 \x01(\v2,.github.actions.results.api.v1.CacheMetadataR\bmetadata\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12!\n" +
+	"\frestore_keys\x18\x03 \x03(\tR\vrestoreKeys\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\"\x83\x01\n" +
+	" GetCacheEntryDownloadURLResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12.\n" +
+	"\x13signed_download_url\x18\x02 \x01(\tR\x11signedDownloadUrl\x12\x1f\n" +
+	"\vmatched_key\x18\x03 \x01(\tR\n" +
+	"matchedKeyB)Z'github.com/nektos/act/pkg/artifactcacheb\x06proto3"
+
+var (
+	file_cache_proto_rawDescOnce sync.Once
+	file_cache_proto_rawDescData []byte
+)
+
+func file_cache_proto_rawDescGZIP() []byte {
+	file_cache_proto_rawDescOnce.Do(func() {
+		file_cache_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_cache_proto_rawDesc), len(file_cache_proto_rawDesc)))
+	})
+	return file_cache_proto_rawDescData
+}
+
+var file_cache_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_cache_proto_goTypes = []any{
+	(*CacheScope)(nil),                       // 0: github.actions.results.api.v1.CacheScope
+	(*CacheMetadata)(nil),                    // 1: github.actions.results.api.v1.CacheMetadata
+	(*CreateCacheEntryRequest)(nil),          // 2: github.actions.results.api.v1.CreateCacheEntryRequest
+	(*CreateCacheEntryResponse)(nil),         // 3: github.actions.results.api.v1.CreateCacheEntryResponse
+	(*FinalizeCacheEntryUploadRequest)(nil),  // 4: github.actions.results.api.v1.FinalizeCacheEntryUploadRequest
+	(*FinalizeCacheEntryUploadResponse)(nil), // 5: github.actions.results.api.v1.FinalizeCacheEntryUploadResponse
+	(*GetCacheEntryDownloadURLRequest)(nil),  // 6: github.actions.results.api.v1.GetCacheEntryDownloadURLRequest
+	(*GetCacheEntryDownloadURLResponse)(nil), // 7: github.actions.results.api.v1.GetCacheEntryDownloadURLResponse
+}
+var file_cache_proto_depIdxs = []int32{
+	0, // 0: github.actions.results.api.v1.CacheMetadata.scope:type_name -> github.actions.results.api.v1.CacheScope
+	1, // 1: github.actions.results.api.v1.CreateCacheEntryRequest.metadata:type_name -> github.actions.results.api.v1.CacheMetadata
+	1, // 2: github.actions.results.api.v1.FinalizeCacheEntryUploadRequest.metadata:type_name -> github.actions.results.api.v1.CacheMetadata
+	1, // 3: github.actions.results.api.v1.GetCacheEntryDownloadURLRequest.metadata:type_name -> github.actions.results.api.v1.CacheMetadata
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_cache_proto_init() }
+func file_cache_proto_init() {
+	if File_cache_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cache_proto_rawDesc), len(file_cache_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_cache_proto_goTypes,
+		DependencyIndexes: file_cache_proto_depIdxs,
+		MessageInfos:      file_cache_proto_msgTypes,
+	}.Build()
+	File_cache_proto = out.File
+	file_cache_proto_goTypes = nil
+	file_cache_proto_depIdxs = nil
+}
@@ -1,6 +1,7 @@
 package artifactcache
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -29,7 +31,7 @@ const (
 
 type Handler struct {
 	dir      string
-	storage  *Storage
+	storage  Storage
 	router   *httprouter.Router
 	listener net.Listener
 	server   *http.Server
@@ -38,11 +40,58 @@ type Handler struct {
 	gcing atomic.Bool
 	gcAt  time.Time
 
+	// maxSize is the quota for the caches of a repository, 0 means no quota
+	maxSize int64
+
+	// signingKey signs the urls to upload and download the archives of the v2 api
+	signingKey []byte
+	// tokenKey is the key of the runtime tokens the requests have to pass, requests without a token are accepted if nil
+	tokenKey []byte
+
 	outboundIP        string
 	customExternalURL string
 }
 
-func StartHandler(dir, customExternalURL string, outboundIP string, port uint16, logger logrus.FieldLogger) (*Handler, error) {
+// StartHandler starts a cache server storing its index in dir. The archives are stored in dir as well, unless
+// storageURL selects a storage shared with other servers, see NewRemoteStorage.
+// The caches of a repository are limited to maxSize bytes, 0 means no quota.
+func StartHandler(dir, storageURL string, maxSize int64, customExternalURL string, outboundIP string, port uint16, logger logrus.FieldLogger) (*Handler, error) {
+	h, err := NewHandler(dir, storageURL, maxSize, customExternalURL, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if outboundIP != "" {
+		h.outboundIP = outboundIP
+	} else if ip := common.GetOutboundIP(); ip == nil {
+		return nil, fmt.Errorf("unable to determine outbound IP address")
+	} else {
+		h.outboundIP = ip.String()
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port)) // listen on all interfaces
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{
+		ReadHeaderTimeout: 2 * time.Second,
+		Handler:           h.router,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && errors.Is(err, net.ErrClosed) {
+			h.logger.Errorf("http serve: %v", err)
+		}
+	}()
+	h.listener = listener
+	h.server = server
+
+	return h, nil
+}
+
+// NewHandler returns a cache server without listening, the caller serves it at customExternalURL, e.g. a standalone
+// server which also serves the artifacts.
+func NewHandler(dir, storageURL string, maxSize int64, customExternalURL string, logger logrus.FieldLogger) (*Handler, error) {
 	h := &Handler{}
 
 	if logger == nil {
@@ -65,23 +114,21 @@ func StartHandler(dir, customExternalURL string, outboundIP string, port uint16,
 	}
 
 	h.dir = dir
+	h.maxSize = maxSize
 
-	storage, err := NewStorage(filepath.Join(dir, "cache"))
+	storage, err := openStorage(dir, storageURL)
 	if err != nil {
 		return nil, err
 	}
 	h.storage = storage
 
-	if customExternalURL != "" {
-		h.customExternalURL = customExternalURL
+	h.signingKey = make([]byte, 32)
+	if _, err := rand.Read(h.signingKey); err != nil {
+		return nil, err
 	}
 
-	if outboundIP != "" {
-		h.outboundIP = outboundIP
-	} else if ip := common.GetOutboundIP(); ip == nil {
-		return nil, fmt.Errorf("unable to determine outbound IP address")
-	} else {
-		h.outboundIP = ip.String()
+	if customExternalURL != "" {
+		h.customExternalURL = customExternalURL
 	}
 
 	router := httprouter.New()
@@ -91,29 +138,23 @@ func StartHandler(dir, customExternalURL string, outboundIP string, port uint16,
 	router.POST(urlBase+"/caches/:id", h.middleware(h.commit))
 	router.GET(urlBase+"/artifacts/:id", h.middleware(h.get))
 	router.POST(urlBase+"/clean", h.middleware(h.clean))
+	router.GET(urlBase+"/stats", h.middleware(h.stats))
+	h.routesV2(router)
 
 	h.router = router
 
 	h.gcCache()
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port)) // listen on all interfaces
-	if err != nil {
-		return nil, err
-	}
+	return h, nil
+}
 
-	server := &http.Server{
-		ReadHeaderTimeout: 2 * time.Second,
-		Handler:           router,
-	}
-	go func() {
-		if err := server.Serve(listener); err != nil && errors.Is(err, net.ErrClosed) {
-			logger.Errorf("http serve: %v", err)
-		}
-	}()
-	h.listener = listener
-	h.server = server
+// RequireRuntimeToken rejects the requests without a runtime token signed with key, except those of the signed urls.
+func (h *Handler) RequireRuntimeToken(key []byte) {
+	h.tokenKey = key
+}
 
-	return h, nil
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
 }
 
 func (h *Handler) GetActualPort() int {
@@ -153,7 +194,11 @@ func (h *Handler) Close() error {
 }
 
 func (h *Handler) openDB() (*bolthold.Store, error) {
-	return bolthold.Open(filepath.Join(h.dir, "bolt.db"), 0o644, &bolthold.Options{
+	return openDB(h.dir)
+}
+
+func openDB(dir string) (*bolthold.Store, error) {
+	return bolthold.Open(filepath.Join(dir, "bolt.db"), 0o644, &bolthold.Options{
 		Encoder: json.Marshal,
 		Decoder: json.Unmarshal,
 		Options: &bbolt.Options{
@@ -173,14 +218,12 @@ func (h *Handler) find(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 	}
 	version := r.URL.Query().Get("version")
 
-	db, err := h.openDB()
+	access, err := parseCacheAccess(r, h.tokenKey)
 	if err != nil {
-		h.responseJSON(w, r, 500, err)
+		h.responseJSON(w, r, 401, err)
 		return
 	}
-	defer db.Close()
-
-	cache, err := findCache(db, keys, version)
+	cache, err := h.lookupCache(keys, version, access)
 	if err != nil {
 		h.responseJSON(w, r, 500, err)
 		return
@@ -189,22 +232,101 @@ func (h *Handler) find(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 		h.responseJSON(w, r, 204)
 		return
 	}
-
-	if ok, err := h.storage.Exist(cache.ID); err != nil {
-		h.responseJSON(w, r, 500, err)
-		return
-	} else if !ok {
-		_ = db.Delete(cache.ID, cache)
-		h.responseJSON(w, r, 204)
-		return
-	}
 	h.responseJSON(w, r, 200, map[string]any{
-		"result":          "hit",
-		"archiveLocation": fmt.Sprintf("%s%s/artifacts/%d", h.ExternalURL(), urlBase, cache.ID),
+		"result": "hit",
+		// the toolkit downloads the archive without the runtime token
+		"archiveLocation": h.signedBlobURL("download", cache.ID),
 		"cacheKey":        cache.Key,
 	})
 }
 
+// lookupCache finds a completed cache whose archive exists, the scopes are searched in order, see findCache.
+// If not found, return (nil, nil) instead of an error.
+func (h *Handler) lookupCache(keys []string, version string, access *common.CacheAccess) (*Cache, error) {
+	db, err := h.openDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	for _, scope := range readScopes(access) {
+		cache, err := findCache(db, keys, version, access.Repository, scope)
+		if err != nil {
+			return nil, err
+		}
+		if cache != nil {
+			if ok, err := h.storage.Exist(cache); err != nil {
+				return nil, err
+			} else if ok {
+				return cache, nil
+			}
+			_ = db.Delete(cache.ID, cache)
+		}
+		if index, ok := h.storage.(Index); ok {
+			// the cache may have been uploaded by another server sharing the storage
+			cache, err = index.Find(keys, version, access.Repository, scope)
+			if err != nil {
+				return nil, err
+			}
+			if cache != nil {
+				cache.UsedAt = time.Now().Unix()
+				if err := insertCache(db, cache); err != nil {
+					return nil, err
+				}
+				return cache, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// parseCacheAccess returns the access of the runtime token of a request to the caches, see readScopes and writeScope.
+// Requests without a runtime token get no repository and no scopes, so do all requests of a server without key, e.g.
+// the token of act serve isn't a runtime token, see common.ParseCacheAccess.
+func parseCacheAccess(r *http.Request, key []byte) (*common.CacheAccess, error) {
+	access, err := common.ParseCacheAccess(r, key)
+	if err != nil {
+		return nil, err
+	}
+	if access == nil {
+		access = &common.CacheAccess{}
+	}
+	return access, nil
+}
+
+// readScopes returns the scopes whose caches a request may restore by precedence, taken from the runtime token like
+// on GitHub. Requests without scopes, like from clients without a runtime token, use the unscoped caches.
+func readScopes(access *common.CacheAccess) []string {
+	scopes := []string{}
+	for _, scope := range access.Scopes {
+		if scope.Scope != "" && !slices.Contains(scopes, scope.Scope) {
+			scopes = append(scopes, scope.Scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return []string{""}
+	}
+	return scopes
+}
+
+// writeScope returns the scope a request saves caches to, see readScopes.
+func writeScope(access *common.CacheAccess) (string, error) {
+	scoped := false
+	for _, scope := range access.Scopes {
+		if scope.Scope == "" {
+			continue
+		}
+		if scope.Permission&common.CachePermissionWrite != 0 {
+			return scope.Scope, nil
+		}
+		scoped = true
+	}
+	if scoped {
+		return "", errors.New("the runtime token doesn't permit to save caches")
+	}
+	return "", nil
+}
+
 // POST /_apis/artifactcache/caches
 func (h *Handler) reserve(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	api := &Request{}
@@ -214,8 +336,25 @@ func (h *Handler) reserve(w http.ResponseWriter, r *http.Request, _ httprouter.P
 	}
 	// cache keys are case insensitive
 	api.Key = strings.ToLower(api.Key)
+	access, err := parseCacheAccess(r, h.tokenKey)
+	if err != nil {
+		h.responseJSON(w, r, 401, err)
+		return
+	}
+	scope, err := writeScope(access)
+	if err != nil {
+		h.responseJSON(w, r, 403, err)
+		return
+	}
+
+	if err := h.checkCacheSize(api.Size); err != nil {
+		h.responseJSON(w, r, 400, err)
+		return
+	}
 
 	cache := api.ToCache()
+	cache.Repository = access.Repository
+	cache.Scope = scope
 	db, err := h.openDB()
 	if err != nil {
 		h.responseJSON(w, r, 500, err)
@@ -269,7 +408,7 @@ func (h *Handler) upload(w http.ResponseWriter, r *http.Request, params httprout
 		h.responseJSON(w, r, 400, err)
 		return
 	}
-	if err := h.storage.Write(cache.ID, start, r.Body); err != nil {
+	if err := h.storage.Write(cache, start, r.Body); err != nil {
 		h.responseJSON(w, r, 500, err)
 	}
 	h.useCache(id)
@@ -307,28 +446,42 @@ func (h *Handler) commit(w http.ResponseWriter, r *http.Request, params httprout
 
 	db.Close()
 
-	size, err := h.storage.Commit(cache.ID, cache.Size)
-	if err != nil {
+	if err := h.commitCache(cache); err != nil {
 		h.responseJSON(w, r, 500, err)
 		return
 	}
+
+	h.responseJSON(w, r, 200)
+}
+
+// commitCache assembles the uploaded chunks of a reserved cache and marks it complete, it counts as used at the commit.
+// The least recently used caches of the repository but the committed one are evicted if the quota is exceeded.
+func (h *Handler) commitCache(cache *Cache) error {
+	size, err := h.storage.Commit(cache)
+	if err != nil {
+		return err
+	}
 	// write real size back to cache, it may be different from the current value when the request doesn't specify it.
 	cache.Size = size
 
-	db, err = h.openDB()
+	db, err := h.openDB()
 	if err != nil {
-		h.responseJSON(w, r, 500, err)
-		return
+		return err
 	}
 	defer db.Close()
 
+	if err := h.checkCacheSize(size); err != nil {
+		h.storage.Remove(cache)
+		_ = db.Delete(cache.ID, cache)
+		return err
+	}
 	cache.Complete = true
+	cache.UsedAt = time.Now().Unix()
 	if err := db.Update(cache.ID, cache); err != nil {
-		h.responseJSON(w, r, 500, err)
-		return
+		return err
 	}
-
-	h.responseJSON(w, r, 200)
+	h.evictCaches(db, cache.Repository, cache.ID)
+	return nil
 }
 
 // GET /_apis/artifactcache/artifacts/:id
@@ -338,8 +491,26 @@ func (h *Handler) get(w http.ResponseWriter, r *http.Request, params httprouter.
 		h.responseJSON(w, r, 400, err)
 		return
 	}
+
+	cache := &Cache{}
+	db, err := h.openDB()
+	if err != nil {
+		h.responseJSON(w, r, 500, err)
+		return
+	}
+	defer db.Close()
+	if err := db.Get(id, cache); err != nil {
+		if errors.Is(err, bolthold.ErrNotFound) {
+			h.responseJSON(w, r, 404, fmt.Errorf("cache %d: not found", id))
+			return
+		}
+		h.responseJSON(w, r, 500, err)
+		return
+	}
+	db.Close()
+
 	h.useCache(id)
-	h.storage.Serve(w, r, id)
+	h.storage.Serve(w, r, cache)
 }
 
 // POST /_apis/artifactcache/clean
@@ -353,19 +524,28 @@ func (h *Handler) clean(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 func (h *Handler) middleware(handler httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		h.logger.Debugf("%s %s", r.Method, r.RequestURI)
+		// the signed urls are verified by their handlers
+		if h.tokenKey != nil && !strings.HasPrefix(r.URL.Path, urlBase+"/blobs/") {
+			if err := common.VerifyAuthorizationToken(r, h.tokenKey); err != nil {
+				h.responseJSON(w, r, 401, err)
+				return
+			}
+		}
 		handler(w, r, params)
 		go h.gcCache()
 	}
 }
 
 // if not found, return (nil, nil) instead of an error.
-func findCache(db *bolthold.Store, keys []string, version string) (*Cache, error) {
+func findCache(db *bolthold.Store, keys []string, version, repository, scope string) (*Cache, error) {
 	cache := &Cache{}
 	for _, prefix := range keys {
 		// if a key in the list matches exactly, don't return partial matches
 		if err := db.FindOne(cache,
 			bolthold.Where("Key").Eq(prefix).
 				And("Version").Eq(version).
+				And("Repository").Eq(repository).
+				And("Scope").Eq(scope).
 				And("Complete").Eq(true).
 				SortBy("CreatedAt").Reverse()); err == nil || !errors.Is(err, bolthold.ErrNotFound) {
 			if err != nil {
@@ -381,6 +561,8 @@ func findCache(db *bolthold.Store, keys []string, version string) (*Cache, error
 		if err := db.FindOne(cache,
 			bolthold.Where("Key").RegExp(re).
 				And("Version").Eq(version).
+				And("Repository").Eq(repository).
+				And("Scope").Eq(scope).
 				And("Complete").Eq(true).
 				SortBy("CreatedAt").Reverse()); err != nil {
 			if errors.Is(err, bolthold.ErrNotFound) {
@@ -456,7 +638,8 @@ func (h *Handler) gcCache() {
 		h.logger.Warnf("find caches: %v", err)
 	} else {
 		for _, cache := range caches {
-			h.storage.Remove(cache.ID)
+			h.storage.Remove(cache)
+			_ = os.RemoveAll(h.blockDir(cache.ID))
 			if err := db.Delete(cache.ID, cache); err != nil {
 				h.logger.Warnf("delete cache: %v", err)
 				continue
@@ -473,7 +656,7 @@ func (h *Handler) gcCache() {
 		h.logger.Warnf("find caches: %v", err)
 	} else {
 		for _, cache := range caches {
-			h.storage.Remove(cache.ID)
+			h.storage.Remove(cache)
 			if err := db.Delete(cache.ID, cache); err != nil {
 				h.logger.Warnf("delete cache: %v", err)
 				continue
@@ -490,7 +673,7 @@ func (h *Handler) gcCache() {
 		h.logger.Warnf("find caches: %v", err)
 	} else {
 		for _, cache := range caches {
-			h.storage.Remove(cache.ID)
+			h.storage.Remove(cache)
 			if err := db.Delete(cache.ID, cache); err != nil {
 				h.logger.Warnf("delete cache: %v", err)
 				continue
@@ -499,12 +682,12 @@ func (h *Handler) gcCache() {
 		}
 	}
 
-	// Remove the old caches with the same key and version, keep the latest one.
+	// Remove the old caches with the same key, version, repository and scope, keep the latest one.
 	// Also keep the olds which have been used recently for a while in case of the cache is still in use.
 	if results, err := db.FindAggregate(
 		&Cache{},
 		bolthold.Where("Complete").Eq(true),
-		"Key", "Version",
+		"Key", "Version", "Repository", "Scope",
 	); err != nil {
 		h.logger.Warnf("find aggregate caches: %v", err)
 	} else {
@@ -521,7 +704,7 @@ func (h *Handler) gcCache() {
 					// Or it could break downloading in process.
 					continue
 				}
-				h.storage.Remove(cache.ID)
+				h.storage.Remove(cache)
 				if err := db.Delete(cache.ID, cache); err != nil {
 					h.logger.Warnf("delete cache: %v", err)
 					continue
@@ -530,6 +713,20 @@ func (h *Handler) gcCache() {
 			}
 		}
 	}
+
+	// Evict the least recently used caches of the repositories exceeding the quota, it may have been lowered.
+	if h.maxSize > 0 {
+		usage, err := cacheUsage(db)
+		if err != nil {
+			h.logger.Warnf("cache usage: %v", err)
+			return
+		}
+		for _, repository := range usage.Repositories {
+			if repository.Size > h.maxSize {
+				h.evictCaches(db, repository.Repository, 0)
+			}
+		}
+	}
 }
 
 func (h *Handler) responseJSON(w http.ResponseWriter, r *http.Request, code int, v ...any) {
@@ -0,0 +1,161 @@
+package artifactcache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+func TestHandler_Scopes(t *testing.T) {
+	handler, err := StartHandler(filepath.Join(t.TempDir(), "artifactcache"), "", 0, "", "", 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+	}()
+	// the scopes are only trusted if the signature of the token is verified
+	key := []byte("run key")
+	handler.RequireRuntimeToken(key)
+	base := fmt.Sprintf("%s%s", handler.ExternalURL(), urlBase)
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+
+	token := func(scopes ...common.CacheScope) string {
+		token, err := common.CreateCacheAuthorizationToken(1, 1, 1, common.CacheAccess{Repository: "nektos/act", Scopes: scopes}, key)
+		require.NoError(t, err)
+		return token
+	}
+	main := token(common.CacheScope{Scope: "refs/heads/main", Permission: common.CachePermissionRead | common.CachePermissionWrite})
+	feature := token(
+		common.CacheScope{Scope: "refs/heads/feature", Permission: common.CachePermissionRead | common.CachePermissionWrite},
+		common.CacheScope{Scope: "refs/heads/main", Permission: common.CachePermissionRead},
+	)
+	other := token(
+		common.CacheScope{Scope: "refs/heads/other", Permission: common.CachePermissionRead | common.CachePermissionWrite},
+		common.CacheScope{Scope: "refs/heads/main", Permission: common.CachePermissionRead},
+	)
+	readOnly := token(common.CacheScope{Scope: "refs/heads/main", Permission: common.CachePermissionRead})
+	unscoped := token()
+
+	do := func(method, url, token string, body []byte) *http.Response {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/*", len(body)-1))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	save := func(token, key string) int {
+		body, err := json.Marshal(&Request{Key: key, Version: version, Size: 3})
+		require.NoError(t, err)
+		resp := do(http.MethodPost, base+"/caches", token, body)
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode
+		}
+		got := struct {
+			CacheID uint64 `json:"cacheId"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		require.Equal(t, http.StatusOK, do(http.MethodPatch, fmt.Sprintf("%s/caches/%d", base, got.CacheID), token, []byte(key[:3])).StatusCode)
+		require.Equal(t, http.StatusOK, do(http.MethodPost, fmt.Sprintf("%s/caches/%d", base, got.CacheID), token, nil).StatusCode)
+		return http.StatusOK
+	}
+	restore := func(token, keys string) string {
+		resp := do(http.MethodGet, fmt.Sprintf("%s/cache?keys=%s&version=%s", base, keys, version), token, nil)
+		if resp.StatusCode == http.StatusNoContent {
+			return ""
+		}
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		got := struct {
+			CacheKey string `json:"cacheKey"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		return got.CacheKey
+	}
+
+	require.Equal(t, http.StatusOK, save(main, "deps-main"))
+	require.Equal(t, http.StatusOK, save(feature, "deps"))
+	assert.Equal(t, http.StatusForbidden, save(readOnly, "deps-readonly"))
+
+	// the caches of the current ref come first, then those of the default branch
+	assert.Equal(t, "deps", restore(feature, "deps"))
+	assert.Equal(t, "deps-main", restore(feature, "deps-m"))
+	// caches of other branches are not visible, otherwise the exact match would win
+	assert.Equal(t, "deps-main", restore(other, "deps"))
+	assert.Equal(t, "deps-main", restore(main, "deps"))
+	// clients without a scoped token only see unscoped caches
+	assert.Equal(t, "", restore(unscoped, "deps"))
+	require.Equal(t, http.StatusOK, save(unscoped, "deps-unscoped"))
+	assert.Equal(t, "deps-unscoped", restore(unscoped, "deps"))
+	assert.Equal(t, "deps-main", restore(main, "deps"))
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, fmt.Sprintf("%s/cache?keys=deps&version=%s", base, version), "invalid", nil).StatusCode)
+}
+
+func TestHandler_RuntimeToken(t *testing.T) {
+	handler, err := StartHandler(filepath.Join(t.TempDir(), "artifactcache"), "", 0, "", "", 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+	}()
+	key := []byte("run key")
+	handler.RequireRuntimeToken(key)
+	base := fmt.Sprintf("%s%s", handler.ExternalURL(), urlBase)
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+
+	token, err := common.CreateCacheAuthorizationToken(1, 1, 1, common.CacheAccess{}, key)
+	require.NoError(t, err)
+	otherToken, err := common.CreateCacheAuthorizationToken(1, 1, 1, common.CacheAccess{}, []byte("other key"))
+	require.NoError(t, err)
+
+	do := func(method, url, token string, body []byte) *http.Response {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/*", len(body)-1))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	find := base + "/cache?keys=deps&version=" + version
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, find, "", nil).StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, find, otherToken, nil).StatusCode)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodGet, find, token, nil).StatusCode)
+
+	body, err := json.Marshal(&Request{Key: "deps", Version: version, Size: 3})
+	require.NoError(t, err)
+	resp := do(http.MethodPost, base+"/caches", token, body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	got := struct {
+		CacheID uint64 `json:"cacheId"`
+	}{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Equal(t, http.StatusOK, do(http.MethodPatch, fmt.Sprintf("%s/caches/%d", base, got.CacheID), token, []byte("abc")).StatusCode)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, fmt.Sprintf("%s/caches/%d", base, got.CacheID), token, nil).StatusCode)
+
+	resp = do(http.MethodGet, find, token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	hit := struct {
+		ArchiveLocation string `json:"archiveLocation"`
+	}{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&hit))
+	// the toolkit downloads the archive without the runtime token from a signed url
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, hit.ArchiveLocation, "", nil).StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, fmt.Sprintf("%s/artifacts/%d", base, got.CacheID), "", nil).StatusCode)
+}
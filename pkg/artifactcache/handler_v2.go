@@ -0,0 +1,446 @@
+package artifactcache
+
+// GitHub Actions Cache Service V2 API Simple Description
+//
+// The toolkit selects it if ACTIONS_CACHE_SERVICE_V2 is set, the service is served at ACTIONS_RESULTS_URL.
+//
+// 1. Save cache
+// 1.1. CreateCacheEntry
+// Post: /twirp/github.actions.results.api.v1.CacheService/CreateCacheEntry
+// Request:
+// {
+//     "key": "linux-npm-f5d1b6c8",
+//     "version": "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+// }
+// Response:
+// {
+//     "ok": true,
+//     "signedUploadUrl": "http://localhost:34567/_apis/artifactcache/blobs/3?expires=1706043517&sig=mO7y35r4GyjN7fwg0DTv3-Fv1NDXD84KLEgLpoPOtDI="
+// }
+// 1.2. Upload the archive like to an Azure block blob, at once
+// PUT: http://localhost:34567/_apis/artifactcache/blobs/3?expires=1706043517&sig=mO7y35r4GyjN7fwg0DTv3-Fv1NDXD84KLEgLpoPOtDI=
+// or in blocks, which may be uploaded concurrently, followed by the list of the blocks
+// PUT: http://localhost:34567/_apis/artifactcache/blobs/3?expires=1706043517&sig=mO7y35r4GyjN7fwg0DTv3-Fv1NDXD84KLEgLpoPOtDI=&comp=block&blockid=MDAwMDAw
+// PUT: http://localhost:34567/_apis/artifactcache/blobs/3?expires=1706043517&sig=mO7y35r4GyjN7fwg0DTv3-Fv1NDXD84KLEgLpoPOtDI=&comp=blocklist
+// <?xml version="1.0" encoding="utf-8"?><BlockList><Latest>MDAwMDAw</Latest></BlockList>
+// 1.3. FinalizeCacheEntryUpload
+// Post: /twirp/github.actions.results.api.v1.CacheService/FinalizeCacheEntryUpload
+// Request:
+// {
+//     "key": "linux-npm-f5d1b6c8",
+//     "size_bytes": "2097",
+//     "version": "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+// }
+// Response:
+// {
+//     "ok": true,
+//     "entryId": "3"
+// }
+// 2. Restore cache
+// 2.1. GetCacheEntryDownloadURL, the key and the restore keys are matched like by the v1 api
+// Post: /twirp/github.actions.results.api.v1.CacheService/GetCacheEntryDownloadURL
+// Request:
+// {
+//     "key": "linux-npm-f5d1b6c8",
+//     "restore_keys": ["linux-npm-"],
+//     "version": "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+// }
+// Response:
+// {
+//     "ok": true,
+//     "signedDownloadUrl": "http://localhost:34567/_apis/artifactcache/blobs/3?expires=1706043517&sig=wHzFOwpF-6220-5CA0CIRmAX9VbiTC2Mji89UOqo1E8=",
+//     "matchedKey": "linux-npm-f5d1b6c8"
+// }
+// 2.2. Download the archive
+// GET: http://localhost:34567/_apis/artifactcache/blobs/3?expires=1706043517&sig=wHzFOwpF-6220-5CA0CIRmAX9VbiTC2Mji89UOqo1E8=
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/timshannon/bolthold"
+	"google.golang.org/protobuf/encoding/protojson"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	cacheV2RouteBase = "/twirp/github.actions.results.api.v1.CacheService"
+
+	// signedURLExpiry is how long the signed urls to upload and download an archive are valid
+	signedURLExpiry = time.Hour
+)
+
+func (h *Handler) routesV2(router *httprouter.Router) {
+	router.POST(path.Join(cacheV2RouteBase, "CreateCacheEntry"), h.middleware(h.createCacheEntry))
+	router.POST(path.Join(cacheV2RouteBase, "FinalizeCacheEntryUpload"), h.middleware(h.finalizeCacheEntryUpload))
+	router.POST(path.Join(cacheV2RouteBase, "GetCacheEntryDownloadURL"), h.middleware(h.getCacheEntryDownloadURL))
+	router.PUT(urlBase+"/blobs/:id", h.middleware(h.uploadBlob))
+	router.GET(urlBase+"/blobs/:id", h.middleware(h.downloadBlob))
+}
+
+// ProxyResults forwards the requests to the results url which aren't handled by the cache service, like those of the
+// artifact service, to upstream. Both services are served at ACTIONS_RESULTS_URL, so the url of the cache server can
+// only be used as ACTIONS_RESULTS_URL if it forwards the other requests to the artifact server.
+func (h *Handler) ProxyResults(upstream *url.URL) {
+	h.ServeResults(&httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			r.SetXForwarded()
+		},
+	})
+}
+
+// ServeResults passes the requests which aren't handled by the cache service to handler, like ProxyResults for an
+// artifact server served by the same process.
+func (h *Handler) ServeResults(handler http.Handler) {
+	h.router.NotFound = handler
+	h.router.HandleMethodNotAllowed = false
+}
+
+// POST /twirp/github.actions.results.api.v1.CacheService/CreateCacheEntry
+func (h *Handler) createCacheEntry(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	req := &CreateCacheEntryRequest{}
+	if !h.parseProtobufBody(w, r, req) {
+		return
+	}
+
+	access, err := parseCacheAccess(r, h.tokenKey)
+	if err != nil {
+		h.twirpError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+	scope, err := writeScope(access)
+	if err != nil {
+		h.twirpError(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	// cache keys are case insensitive
+	cache := &Cache{
+		Key:        strings.ToLower(req.Key),
+		Version:    req.Version,
+		Repository: access.Repository,
+		Scope:      scope,
+		// the size is sent when the upload is finalized
+		Size: -1,
+	}
+	db, err := h.openDB()
+	if err != nil {
+		h.twirpError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	now := time.Now().Unix()
+	cache.CreatedAt = now
+	cache.UsedAt = now
+	if err := insertCache(db, cache); err != nil {
+		h.twirpError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.sendProtobufBody(w, r, &CreateCacheEntryResponse{
+		Ok:              true,
+		SignedUploadUrl: h.signedBlobURL("upload", cache.ID),
+	})
+}
+
+// POST /twirp/github.actions.results.api.v1.CacheService/FinalizeCacheEntryUpload
+func (h *Handler) finalizeCacheEntryUpload(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	req := &FinalizeCacheEntryUploadRequest{}
+	if !h.parseProtobufBody(w, r, req) {
+		return
+	}
+
+	access, err := parseCacheAccess(r, h.tokenKey)
+	if err != nil {
+		h.twirpError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+	scope, err := writeScope(access)
+	if err != nil {
+		h.twirpError(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	db, err := h.openDB()
+	if err != nil {
+		h.twirpError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+	cache := &Cache{}
+	if err := db.FindOne(cache, bolthold.
+		Where("Key").Eq(strings.ToLower(req.Key)).
+		And("Version").Eq(req.Version).
+		And("Repository").Eq(access.Repository).
+		And("Scope").Eq(scope).
+		And("Complete").Eq(false).
+		SortBy("CreatedAt").Reverse()); err != nil {
+		if errors.Is(err, bolthold.ErrNotFound) {
+			h.twirpError(w, r, http.StatusNotFound, fmt.Errorf("cache %q: not reserved", req.Key))
+			return
+		}
+		h.twirpError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	db.Close()
+
+	if req.SizeBytes > 0 {
+		cache.Size = req.SizeBytes
+	}
+	if err := h.commitCache(cache); err != nil {
+		h.twirpError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	h.sendProtobufBody(w, r, &FinalizeCacheEntryUploadResponse{
+		Ok:      true,
+		EntryId: int64(cache.ID),
+	})
+}
+
+// POST /twirp/github.actions.results.api.v1.CacheService/GetCacheEntryDownloadURL
+func (h *Handler) getCacheEntryDownloadURL(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	req := &GetCacheEntryDownloadURLRequest{}
+	if !h.parseProtobufBody(w, r, req) {
+		return
+	}
+
+	keys := append([]string{req.Key}, req.RestoreKeys...)
+	// cache keys are case insensitive
+	for i, key := range keys {
+		keys[i] = strings.ToLower(key)
+	}
+	access, err := parseCacheAccess(r, h.tokenKey)
+	if err != nil {
+		h.twirpError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+	cache, err := h.lookupCache(keys, req.Version, access)
+	if err != nil {
+		h.twirpError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if cache == nil {
+		h.sendProtobufBody(w, r, &GetCacheEntryDownloadURLResponse{})
+		return
+	}
+	h.sendProtobufBody(w, r, &GetCacheEntryDownloadURLResponse{
+		Ok:                true,
+		SignedDownloadUrl: h.signedBlobURL("download", cache.ID),
+		MatchedKey:        cache.Key,
+	})
+}
+
+// PUT /_apis/artifactcache/blobs/:id
+func (h *Handler) uploadBlob(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	cache, ok := h.verifyBlobURL(w, r, params)
+	if !ok {
+		return
+	}
+	if cache.Complete {
+		h.responseJSON(w, r, 400, fmt.Errorf("cache %v %q: already complete", cache.ID, cache.Key))
+		return
+	}
+
+	switch r.URL.Query().Get("comp") {
+	case "":
+		if err := h.storage.Write(cache, 0, r.Body); err != nil {
+			h.responseJSON(w, r, 500, err)
+			return
+		}
+	case "block":
+		if err := h.writeBlock(cache, r.URL.Query().Get("blockid"), r.Body); err != nil {
+			h.responseJSON(w, r, 500, err)
+			return
+		}
+	case "blocklist":
+		if err := h.writeBlockList(cache, r.Body); err != nil {
+			h.responseJSON(w, r, 500, err)
+			return
+		}
+	default:
+		h.responseJSON(w, r, 400, fmt.Errorf("unsupported operation %q", r.URL.Query().Get("comp")))
+		return
+	}
+	h.useCache(cache.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GET /_apis/artifactcache/blobs/:id
+func (h *Handler) downloadBlob(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	cache, ok := h.verifyBlobURL(w, r, params)
+	if !ok {
+		return
+	}
+	h.useCache(cache.ID)
+	h.storage.Serve(w, r, cache)
+}
+
+func (h *Handler) blockDir(id uint64) string {
+	return blockDir(h.dir, id)
+}
+
+func blockDir(dir string, id uint64) string {
+	return filepath.Join(dir, "blocks", fmt.Sprint(id))
+}
+
+// writeBlock stages a block until the list of the blocks is uploaded, the blocks may be uploaded in any order
+func (h *Handler) writeBlock(cache *Cache, blockID string, reader io.Reader) error {
+	if blockID == "" {
+		return errors.New("missing block id")
+	}
+	if err := os.MkdirAll(h.blockDir(cache.ID), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(h.blockDir(cache.ID), hex.EncodeToString([]byte(blockID))))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, reader)
+	return err
+}
+
+// writeBlockList writes the staged blocks to the storage in the order of the list
+func (h *Handler) writeBlockList(cache *Cache, reader io.Reader) error {
+	defer os.RemoveAll(h.blockDir(cache.ID))
+
+	var list struct {
+		Blocks []string `xml:",any"`
+	}
+	if err := xml.NewDecoder(reader).Decode(&list); err != nil {
+		return fmt.Errorf("parse block list: %w", err)
+	}
+	var offset int64
+	for _, blockID := range list.Blocks {
+		file, err := os.Open(filepath.Join(h.blockDir(cache.ID), hex.EncodeToString([]byte(blockID))))
+		if err != nil {
+			return fmt.Errorf("block %q: %w", blockID, err)
+		}
+		info, err := file.Stat()
+		if err == nil {
+			err = h.storage.Write(cache, offset, file)
+		}
+		file.Close()
+		if err != nil {
+			return err
+		}
+		offset += info.Size()
+	}
+	return nil
+}
+
+func (h *Handler) signBlobURL(operation string, id uint64, expires string) string {
+	mac := hmac.New(sha256.New, h.signingKey)
+	mac.Write([]byte(operation))
+	mac.Write([]byte(fmt.Sprint(id)))
+	mac.Write([]byte(expires))
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (h *Handler) signedBlobURL(operation string, id uint64) string {
+	expires := strconv.FormatInt(time.Now().Add(signedURLExpiry).Unix(), 10)
+	return fmt.Sprintf("%s%s/blobs/%d?expires=%s&sig=%s", h.ExternalURL(), urlBase, id, expires, url.QueryEscape(h.signBlobURL(operation, id, expires)))
+}
+
+// verifyBlobURL checks the signature of the url and returns the cache of the blob
+func (h *Handler) verifyBlobURL(w http.ResponseWriter, r *http.Request, params httprouter.Params) (*Cache, bool) {
+	id, err := strconv.ParseUint(params.ByName("id"), 10, 64)
+	if err != nil {
+		h.responseJSON(w, r, 400, err)
+		return nil, false
+	}
+	operation := "download"
+	if r.Method == http.MethodPut {
+		operation = "upload"
+	}
+	expires := r.URL.Query().Get("expires")
+	if !hmac.Equal([]byte(r.URL.Query().Get("sig")), []byte(h.signBlobURL(operation, id, expires))) {
+		h.responseJSON(w, r, 401, errors.New("invalid signature"))
+		return nil, false
+	}
+	if t, err := strconv.ParseInt(expires, 10, 64); err != nil || time.Now().Unix() > t {
+		h.responseJSON(w, r, 401, errors.New("url expired"))
+		return nil, false
+	}
+
+	db, err := h.openDB()
+	if err != nil {
+		h.responseJSON(w, r, 500, err)
+		return nil, false
+	}
+	defer db.Close()
+	cache := &Cache{}
+	if err := db.Get(id, cache); err != nil {
+		if errors.Is(err, bolthold.ErrNotFound) {
+			h.responseJSON(w, r, 404, fmt.Errorf("cache %d: not found", id))
+			return nil, false
+		}
+		h.responseJSON(w, r, 500, err)
+		return nil, false
+	}
+	return cache, true
+}
+
+func (h *Handler) parseProtobufBody(w http.ResponseWriter, r *http.Request, req protoreflect.ProtoMessage) bool {
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, req)
+	}
+	if err != nil {
+		h.twirpError(w, r, http.StatusBadRequest, fmt.Errorf("decode request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) sendProtobufBody(w http.ResponseWriter, r *http.Request, resp protoreflect.ProtoMessage) {
+	data, err := protojson.Marshal(resp)
+	if err != nil {
+		h.twirpError(w, r, http.StatusInternalServerError, fmt.Errorf("encode response body: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// twirpError responds with an error in the format of twirp, which is reported by the toolkit
+func (h *Handler) twirpError(w http.ResponseWriter, r *http.Request, code int, err error) {
+	h.logger.Errorf("%v %v: %v", r.Method, r.RequestURI, err)
+	twirpCode := "internal"
+	switch code {
+	case http.StatusBadRequest:
+		twirpCode = "invalid_argument"
+	case http.StatusUnauthorized:
+		twirpCode = "unauthenticated"
+	case http.StatusForbidden:
+		twirpCode = "permission_denied"
+	case http.StatusNotFound:
+		twirpCode = "not_found"
+	}
+	data, _ := json.Marshal(map[string]string{
+		"code": twirpCode,
+		"msg":  err.Error(),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,161 @@
+package artifactcache
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func callCacheV2(t *testing.T, handler *Handler, method, body string, resp protoreflect.ProtoMessage) int {
+	r, err := http.Post(handler.ExternalURL()+path.Join(cacheV2RouteBase, method), "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	if r.StatusCode == http.StatusOK && resp != nil {
+		require.NoError(t, protojson.Unmarshal(data, resp), method)
+	}
+	return r.StatusCode
+}
+
+func putBlob(t *testing.T, url string, content []byte) int {
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(content))
+	require.NoError(t, err)
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func downloadCacheV2(t *testing.T, handler *Handler, body string) (string, []byte) {
+	got := &GetCacheEntryDownloadURLResponse{}
+	require.Equal(t, http.StatusOK, callCacheV2(t, handler, "GetCacheEntryDownloadURL", body, got))
+	if !got.Ok {
+		return "", nil
+	}
+	resp, err := http.Get(got.SignedDownloadUrl)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return got.MatchedKey, content
+}
+
+func TestHandlerV2(t *testing.T) {
+	handler, err := StartHandler(filepath.Join(t.TempDir(), "artifactcache"), "", 0, "", "", 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+	}()
+
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+
+	t.Run("upload at once", func(t *testing.T) {
+		created := &CreateCacheEntryResponse{}
+		require.Equal(t, http.StatusOK, callCacheV2(t, handler, "CreateCacheEntry", `{"key":"Linux-Deps-1","version":"`+version+`"}`, created))
+		require.True(t, created.Ok)
+		assert.Equal(t, http.StatusCreated, putBlob(t, created.SignedUploadUrl, []byte("content")))
+
+		finalized := &FinalizeCacheEntryUploadResponse{}
+		require.Equal(t, http.StatusOK, callCacheV2(t, handler, "FinalizeCacheEntryUpload", `{"key":"Linux-Deps-1","version":"`+version+`","size_bytes":"7"}`, finalized))
+		assert.True(t, finalized.Ok)
+		assert.NotZero(t, finalized.EntryId)
+
+		// a completed cache can't be uploaded again
+		assert.Equal(t, http.StatusBadRequest, putBlob(t, created.SignedUploadUrl, []byte("content")))
+
+		key, content := downloadCacheV2(t, handler, `{"key":"linux-deps-2","restore_keys":["linux-deps-"],"version":"`+version+`"}`)
+		assert.Equal(t, "linux-deps-1", key)
+		assert.Equal(t, "content", string(content))
+	})
+
+	t.Run("upload in blocks", func(t *testing.T) {
+		created := &CreateCacheEntryResponse{}
+		require.Equal(t, http.StatusOK, callCacheV2(t, handler, "CreateCacheEntry", `{"key":"blocks","version":"`+version+`"}`, created))
+
+		blocks := []string{"first ", "second ", "third"}
+		blockList := `<?xml version="1.0" encoding="utf-8"?><BlockList>`
+		for i := range blocks {
+			blockList += "<Latest>" + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%06d", i))) + "</Latest>"
+		}
+		blockList += "</BlockList>"
+		// the blocks are uploaded concurrently, so they may arrive in any order
+		for _, i := range []int{2, 0, 1} {
+			blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%06d", i)))
+			assert.Equal(t, http.StatusCreated, putBlob(t, created.SignedUploadUrl+"&comp=block&blockid="+url.QueryEscape(blockID), []byte(blocks[i])))
+		}
+		assert.Equal(t, http.StatusCreated, putBlob(t, created.SignedUploadUrl+"&comp=blocklist", []byte(blockList)))
+		require.Equal(t, http.StatusOK, callCacheV2(t, handler, "FinalizeCacheEntryUpload", `{"key":"blocks","version":"`+version+`","size_bytes":"18"}`, nil))
+
+		key, content := downloadCacheV2(t, handler, `{"key":"blocks","version":"`+version+`"}`)
+		assert.Equal(t, "blocks", key)
+		assert.Equal(t, "first second third", string(content))
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		key, _ := downloadCacheV2(t, handler, `{"key":"blocks","version":"other"}`)
+		assert.Empty(t, key)
+	})
+
+	t.Run("finalize without reserving", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, callCacheV2(t, handler, "FinalizeCacheEntryUpload", `{"key":"unknown","version":"`+version+`","size_bytes":"1"}`, nil))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		created := &CreateCacheEntryResponse{}
+		require.Equal(t, http.StatusOK, callCacheV2(t, handler, "CreateCacheEntry", `{"key":"signed","version":"`+version+`"}`, created))
+		u, err := url.Parse(created.SignedUploadUrl)
+		require.NoError(t, err)
+		query := u.Query()
+		query.Set("expires", "1")
+		u.RawQuery = query.Encode()
+		assert.Equal(t, http.StatusUnauthorized, putBlob(t, u.String(), []byte("content")))
+
+		// the upload url can't be used to download
+		resp, err := http.Get(created.SignedUploadUrl)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestHandler_ProxyResults(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("upstream " + r.URL.Path))
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	handler, err := StartHandler(filepath.Join(t.TempDir(), "artifactcache"), "", 0, "", "", 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+	}()
+	handler.ProxyResults(upstreamURL)
+
+	resp, err := http.Post(handler.ExternalURL()+"/twirp/github.actions.results.api.v1.ArtifactService/ListArtifacts", "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "upstream /twirp/github.actions.results.api.v1.ArtifactService/ListArtifacts", string(body))
+
+	// the requests of the cache service are still handled
+	assert.Equal(t, http.StatusOK, callCacheV2(t, handler, "GetCacheEntryDownloadURL", `{"key":"key","version":"version"}`, nil))
+}
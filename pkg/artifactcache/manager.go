@@ -0,0 +1,143 @@
+package artifactcache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/timshannon/bolthold"
+)
+
+// Manager inspects and removes the caches of a cache server directory without serving them, e.g. for `act cache`.
+// The index is opened per call, so a running cache server may use the directory at the same time.
+type Manager struct {
+	dir     string
+	storage Storage
+}
+
+// NewManager returns a manager of the caches indexed in dir, storageURL is the same as for StartHandler.
+func NewManager(dir, storageURL string) (*Manager, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("cache server directory: %w", err)
+	}
+	storage, err := openStorage(dir, storageURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{
+		dir:     dir,
+		storage: storage,
+	}, nil
+}
+
+// List returns the caches whose key starts with prefix, sorted by key and creation time.
+func (m *Manager) List(prefix string) ([]*Cache, error) {
+	db, err := openDB(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := &bolthold.Query{}
+	if prefix != "" {
+		// the keys are stored in lower case
+		query = bolthold.Where("Key").RegExp(regexp.MustCompile("^" + regexp.QuoteMeta(strings.ToLower(prefix))))
+	}
+	var caches []*Cache
+	if err := db.Find(&caches, query); err != nil {
+		return nil, fmt.Errorf("find caches: %w", err)
+	}
+	sort.SliceStable(caches, func(i, j int) bool {
+		if caches[i].Key != caches[j].Key {
+			return caches[i].Key < caches[j].Key
+		}
+		return caches[i].CreatedAt < caches[j].CreatedAt
+	})
+	return caches, nil
+}
+
+// Get returns the cache with the id, or an error wrapping os.ErrNotExist.
+func (m *Manager) Get(id uint64) (*Cache, error) {
+	db, err := openDB(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	cache := &Cache{}
+	if err := db.Get(id, cache); errors.Is(err, bolthold.ErrNotFound) {
+		return nil, fmt.Errorf("cache %d: %w", id, os.ErrNotExist)
+	} else if err != nil {
+		return nil, fmt.Errorf("get cache %d: %w", id, err)
+	}
+	return cache, nil
+}
+
+// Remove removes the caches from the index and their archives from the storage.
+// The archives of a shared storage are kept, see RemoteStorage.
+func (m *Manager) Remove(caches ...*Cache) error {
+	db, err := openDB(m.dir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, cache := range caches {
+		if err := m.remove(db, cache); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manager) remove(db *bolthold.Store, cache *Cache) error {
+	m.storage.Remove(cache)
+	_ = os.RemoveAll(blockDir(m.dir, cache.ID))
+	if err := db.Delete(cache.ID, cache); err != nil && !errors.Is(err, bolthold.ErrNotFound) {
+		return fmt.Errorf("delete cache %d: %w", cache.ID, err)
+	}
+	return nil
+}
+
+// Prune removes the caches which have not been used for longer than unused, then the least recently used caches until
+// the total size doesn't exceed maxSize. A zero unused or maxSize skips the step. It returns the removed caches.
+// Incomplete caches may still be uploading, they are left to the cleanup of the cache server.
+func (m *Manager) Prune(unused time.Duration, maxSize int64) ([]*Cache, error) {
+	db, err := openDB(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var caches []*Cache
+	if err := db.Find(&caches, bolthold.Where("Complete").Eq(true).SortBy("UsedAt")); err != nil {
+		return nil, fmt.Errorf("find caches: %w", err)
+	}
+	var size int64
+	for _, cache := range caches {
+		if cache.Size > 0 {
+			size += cache.Size
+		}
+	}
+
+	removed := []*Cache{}
+	for _, cache := range caches {
+		expired := unused > 0 && time.Since(time.Unix(cache.UsedAt, 0)) > unused
+		exceeded := maxSize > 0 && size > maxSize
+		if !expired && !exceeded {
+			continue
+		}
+		if err := m.remove(db, cache); err != nil {
+			return removed, err
+		}
+		if cache.Size > 0 {
+			size -= cache.Size
+		}
+		removed = append(removed, cache)
+	}
+	return removed, nil
+}
@@ -0,0 +1,89 @@
+package artifactcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "artifactcache")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	manager, err := NewManager(dir, "")
+	require.NoError(t, err)
+
+	now := time.Now()
+	caches := []*Cache{
+		{Key: "linux-deps-2", Version: "v", Size: 4, Complete: true, UsedAt: now.Unix(), CreatedAt: now.Add(-time.Hour).Unix()},
+		{Key: "linux-deps-1", Version: "v", Size: 4, Complete: true, UsedAt: now.Add(-time.Hour).Unix(), CreatedAt: now.Add(-2 * time.Hour).Unix()},
+		{Key: "linux-build", Version: "v", Size: 8, Complete: true, UsedAt: now.Add(-48 * time.Hour).Unix(), CreatedAt: now.Add(-48 * time.Hour).Unix()},
+		{Key: "windows-deps", Version: "v", Size: 2, Complete: true, UsedAt: now.Add(-2 * time.Hour).Unix(), CreatedAt: now.Add(-2 * time.Hour).Unix()},
+		// it's still uploading, so it's never pruned
+		{Key: "linux-uploading", Version: "v", Size: 16, UsedAt: now.Add(-48 * time.Hour).Unix(), CreatedAt: now.Add(-48 * time.Hour).Unix()},
+	}
+	db, err := openDB(dir)
+	require.NoError(t, err)
+	for _, cache := range caches {
+		require.NoError(t, insertCache(db, cache))
+	}
+	require.NoError(t, db.Close())
+	storage, err := NewDiskStorage(filepath.Join(dir, "cache"))
+	require.NoError(t, err)
+	for _, cache := range caches {
+		require.NoError(t, os.MkdirAll(filepath.Dir(storage.filename(cache.ID)), 0o755))
+		require.NoError(t, os.WriteFile(storage.filename(cache.ID), make([]byte, cache.Size), 0o644))
+	}
+	keys := func(caches []*Cache) []string {
+		keys := []string{}
+		for _, cache := range caches {
+			keys = append(keys, cache.Key)
+		}
+		return keys
+	}
+
+	t.Run("list", func(t *testing.T) {
+		list, err := manager.List("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linux-build", "linux-deps-1", "linux-deps-2", "linux-uploading", "windows-deps"}, keys(list))
+
+		list, err = manager.List("Linux-Deps")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linux-deps-1", "linux-deps-2"}, keys(list))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		cache, err := manager.Get(caches[2].ID)
+		require.NoError(t, err)
+		assert.Equal(t, caches[2], cache)
+
+		_, err = manager.Get(100)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("prune unused", func(t *testing.T) {
+		removed, err := manager.Prune(24*time.Hour, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linux-build"}, keys(removed))
+		assert.NoFileExists(t, storage.filename(caches[2].ID))
+	})
+
+	t.Run("prune to size", func(t *testing.T) {
+		removed, err := manager.Prune(0, 5)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"windows-deps", "linux-deps-1"}, keys(removed))
+		assert.FileExists(t, storage.filename(caches[4].ID))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, manager.Remove(caches[0], caches[4]))
+		assert.NoFileExists(t, storage.filename(caches[0].ID))
+		assert.NoFileExists(t, storage.filename(caches[4].ID))
+		list, err := manager.List("")
+		require.NoError(t, err)
+		assert.Empty(t, list)
+	})
+}
@@ -24,11 +24,28 @@ func (c *Request) ToCache() *Cache {
 }
 
 type Cache struct {
-	ID        uint64 `json:"id" boltholdKey:"ID"`
-	Key       string `json:"key" boltholdIndex:"Key"`
-	Version   string `json:"version" boltholdIndex:"Version"`
-	Size      int64  `json:"cacheSize"`
-	Complete  bool   `json:"complete" boltholdIndex:"Complete"`
-	UsedAt    int64  `json:"usedAt" boltholdIndex:"UsedAt"`
-	CreatedAt int64  `json:"createdAt" boltholdIndex:"CreatedAt"`
+	ID         uint64 `json:"id" boltholdKey:"ID"`
+	Key        string `json:"key" boltholdIndex:"Key"`
+	Version    string `json:"version" boltholdIndex:"Version"`
+	Repository string `json:"repository"` // the repository which saved the cache, empty without a runtime token
+	Scope      string `json:"scope"`      // the ref which saved the cache, empty if unscoped
+	Size       int64  `json:"cacheSize"`
+	Complete   bool   `json:"complete" boltholdIndex:"Complete"`
+	UsedAt     int64  `json:"usedAt" boltholdIndex:"UsedAt"`
+	CreatedAt  int64  `json:"createdAt" boltholdIndex:"CreatedAt"`
+}
+
+// Usage is the total size and count of the completed caches of a repository
+type Usage struct {
+	Repository string `json:"repository"`
+	Size       int64  `json:"size"`
+	Count      int    `json:"count"`
+}
+
+// Stats is the response of the stats endpoint
+type Stats struct {
+	MaxSize      int64   `json:"maxSize"` // the quota per repository, 0 means no quota
+	Size         int64   `json:"size"`
+	Count        int     `json:"count"`
+	Repositories []Usage `json:"repositories"`
 }
@@ -0,0 +1,100 @@
+package artifactcache
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/timshannon/bolthold"
+)
+
+// GET /_apis/artifactcache/stats
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	db, err := h.openDB()
+	if err != nil {
+		h.responseJSON(w, r, 500, err)
+		return
+	}
+	defer db.Close()
+
+	stats, err := cacheUsage(db)
+	if err != nil {
+		h.responseJSON(w, r, 500, err)
+		return
+	}
+	stats.MaxSize = h.maxSize
+	h.responseJSON(w, r, 200, stats)
+}
+
+// checkCacheSize rejects caches which exceed the quota on their own, like GitHub rejects caches larger than 10 GB
+func (h *Handler) checkCacheSize(size int64) error {
+	if h.maxSize > 0 && size > h.maxSize {
+		return fmt.Errorf("cache size of %d bytes exceeds the quota of %d bytes", size, h.maxSize)
+	}
+	return nil
+}
+
+// evictCaches removes the least recently used caches of a repository until its caches fit in the quota.
+// The cache with the id keep is never evicted, it's the cache which was just committed. 0 keeps no cache.
+func (h *Handler) evictCaches(db *bolthold.Store, repository string, keep uint64) {
+	if h.maxSize <= 0 {
+		return
+	}
+	var caches []*Cache
+	if err := db.Find(&caches, bolthold.
+		Where("Complete").Eq(true).
+		And("Repository").Eq(repository).
+		SortBy("UsedAt"),
+	); err != nil {
+		h.logger.Warnf("find caches: %v", err)
+		return
+	}
+	var size int64
+	for _, cache := range caches {
+		size += cache.Size
+	}
+	for _, cache := range caches {
+		if size <= h.maxSize {
+			break
+		}
+		if cache.ID == keep {
+			continue
+		}
+		h.storage.Remove(cache)
+		if err := db.Delete(cache.ID, cache); err != nil {
+			h.logger.Warnf("delete cache: %v", err)
+			continue
+		}
+		size -= cache.Size
+		h.logger.Infof("evicted cache: %+v", cache)
+	}
+}
+
+// cacheUsage sums up the sizes of the completed caches per repository
+func cacheUsage(db *bolthold.Store) (*Stats, error) {
+	var caches []*Cache
+	if err := db.Find(&caches, bolthold.Where("Complete").Eq(true)); err != nil {
+		return nil, fmt.Errorf("find caches: %w", err)
+	}
+	stats := &Stats{Repositories: []Usage{}}
+	repositories := map[string]*Usage{}
+	for _, cache := range caches {
+		usage, ok := repositories[cache.Repository]
+		if !ok {
+			usage = &Usage{Repository: cache.Repository}
+			repositories[cache.Repository] = usage
+		}
+		usage.Size += cache.Size
+		usage.Count++
+		stats.Size += cache.Size
+		stats.Count++
+	}
+	for _, usage := range repositories {
+		stats.Repositories = append(stats.Repositories, *usage)
+	}
+	sort.Slice(stats.Repositories, func(i, j int) bool {
+		return stats.Repositories[i].Repository < stats.Repositories[j].Repository
+	})
+	return stats, nil
+}
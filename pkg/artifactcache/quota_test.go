@@ -0,0 +1,136 @@
+package artifactcache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timshannon/bolthold"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+func TestHandler_Quota(t *testing.T) {
+	handler, err := StartHandler(filepath.Join(t.TempDir(), "artifactcache"), "", 10, "", "", 0, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, handler.Close())
+	}()
+	key := []byte("run key")
+	handler.RequireRuntimeToken(key)
+	base := fmt.Sprintf("%s%s", handler.ExternalURL(), urlBase)
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+
+	now := time.Now()
+	caches := []*Cache{
+		{Key: "least-recently-used", Version: version, Repository: "nektos/act", Size: 4, Complete: true, UsedAt: now.Add(-3 * time.Hour).Unix(), CreatedAt: now.Add(-4 * time.Hour).Unix()},
+		{Key: "recently-used", Version: version, Repository: "nektos/act", Size: 4, Complete: true, UsedAt: now.Add(-time.Hour).Unix(), CreatedAt: now.Add(-5 * time.Hour).Unix()},
+		// the caches of other repositories don't count
+		{Key: "other", Version: version, Repository: "nektos/other", Size: 8, Complete: true, UsedAt: now.Add(-5 * time.Hour).Unix(), CreatedAt: now.Add(-5 * time.Hour).Unix()},
+	}
+	db, err := handler.openDB()
+	require.NoError(t, err)
+	for _, cache := range caches {
+		require.NoError(t, insertCache(db, cache))
+	}
+	require.NoError(t, db.Close())
+
+	token, err := common.CreateCacheAuthorizationToken(1, 1, 1, common.CacheAccess{
+		Repository: "nektos/act",
+		Scopes:     []common.CacheScope{{Scope: "", Permission: common.CachePermissionRead | common.CachePermissionWrite}},
+	}, key)
+	require.NoError(t, err)
+	do := func(method, url string, body []byte) *http.Response {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/*", len(body)-1))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	reserve := func(key string, size int64) *http.Response {
+		body, err := json.Marshal(&Request{Key: key, Version: version, Size: size})
+		require.NoError(t, err)
+		return do(http.MethodPost, base+"/caches", body)
+	}
+
+	t.Run("reject oversized caches", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, reserve("oversized", 11).StatusCode)
+	})
+
+	t.Run("evict least recently used", func(t *testing.T) {
+		resp := reserve("new", 4)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		got := struct {
+			CacheID uint64 `json:"cacheId"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		require.Equal(t, http.StatusOK, do(http.MethodPatch, fmt.Sprintf("%s/caches/%d", base, got.CacheID), []byte("data")).StatusCode)
+		require.Equal(t, http.StatusOK, do(http.MethodPost, fmt.Sprintf("%s/caches/%d", base, got.CacheID), nil).StatusCode)
+
+		db, err := handler.openDB()
+		require.NoError(t, err)
+		defer db.Close()
+		assert.ErrorIs(t, db.Get(caches[0].ID, &Cache{}), bolthold.ErrNotFound)
+		assert.NoError(t, db.Get(caches[1].ID, &Cache{}))
+		assert.NoError(t, db.Get(caches[2].ID, &Cache{}))
+		assert.NoError(t, db.Get(got.CacheID, &Cache{}))
+	})
+
+	t.Run("keep committed cache", func(t *testing.T) {
+		resp := reserve("newer", 4)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		got := struct {
+			CacheID uint64 `json:"cacheId"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+		// the other caches are restored while the cache is uploaded
+		db, err := handler.openDB()
+		require.NoError(t, err)
+		var used []*Cache
+		require.NoError(t, db.Find(&used, bolthold.Where("Repository").Eq("nektos/act").And("Complete").Eq(true)))
+		for _, cache := range used {
+			cache.UsedAt = time.Now().Add(time.Hour).Unix()
+			if cache.Key == "new" {
+				cache.UsedAt = time.Now().Add(2 * time.Hour).Unix()
+			}
+			require.NoError(t, db.Update(cache.ID, cache))
+		}
+		require.NoError(t, db.Close())
+
+		require.Equal(t, http.StatusOK, do(http.MethodPatch, fmt.Sprintf("%s/caches/%d", base, got.CacheID), []byte("data")).StatusCode)
+		require.Equal(t, http.StatusOK, do(http.MethodPost, fmt.Sprintf("%s/caches/%d", base, got.CacheID), nil).StatusCode)
+
+		db, err = handler.openDB()
+		require.NoError(t, err)
+		defer db.Close()
+		assert.ErrorIs(t, db.Get(caches[1].ID, &Cache{}), bolthold.ErrNotFound)
+		assert.NoError(t, db.Get(got.CacheID, &Cache{}))
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		resp := do(http.MethodGet, base+"/stats", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		got := &Stats{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(got))
+		assert.Equal(t, &Stats{
+			MaxSize: 10,
+			Size:    16,
+			Count:   3,
+			Repositories: []Usage{
+				{Repository: "nektos/act", Size: 8, Count: 2},
+				{Repository: "nektos/other", Size: 8, Count: 1},
+			},
+		}, got)
+	})
+}
@@ -8,21 +8,51 @@ import (
 	"path/filepath"
 )
 
-type Storage struct {
+// Storage stores the archives of the caches, the caches themselves are indexed by the handler.
+type Storage interface {
+	// Exist reports whether the archive of a completed cache exists.
+	Exist(cache *Cache) (bool, error)
+	// Write stores a chunk of an uploading cache starting at offset.
+	Write(cache *Cache, offset int64, reader io.Reader) error
+	// Commit assembles the chunks to the archive and returns its size.
+	Commit(cache *Cache) (int64, error)
+	// Serve responds with the archive.
+	Serve(w http.ResponseWriter, r *http.Request, cache *Cache)
+	// Remove removes the archive and the chunks of a cache.
+	Remove(cache *Cache)
+}
+
+// Index is implemented by storages shared by several handlers, it finds the caches uploaded by the other handlers.
+type Index interface {
+	// Find returns the newest completed cache of the version, repository and scope whose key matches one of keys
+	// exactly or by prefix, the keys are tried in order. If not found, it returns (nil, nil).
+	Find(keys []string, version, repository, scope string) (*Cache, error)
+}
+
+// openStorage returns the storage of the archives in dir/cache, or in the storage at storageURL if set.
+func openStorage(dir, storageURL string) (Storage, error) {
+	if storageURL == "" {
+		return NewDiskStorage(filepath.Join(dir, "cache"))
+	}
+	return NewRemoteStorage(storageURL, filepath.Join(dir, "cache"))
+}
+
+// DiskStorage stores the archives in a local directory.
+type DiskStorage struct {
 	rootDir string
 }
 
-func NewStorage(rootDir string) (*Storage, error) {
+func NewDiskStorage(rootDir string) (*DiskStorage, error) {
 	if err := os.MkdirAll(rootDir, 0o755); err != nil {
 		return nil, err
 	}
-	return &Storage{
+	return &DiskStorage{
 		rootDir: rootDir,
 	}, nil
 }
 
-func (s *Storage) Exist(id uint64) (bool, error) {
-	name := s.filename(id)
+func (s *DiskStorage) Exist(cache *Cache) (bool, error) {
+	name := s.filename(cache.ID)
 	if _, err := os.Stat(name); os.IsNotExist(err) {
 		return false, nil
 	} else if err != nil {
@@ -31,8 +61,8 @@ func (s *Storage) Exist(id uint64) (bool, error) {
 	return true, nil
 }
 
-func (s *Storage) Write(id uint64, offset int64, reader io.Reader) error {
-	name := s.tempName(id, offset)
+func (s *DiskStorage) Write(cache *Cache, offset int64, reader io.Reader) error {
+	name := s.tempName(cache.ID, offset)
 	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
 		return err
 	}
@@ -46,7 +76,8 @@ func (s *Storage) Write(id uint64, offset int64, reader io.Reader) error {
 	return err
 }
 
-func (s *Storage) Commit(id uint64, size int64) (int64, error) {
+func (s *DiskStorage) Commit(cache *Cache) (int64, error) {
+	id, size := cache.ID, cache.Size
 	defer func() {
 		_ = os.RemoveAll(s.tempDir(id))
 	}()
@@ -92,29 +123,29 @@ func (s *Storage) Commit(id uint64, size int64) (int64, error) {
 	return written, nil
 }
 
-func (s *Storage) Serve(w http.ResponseWriter, r *http.Request, id uint64) {
-	name := s.filename(id)
+func (s *DiskStorage) Serve(w http.ResponseWriter, r *http.Request, cache *Cache) {
+	name := s.filename(cache.ID)
 	http.ServeFile(w, r, name)
 }
 
-func (s *Storage) Remove(id uint64) {
-	_ = os.Remove(s.filename(id))
-	_ = os.RemoveAll(s.tempDir(id))
+func (s *DiskStorage) Remove(cache *Cache) {
+	_ = os.Remove(s.filename(cache.ID))
+	_ = os.RemoveAll(s.tempDir(cache.ID))
 }
 
-func (s *Storage) filename(id uint64) string {
+func (s *DiskStorage) filename(id uint64) string {
 	return filepath.Join(s.rootDir, fmt.Sprintf("%02x", id%0xff), fmt.Sprint(id))
 }
 
-func (s *Storage) tempDir(id uint64) string {
+func (s *DiskStorage) tempDir(id uint64) string {
 	return filepath.Join(s.rootDir, "tmp", fmt.Sprint(id))
 }
 
-func (s *Storage) tempName(id uint64, offset int64) string {
+func (s *DiskStorage) tempName(id uint64, offset int64) string {
 	return filepath.Join(s.tempDir(id), fmt.Sprintf("%016x", offset))
 }
 
-func (s *Storage) tempNames(id uint64) ([]string, error) {
+func (s *DiskStorage) tempNames(id uint64) ([]string, error) {
 	dir := s.tempDir(id)
 	files, err := os.ReadDir(dir)
 	if err != nil {
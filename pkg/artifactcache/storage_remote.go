@@ -0,0 +1,193 @@
+package artifactcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/nektos/act/pkg/filestore"
+)
+
+// RemoteStorage stores the archives in a storage shared by several handlers, like an S3 bucket or a WebDAV server.
+// The chunks are staged on the local disk until the cache is committed.
+//
+// The archives are stored by repository, scope, version and key, so the handlers find the caches of each other. Caches removed by
+// a handler are only forgotten by it, since others may still use them. The shared archives should be expired by the
+// remote storage, e.g. with a lifecycle rule of the S3 bucket.
+type RemoteStorage struct {
+	remote  filestore.Storage
+	staging *DiskStorage
+}
+
+// NewRemoteStorage returns a storage for a url supported by filestore.Open.
+func NewRemoteStorage(rawURL, stagingDir string) (*RemoteStorage, error) {
+	remote, err := filestore.Open(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	staging, err := NewDiskStorage(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteStorage{
+		remote:  remote,
+		staging: staging,
+	}, nil
+}
+
+func (s *RemoteStorage) Exist(cache *Cache) (bool, error) {
+	if _, err := fs.Stat(s.remote, remoteName(cache.Repository, cache.Scope, cache.Version, cache.Key)); errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *RemoteStorage) Write(cache *Cache, offset int64, reader io.Reader) error {
+	return s.staging.Write(cache, offset, reader)
+}
+
+func (s *RemoteStorage) Commit(cache *Cache) (int64, error) {
+	size, err := s.staging.Commit(cache)
+	if err != nil {
+		return 0, err
+	}
+	defer s.staging.Remove(cache)
+
+	file, err := os.Open(s.staging.filename(cache.ID))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	remote, err := s.remote.OpenWritable(remoteName(cache.Repository, cache.Scope, cache.Version, cache.Key))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(remote, file); err != nil {
+		_ = remote.Close()
+		return 0, err
+	}
+	if err := remote.Close(); err != nil {
+		return 0, fmt.Errorf("upload cache %q: %w", cache.Key, err)
+	}
+	return size, nil
+}
+
+func (s *RemoteStorage) Serve(w http.ResponseWriter, r *http.Request, cache *Cache) {
+	name := remoteName(cache.Repository, cache.Scope, cache.Version, cache.Key)
+	file, err := s.remote.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if content, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, path.Base(name), info.ModTime(), content)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if info.Size() >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+	_, _ = io.Copy(w, file)
+}
+
+func (s *RemoteStorage) Remove(cache *Cache) {
+	s.staging.Remove(cache)
+}
+
+func (s *RemoteStorage) Find(keys []string, version, repository, scope string) (*Cache, error) {
+	dir := remoteDir(repository, scope, version)
+	var entries []fs.DirEntry
+	for _, key := range keys {
+		// if a key in the list matches exactly, don't return partial matches
+		if info, err := fs.Stat(s.remote, remoteName(repository, scope, version, key)); err == nil {
+			return remoteCache(repository, scope, version, key, info), nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("find cache: %w", err)
+		}
+
+		if entries == nil {
+			var err error
+			if entries, err = fs.ReadDir(s.remote, dir); errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("find cache: %w", err)
+			}
+		}
+		prefix := escapeRemoteName(key)
+		var newest fs.FileInfo
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if newest == nil || info.ModTime().After(newest.ModTime()) {
+				newest = info
+			}
+		}
+		if newest == nil {
+			continue
+		}
+		key, err := url.PathUnescape(newest.Name())
+		if err != nil {
+			continue
+		}
+		return remoteCache(repository, scope, version, key, newest), nil
+	}
+	return nil, nil
+}
+
+func remoteCache(repository, scope, version, key string, info fs.FileInfo) *Cache {
+	return &Cache{
+		Key:        key,
+		Version:    version,
+		Repository: repository,
+		Scope:      scope,
+		Size:       info.Size(),
+		Complete:   true,
+		CreatedAt:  info.ModTime().Unix(),
+		UsedAt:     info.ModTime().Unix(),
+	}
+}
+
+// remoteDir returns {repository}/{scope}/{version}, the repository and scope are omitted if empty
+func remoteDir(repository, scope, version string) string {
+	dir := escapeRemoteName(version)
+	if scope != "" {
+		dir = escapeRemoteName(scope) + "/" + dir
+	}
+	if repository != "" {
+		dir = escapeRemoteName(repository) + "/" + dir
+	}
+	return dir
+}
+
+func remoteName(repository, scope, version, key string) string {
+	return remoteDir(repository, scope, version) + "/" + escapeRemoteName(key)
+}
+
+// escapeRemoteName escapes a repository, scope, version or key to a single path element, which is never "." or ".."
+func escapeRemoteName(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ".", "%2E")
+}
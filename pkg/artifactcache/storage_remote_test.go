@@ -0,0 +1,63 @@
+package artifactcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestHandler_RemoteStorage(t *testing.T) {
+	server := httptest.NewServer(&webdav.Handler{FileSystem: webdav.NewMemFS(), LockSystem: webdav.NewMemLS()})
+	defer server.Close()
+
+	var bases []string
+	for i := 0; i < 2; i++ {
+		handler, err := StartHandler(filepath.Join(t.TempDir(), "artifactcache"), server.URL, 0, "", "", 0, nil)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, handler.Close())
+		}()
+		bases = append(bases, fmt.Sprintf("%s%s", handler.ExternalURL(), urlBase))
+	}
+
+	version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+	content := make([]byte, 100)
+	uploadCacheNormally(t, bases[0], "os/linux-deps.1", version, content)
+
+	for _, tt := range []struct {
+		keys, key string
+	}{
+		// the first lookup is answered by the shared storage, the second one by the index of the handler
+		{"missing,os/linux-", "os/linux-deps.1"},
+		{"os/linux-deps.1", "os/linux-deps.1"},
+	} {
+		resp, err := http.Get(fmt.Sprintf("%s/cache?keys=%s&version=%s", bases[1], tt.keys, version))
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, tt.keys)
+		got := struct {
+			ArchiveLocation string `json:"archiveLocation"`
+			CacheKey        string `json:"cacheKey"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, tt.key, got.CacheKey)
+
+		resp, err = http.Get(got.ArchiveLocation) //nolint:gosec
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		archive, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, content, archive)
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/cache?keys=%s&version=%s", bases[1], "os/windows", version))
+	require.NoError(t, err)
+	assert.Equal(t, 204, resp.StatusCode)
+}
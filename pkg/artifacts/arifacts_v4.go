@@ -59,7 +59,8 @@ package artifacts
 //             "databaseId": "4",
 //             "name": "test",
 //             "size": "2093",
-//             "createdAt": "2024-01-23T00:13:28Z"
+//             "createdAt": "2024-01-23T00:13:28Z",
+//             "digest": "sha256:b6325614d5649338b87215d9536b3c0477729b8638994c74cdefacb020a2cad4"
 //         }
 //     ]
 // }
@@ -77,29 +78,44 @@ package artifacts
 // }
 // 2.3. Download Zip from Blobstorage (unauthenticated request)
 // GET: http://localhost:3000/twirp/github.actions.results.api.v1.ArtifactService/DownloadArtifact?sig=wHzFOwpF-6220-5CA0CIRmAX9VbiTC2Mji89UOqo1E8=&expires=2024-01-23+21%3A51%3A56.872846295+%2B0100+CET&artifactName=test&taskID=76
+// 3. Migrate artifact
+// 3.1. MigrateArtifact creates an artifact like CreateArtifact, the zip file is uploaded and finalized like 1.2 - 1.5
+// Post: /twirp/github.actions.results.api.v1.ArtifactService/MigrateArtifact
+// Request
+// {
+//     "workflow_run_backend_id": "21",
+//     "name": "test",
+//     "expires_at": "2024-04-22T00:00:00Z"
+// }
+// Response
+// {
+//     "ok": true,
+//     "signedUploadUrl": "http://localhost:3000/twirp/github.actions.results.api.v1.ArtifactService/UploadArtifact?sig=mO7y35r4GyjN7fwg0DTv3-Fv1NDXD84KLEgLpoPOtDI=&expires=2024-01-23+21%3A48%3A37.20833956+%2B0100+CET&artifactName=test&taskID=21"
+// }
 
 import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"hash/fnv"
 	"io"
 	"io/fs"
 	"net/http"
 	"net/url"
-	"os"
 	"path"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/nektos/act/pkg/filestore"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 const (
@@ -113,6 +129,8 @@ type artifactV4Routes struct {
 	rfs     fs.FS
 	AppURL  string
 	baseDir string
+	// signingKey signs the urls to upload and download the artifacts, a fixed key is used if nil
+	signingKey []byte
 }
 
 type ArtifactContext struct {
@@ -120,12 +138,6 @@ type ArtifactContext struct {
 	Resp http.ResponseWriter
 }
 
-func artifactNameToID(s string) int64 {
-	h := fnv.New32a()
-	h.Write([]byte(s))
-	return int64(h.Sum32())
-}
-
 func (c ArtifactContext) Error(status int, _ ...interface{}) {
 	c.Resp.WriteHeader(status)
 }
@@ -145,19 +157,31 @@ func validateRunIDV4(ctx *ArtifactContext, rawRunID string) (interface{}, int64,
 }
 
 func RoutesV4(router *httprouter.Router, baseDir string, fsys WriteFS, rfs fs.FS) {
+	routesV4(router, baseDir, fsys, rfs, nil)
+}
+
+func routesV4(router *httprouter.Router, baseDir string, fsys WriteFS, rfs fs.FS, signingKey []byte) {
 	route := &artifactV4Routes{
-		fs:      fsys,
-		rfs:     rfs,
-		baseDir: baseDir,
-		prefix:  ArtifactV4RouteBase,
+		fs:         fsys,
+		rfs:        rfs,
+		baseDir:    baseDir,
+		prefix:     ArtifactV4RouteBase,
+		signingKey: signingKey,
 	}
 	router.POST(path.Join(ArtifactV4RouteBase, "CreateArtifact"), func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-		route.AppURL = r.Host
+		route.AppURL = baseURL(r)
 		route.createArtifact(&ArtifactContext{
 			Req:  r,
 			Resp: w,
 		})
 	})
+	router.POST(path.Join(ArtifactV4RouteBase, "MigrateArtifact"), func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		route.AppURL = baseURL(r)
+		route.migrateArtifact(&ArtifactContext{
+			Req:  r,
+			Resp: w,
+		})
+	})
 	router.POST(path.Join(ArtifactV4RouteBase, "FinalizeArtifact"), func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		route.finalizeArtifact(&ArtifactContext{
 			Req:  r,
@@ -171,14 +195,14 @@ func RoutesV4(router *httprouter.Router, baseDir string, fsys WriteFS, rfs fs.FS
 		})
 	})
 	router.POST(path.Join(ArtifactV4RouteBase, "GetSignedArtifactURL"), func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-		route.AppURL = r.Host
+		route.AppURL = baseURL(r)
 		route.getSignedArtifactURL(&ArtifactContext{
 			Req:  r,
 			Resp: w,
 		})
 	})
 	router.POST(path.Join(ArtifactV4RouteBase, "DeleteArtifact"), func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-		route.AppURL = r.Host
+		route.AppURL = baseURL(r)
 		route.deleteArtifact(&ArtifactContext{
 			Req:  r,
 			Resp: w,
@@ -199,7 +223,11 @@ func RoutesV4(router *httprouter.Router, baseDir string, fsys WriteFS, rfs fs.FS
 }
 
 func (r artifactV4Routes) buildSignature(endp, expires, artifactName string, taskID int64) []byte {
-	mac := hmac.New(sha256.New, []byte{0xba, 0xdb, 0xee, 0xf0})
+	key := r.signingKey
+	if key == nil {
+		key = []byte{0xba, 0xdb, 0xee, 0xf0}
+	}
+	mac := hmac.New(sha256.New, key)
 	mac.Write([]byte(endp))
 	mac.Write([]byte(expires))
 	mac.Write([]byte(artifactName))
@@ -209,7 +237,7 @@ func (r artifactV4Routes) buildSignature(endp, expires, artifactName string, tas
 
 func (r artifactV4Routes) buildArtifactURL(endp, artifactName string, taskID int64) string {
 	expires := time.Now().Add(60 * time.Minute).Format("2006-01-02 15:04:05.999999999 -0700 MST")
-	uploadURL := "http://" + strings.TrimSuffix(r.AppURL, "/") + strings.TrimSuffix(r.prefix, "/") +
+	uploadURL := strings.TrimSuffix(r.AppURL, "/") + strings.TrimSuffix(r.prefix, "/") +
 		"/" + endp + "?sig=" + base64.URLEncoding.EncodeToString(r.buildSignature(endp, expires, artifactName, taskID)) + "&expires=" + url.QueryEscape(expires) + "&artifactName=" + url.QueryEscape(artifactName) + "&taskID=" + fmt.Sprint(taskID)
 	return uploadURL
 }
@@ -276,8 +304,33 @@ func (r *artifactV4Routes) createArtifact(ctx *ArtifactContext) {
 		return
 	}
 
-	artifactName := req.Name
+	respData := CreateArtifactResponse{
+		Ok:              true,
+		SignedUploadUrl: r.startUpload(runID, req.Name, req.ExpiresAt),
+	}
+	r.sendProtbufBody(ctx, &respData)
+}
 
+func (r *artifactV4Routes) migrateArtifact(ctx *ArtifactContext) {
+	var req MigrateArtifactRequest
+
+	if ok := r.parseProtbufBody(ctx, &req); !ok {
+		return
+	}
+	_, runID, ok := validateRunIDV4(ctx, req.WorkflowRunBackendId)
+	if !ok {
+		return
+	}
+
+	respData := MigrateArtifactResponse{
+		Ok:              true,
+		SignedUploadUrl: r.startUpload(runID, req.Name, req.ExpiresAt),
+	}
+	r.sendProtbufBody(ctx, &respData)
+}
+
+// startUpload creates an empty zip file for the artifact and returns the signed url to upload its content
+func (r *artifactV4Routes) startUpload(runID int64, artifactName string, expiresAt *timestamppb.Timestamp) string {
 	safeRunPath := safeResolve(r.baseDir, fmt.Sprint(runID))
 	safePath := safeResolve(safeRunPath, artifactName)
 	safePath = safeResolve(safePath, artifactName+".zip")
@@ -288,11 +341,20 @@ func (r *artifactV4Routes) createArtifact(ctx *ArtifactContext) {
 	}
 	file.Close()
 
-	respData := CreateArtifactResponse{
-		Ok:              true,
-		SignedUploadUrl: r.buildArtifactURL("UploadArtifact", artifactName, runID),
+	err = recordArtifact(r.fs, r.baseDir, fmt.Sprint(runID), artifactName, func(metadata *artifactMetadata) {
+		metadata.CreatedAt = time.Now()
+		metadata.ExpiresAt = time.Time{}
+		metadata.Finalized = false
+		metadata.Digest = ""
+		if expiresAt != nil {
+			metadata.ExpiresAt = expiresAt.AsTime()
+		}
+	})
+	if err != nil {
+		panic(err)
 	}
-	r.sendProtbufBody(ctx, &respData)
+
+	return r.buildArtifactURL("UploadArtifact", artifactName, runID)
 }
 
 func (r *artifactV4Routes) uploadArtifact(ctx *ArtifactContext) {
@@ -342,14 +404,44 @@ func (r *artifactV4Routes) finalizeArtifact(ctx *ArtifactContext) {
 	if ok := r.parseProtbufBody(ctx, &req); !ok {
 		return
 	}
-	_, _, ok := validateRunIDV4(ctx, req.WorkflowRunBackendId)
+	_, runID, ok := validateRunIDV4(ctx, req.WorkflowRunBackendId)
 	if !ok {
 		return
 	}
 
+	safeRunPath := safeResolve(r.baseDir, fmt.Sprint(runID))
+	safePath := safeResolve(safeRunPath, req.Name)
+	safePath = safeResolve(safePath, req.Name+".zip")
+
+	if err := filestore.Commit(r.fs, safePath); err != nil {
+		log.Errorf("Error commit artifact: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error commit artifact")
+		return
+	}
+	digest, size, err := artifactDigest(r.rfs, safePath)
+	if err != nil {
+		log.Errorf("Error artifact not found: %v", err)
+		ctx.Error(http.StatusNotFound, "Error artifact not found")
+		return
+	}
+	// actions/upload-artifact sends the digest of the uploaded zip file, e.g. sha256:<hex>
+	if req.Hash != nil && req.Hash.Value != "" && !strings.EqualFold(req.Hash.Value, digest) {
+		log.Errorf("Error artifact digest mismatch: expected %s, got %s", req.Hash.Value, digest)
+		ctx.Error(http.StatusBadRequest, "Error artifact digest mismatch")
+		return
+	}
+
+	if err := finalizeArtifactMetadata(r.fs, r.baseDir, fmt.Sprint(runID), req.Name, size, digest); err != nil {
+		panic(err)
+	}
+	metadata, err := readMetadata(r.rfs, r.baseDir, fmt.Sprint(runID), req.Name)
+	if err != nil {
+		panic(err)
+	}
+
 	respData := FinalizeArtifactResponse{
 		Ok:         true,
-		ArtifactId: artifactNameToID(req.Name),
+		ArtifactId: artifactID(metadata, fmt.Sprint(runID), req.Name),
 	}
 	r.sendProtbufBody(ctx, &respData)
 }
@@ -365,32 +457,29 @@ func (r *artifactV4Routes) listArtifacts(ctx *ArtifactContext) {
 		return
 	}
 
-	safePath := safeResolve(r.baseDir, fmt.Sprint(runID))
-
-	entries, err := fs.ReadDir(r.rfs, safePath)
+	artifacts, err := listArtifactsV4(r.rfs, r.baseDir, runID)
 	if err != nil {
 		panic(err)
 	}
 
 	list := []*ListArtifactsResponse_MonolithArtifact{}
 
-	for _, entry := range entries {
-		id := artifactNameToID(entry.Name())
-		if (req.NameFilter == nil || req.NameFilter.Value == entry.Name()) && (req.IdFilter == nil || req.IdFilter.Value == id) {
-			data := &ListArtifactsResponse_MonolithArtifact{
-				Name:                    entry.Name(),
-				CreatedAt:               timestamppb.Now(),
-				DatabaseId:              id,
-				WorkflowRunBackendId:    req.WorkflowRunBackendId,
-				WorkflowJobRunBackendId: req.WorkflowJobRunBackendId,
-				Size:                    0,
-			}
-			if info, err := entry.Info(); err == nil {
-				data.Size = info.Size()
-				data.CreatedAt = timestamppb.New(info.ModTime())
-			}
-			list = append(list, data)
+	for _, artifact := range artifacts {
+		if (req.NameFilter != nil && req.NameFilter.Value != artifact.Name) || (req.IdFilter != nil && req.IdFilter.Value != artifact.ID) {
+			continue
+		}
+		data := &ListArtifactsResponse_MonolithArtifact{
+			Name:                    artifact.Name,
+			CreatedAt:               timestamppb.New(artifact.CreatedAt),
+			DatabaseId:              artifact.ID,
+			WorkflowRunBackendId:    req.WorkflowRunBackendId,
+			WorkflowJobRunBackendId: req.WorkflowJobRunBackendId,
+			Size:                    artifact.Size,
 		}
+		if artifact.Digest != "" {
+			data.Digest = wrapperspb.String(artifact.Digest)
+		}
+		list = append(list, data)
 	}
 
 	respData := ListArtifactsResponse{
@@ -399,6 +488,86 @@ func (r *artifactV4Routes) listArtifacts(ctx *ArtifactContext) {
 	r.sendProtbufBody(ctx, &respData)
 }
 
+// artifactV4 is a finalized artifact of the v4 api
+type artifactV4 struct {
+	ID        int64
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Digest    string
+}
+
+// listArtifactsV4 returns the finalized zip files of the run, like the service it doesn't list artifacts of the v3 api
+func listArtifactsV4(rfs fs.FS, baseDir string, runID int64) ([]artifactV4, error) {
+	safePath := safeResolve(baseDir, fmt.Sprint(runID))
+
+	entries, err := fs.ReadDir(rfs, safePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list := []artifactV4{}
+	for _, entry := range entries {
+		name := entry.Name()
+		info, err := fs.Stat(rfs, safeResolve(safeResolve(safePath, name), name+".zip"))
+		if err != nil {
+			continue
+		}
+		metadata, err := readMetadata(rfs, baseDir, fmt.Sprint(runID), name)
+		if err != nil {
+			return nil, err
+		}
+		if metadata != nil && !metadata.Finalized {
+			continue
+		}
+		artifact := artifactV4{
+			ID:        artifactID(metadata, fmt.Sprint(runID), name),
+			Name:      name,
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		}
+		if metadata != nil {
+			artifact.CreatedAt = metadata.CreatedAt
+			artifact.ExpiresAt = metadata.ExpiresAt
+			artifact.Digest = metadata.Digest
+		}
+		list = append(list, artifact)
+	}
+	return list, nil
+}
+
+// findArtifactV4 returns the run and the finalized artifact with the id, ok is false if there is none
+func findArtifactV4(rfs fs.FS, baseDir string, id int64) (runID int64, artifact artifactV4, ok bool, err error) {
+	entries, err := fs.ReadDir(rfs, safeResolve(baseDir, "."))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, artifactV4{}, false, nil
+		}
+		return 0, artifactV4{}, false, err
+	}
+	for _, entry := range entries {
+		// the metadata directory and the run directories of other names aren't runs of the v4 api
+		runID, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		artifacts, err := listArtifactsV4(rfs, baseDir, runID)
+		if err != nil {
+			return 0, artifactV4{}, false, err
+		}
+		for _, artifact := range artifacts {
+			if artifact.ID == id {
+				return runID, artifact, true, nil
+			}
+		}
+	}
+	return 0, artifactV4{}, false, nil
+}
+
 func (r *artifactV4Routes) getSignedArtifactURL(ctx *ArtifactContext) {
 	var req GetSignedArtifactURLRequest
 
@@ -446,11 +615,40 @@ func (r *artifactV4Routes) deleteArtifact(ctx *ArtifactContext) {
 	safeRunPath := safeResolve(r.baseDir, fmt.Sprint(runID))
 	safePath := safeResolve(safeRunPath, req.Name)
 
-	_ = os.RemoveAll(safePath)
+	rfs, ok := r.fs.(filestore.RemoveFS)
+	if !ok {
+		log.Error("Error artifact storage can't remove artifacts")
+		ctx.Error(http.StatusNotImplemented, "artifacts can't be removed")
+		return
+	}
+	metadata, err := readMetadata(r.rfs, r.baseDir, fmt.Sprint(runID), req.Name)
+	if err != nil {
+		panic(err)
+	}
+	if err := rfs.RemoveAll(safePath); err != nil {
+		log.Errorf("Error delete artifact: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error delete artifact")
+		return
+	}
 
 	respData := DeleteArtifactResponse{
 		Ok:         true,
-		ArtifactId: artifactNameToID(req.Name),
+		ArtifactId: artifactID(metadata, fmt.Sprint(runID), req.Name),
 	}
 	r.sendProtbufBody(ctx, &respData)
 }
+
+// artifactDigest returns the sha256 digest in the format of actions/upload-artifact and the size of the file
+func artifactDigest(fsys fs.FS, name string) (string, int64, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -0,0 +1,131 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nektos/act/pkg/filestore"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func callArtifactV4(t *testing.T, router http.Handler, method string, body string, resp protoreflect.ProtoMessage) int {
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost"+path.Join(ArtifactV4RouteBase, method), strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code == http.StatusOK && resp != nil {
+		assert.NoError(t, protojson.Unmarshal(rr.Body.Bytes(), resp), method)
+	}
+	return rr.Code
+}
+
+func uploadArtifactV4(t *testing.T, router http.Handler, signedURL string, content string) {
+	req, _ := http.NewRequest(http.MethodPut, signedURL+"&comp=block", strings.NewReader(content))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func TestArtifactV4Digest(t *testing.T) {
+	storage := filestore.NewMemory()
+	router := httprouter.New()
+	RoutesV4(router, ".", storage, storage)
+
+	content := "zip content"
+	sum := sha256.Sum256([]byte(content))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	for _, name := range []string{"first", "second"} {
+		created := &CreateArtifactResponse{}
+		assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "CreateArtifact", `{"workflow_run_backend_id":"1","workflow_job_run_backend_id":"1","name":"`+name+`","version":4}`, created))
+		uploadArtifactV4(t, router, created.SignedUploadUrl, content)
+	}
+
+	// not finalized artifacts are not listed
+	list := &ListArtifactsResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "ListArtifacts", `{"workflow_run_backend_id":"1"}`, list))
+	assert.Empty(t, list.Artifacts)
+
+	assert.Equal(t, http.StatusBadRequest, callArtifactV4(t, router, "FinalizeArtifact", `{"workflow_run_backend_id":"1","name":"first","size":"11","hash":"sha256:0000"}`, nil))
+	assert.Equal(t, http.StatusNotFound, callArtifactV4(t, router, "FinalizeArtifact", `{"workflow_run_backend_id":"1","name":"missing","size":"11"}`, nil))
+
+	// the ids are assigned in the order the artifacts were created
+	for i, name := range []string{"first", "second"} {
+		finalized := &FinalizeArtifactResponse{}
+		assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "FinalizeArtifact", `{"workflow_run_backend_id":"1","name":"`+name+`","size":"11","hash":"`+digest+`"}`, finalized))
+		assert.Equal(t, int64(i+1), finalized.ArtifactId)
+	}
+
+	list = &ListArtifactsResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "ListArtifacts", `{"workflow_run_backend_id":"1"}`, list))
+	if assert.Len(t, list.Artifacts, 2) {
+		assert.Equal(t, "first", list.Artifacts[0].Name)
+		assert.Equal(t, int64(len(content)), list.Artifacts[0].Size)
+		assert.Equal(t, digest, list.Artifacts[0].GetDigest().GetValue())
+	}
+
+	list = &ListArtifactsResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "ListArtifacts", `{"workflow_run_backend_id":"1","name_filter":"second"}`, list))
+	if assert.Len(t, list.Artifacts, 1) {
+		assert.Equal(t, "second", list.Artifacts[0].Name)
+	}
+
+	list = &ListArtifactsResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "ListArtifacts", `{"workflow_run_backend_id":"1","id_filter":"`+"1"+`"}`, list))
+	if assert.Len(t, list.Artifacts, 1) {
+		assert.Equal(t, "first", list.Artifacts[0].Name)
+	}
+
+	list = &ListArtifactsResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "ListArtifacts", `{"workflow_run_backend_id":"1","name_filter":"first","id_filter":"`+"2"+`"}`, list))
+	assert.Empty(t, list.Artifacts)
+
+	// the ids are unique across runs
+	created := &CreateArtifactResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "CreateArtifact", `{"workflow_run_backend_id":"2","workflow_job_run_backend_id":"1","name":"first","version":4}`, created))
+	uploadArtifactV4(t, router, created.SignedUploadUrl, content)
+	finalized := &FinalizeArtifactResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "FinalizeArtifact", `{"workflow_run_backend_id":"2","name":"first"}`, finalized))
+	assert.Equal(t, int64(3), finalized.ArtifactId)
+}
+
+func TestArtifactID(t *testing.T) {
+	assert.Equal(t, int64(7), artifactID(&artifactMetadata{ID: 7}, "1", "first"))
+
+	// artifacts without a recorded id get an id above the counter which is a safe javascript number
+	id := artifactID(nil, "1", "first")
+	assert.GreaterOrEqual(t, id, int64(1<<52))
+	assert.Less(t, id, int64(1<<53))
+	assert.Equal(t, id, artifactID(&artifactMetadata{}, "1", "first"))
+	assert.NotEqual(t, id, artifactID(nil, "2", "first"))
+}
+
+func TestArtifactV4Migrate(t *testing.T) {
+	storage := filestore.NewMemory()
+	router := httprouter.New()
+	RoutesV4(router, ".", storage, storage)
+
+	migrated := &MigrateArtifactResponse{}
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "MigrateArtifact", `{"workflow_run_backend_id":"2","name":"legacy","expires_at":"2099-01-01T00:00:00Z"}`, migrated))
+	assert.True(t, migrated.Ok)
+	uploadArtifactV4(t, router, migrated.SignedUploadUrl, "legacy content")
+	assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "FinalizeArtifact", `{"workflow_run_backend_id":"2","name":"legacy","size":"14"}`, nil))
+
+	metadata, err := readMetadata(storage, ".", "2", "legacy")
+	assert.NoError(t, err)
+	if assert.NotNil(t, metadata) {
+		assert.True(t, metadata.Finalized)
+		assert.Equal(t, 2099, metadata.ExpiresAt.Year())
+	}
+	content, err := storage.Open("2/legacy/legacy.zip")
+	if assert.NoError(t, err) {
+		content.Close()
+	}
+}
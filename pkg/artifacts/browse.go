@@ -0,0 +1,237 @@
+package artifacts
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nektos/act/pkg/filestore"
+)
+
+// Artifact is an artifact stored by the artifact server
+type Artifact struct {
+	RunID   string
+	Name    string
+	Version int       // 3 for the layout of the v3 upload api, 4 for the zip file of the v4 api
+	Size    int64     // the stored size, gzip compressed files and zip files are not extracted
+	ModTime time.Time // the newest modification time of the files of the artifact
+
+	CreatedAt time.Time // the creation time recorded by the artifact server, or ModTime for artifacts without metadata
+	ExpiresAt time.Time // zero if the artifact is kept forever
+	finalized bool
+}
+
+func (a Artifact) path() string {
+	return path.Join(a.RunID, a.Name)
+}
+
+// ListArtifacts returns the artifacts of the run, or of all runs if runID is empty.
+// The artifacts are sorted by run and name, numeric run ids are sorted by their value.
+// Artifacts uploaded without retention-days expire after retentionDays, they are kept forever if it is 0.
+func ListArtifacts(fsys fs.FS, runID string, retentionDays int) ([]Artifact, error) {
+	runIDs := []string{runID}
+	if runID == "" {
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		runIDs = []string{}
+		for _, entry := range entries {
+			// the metadata directory starts with a dot
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				runIDs = append(runIDs, entry.Name())
+			}
+		}
+	}
+	sort.Slice(runIDs, func(i, j int) bool {
+		return lessRunID(runIDs[i], runIDs[j])
+	})
+
+	artifacts := []Artifact{}
+	for _, runID := range runIDs {
+		entries, err := fs.ReadDir(fsys, safeResolve(".", runID))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			artifact, err := statArtifact(fsys, runID, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			metadata, err := readMetadata(fsys, ".", runID, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			artifact.applyMetadata(metadata, retentionDays)
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	return artifacts, nil
+}
+
+func lessRunID(a, b string) bool {
+	na, erra := strconv.ParseInt(a, 10, 64)
+	nb, errb := strconv.ParseInt(b, 10, 64)
+	if erra == nil && errb == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+func statArtifact(fsys fs.FS, runID, name string) (Artifact, error) {
+	artifact := Artifact{RunID: runID, Name: name, Version: 3}
+	dir := artifact.path()
+	files := 0
+	err := fs.WalkDir(fsys, dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		artifact.Size += info.Size()
+		if info.ModTime().After(artifact.ModTime) {
+			artifact.ModTime = info.ModTime()
+		}
+		if p == path.Join(dir, name+".zip") {
+			artifact.Version = 4
+		}
+		return nil
+	})
+	// the v4 api stores a single zip file
+	if files != 1 {
+		artifact.Version = 3
+	}
+	return artifact, err
+}
+
+// DownloadArtifact writes the files of the artifact into the directory dest.
+// The gzip compressed files of the v3 api are decompressed and the zip file of the v4 api is extracted.
+func DownloadArtifact(fsys fs.FS, artifact Artifact, dest string) error {
+	if artifact.Version == 4 {
+		return extractArtifactZip(fsys, path.Join(artifact.path(), artifact.Name+".zip"), dest)
+	}
+	dir := artifact.path()
+	return fs.WalkDir(fsys, dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel := strings.TrimPrefix(p, dir+"/")
+		src, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		var reader io.Reader = src
+		if strings.HasSuffix(rel, gzipExtension) {
+			rel = strings.TrimSuffix(rel, gzipExtension)
+			gz, err := gzip.NewReader(src)
+			if err != nil {
+				return fmt.Errorf("failed to decompress '%s': %w", p, err)
+			}
+			defer gz.Close()
+			reader = gz
+		}
+		return writeArtifactFile(safeResolve(dest, rel), reader)
+	})
+}
+
+func extractArtifactZip(fsys fs.FS, name, dest string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// zip files are read at random positions, spool files of storages which can't read at an offset
+	reader, ok := f.(io.ReaderAt)
+	size := int64(0)
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	if !ok {
+		spool, err := os.CreateTemp("", "act-artifact-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+		if size, err = io.Copy(spool, f); err != nil {
+			return err
+		}
+		reader = spool
+	}
+
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", name, err)
+	}
+	for _, file := range archive.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeArtifactFile(safeResolve(dest, file.Name), src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeArtifactFile(name string, content io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RemoveArtifact removes all files and the metadata of the artifact
+func RemoveArtifact(storage filestore.RemoveFS, artifact Artifact) error {
+	if err := storage.RemoveAll(artifact.path()); err != nil {
+		return err
+	}
+	if err := removeMetadata(storage, artifact); err != nil {
+		return err
+	}
+	// remove the directory of the run after its last artifact
+	if fsys, ok := storage.(fs.FS); ok {
+		if entries, err := fs.ReadDir(fsys, artifact.RunID); err == nil && len(entries) == 0 {
+			return storage.RemoveAll(artifact.RunID)
+		}
+	}
+	return nil
+}
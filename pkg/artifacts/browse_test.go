@@ -0,0 +1,81 @@
+package artifacts
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nektos/act/pkg/filestore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrowseArtifacts(t *testing.T) {
+	storage := filestore.NewMemory()
+
+	// v3 stores the files of the artifact, gzip compressed files get a suffix
+	gz := &bytes.Buffer{}
+	gzw := gzip.NewWriter(gz)
+	_, _ = gzw.Write([]byte("compressed"))
+	assert.NoError(t, gzw.Close())
+	writeStorageFile(t, storage, "10/logs/out.txt", "plain")
+	writeStorageFile(t, storage, "10/logs/nested/big.txt"+gzipExtension, gz.String())
+
+	// v4 stores a single zip file
+	zipped := &bytes.Buffer{}
+	zw := zip.NewWriter(zipped)
+	w, _ := zw.Create("dist/app.js")
+	_, _ = w.Write([]byte("app"))
+	assert.NoError(t, zw.Close())
+	writeStorageFile(t, storage, "9/dist/dist.zip", zipped.String())
+	writeStorageFile(t, storage, "10/dist/dist.zip", zipped.String())
+
+	list, err := ListArtifacts(storage, "", 0)
+	assert.NoError(t, err)
+	type entry struct {
+		RunID, Name string
+		Version     int
+		Size        int64
+	}
+	entries := []entry{}
+	for _, artifact := range list {
+		entries = append(entries, entry{artifact.RunID, artifact.Name, artifact.Version, artifact.Size})
+	}
+	assert.Equal(t, []entry{
+		{"9", "dist", 4, int64(zipped.Len())},
+		{"10", "dist", 4, int64(zipped.Len())},
+		{"10", "logs", 3, int64(5 + gz.Len())},
+	}, entries)
+
+	list, err = ListArtifacts(storage, "9", 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	dest := t.TempDir()
+	assert.NoError(t, DownloadArtifact(storage, Artifact{RunID: "10", Name: "logs", Version: 3}, filepath.Join(dest, "logs")))
+	assert.NoError(t, DownloadArtifact(storage, Artifact{RunID: "10", Name: "dist", Version: 4}, filepath.Join(dest, "dist")))
+	for file, content := range map[string]string{
+		"logs/out.txt":        "plain",
+		"logs/nested/big.txt": "compressed",
+		"dist/dist/app.js":    "app",
+	} {
+		data, err := os.ReadFile(filepath.Join(dest, file))
+		assert.NoError(t, err, file)
+		assert.Equal(t, content, string(data), file)
+	}
+
+	assert.NoError(t, RemoveArtifact(storage, Artifact{RunID: "10", Name: "dist"}))
+	list, err = ListArtifacts(storage, "10", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "logs", list[0].Name)
+	}
+}
+
+func TestListArtifactsWithoutStorage(t *testing.T) {
+	list, err := ListArtifacts(filestore.NewLocal(filepath.Join(t.TempDir(), "missing")), "", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,150 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+)
+
+// RestArtifact is an artifact of the GitHub REST API
+type RestArtifact struct {
+	ID                 int64              `json:"id"`
+	Name               string             `json:"name"`
+	SizeInBytes        int64              `json:"size_in_bytes"`
+	URL                string             `json:"url"`
+	ArchiveDownloadURL string             `json:"archive_download_url"`
+	Expired            bool               `json:"expired"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+	ExpiresAt          *time.Time         `json:"expires_at"`
+	Digest             string             `json:"digest,omitempty"`
+	WorkflowRun        RestArtifactRunRef `json:"workflow_run"`
+}
+
+type RestArtifactRunRef struct {
+	ID int64 `json:"id"`
+}
+
+type RestArtifactList struct {
+	TotalCount int            `json:"total_count"`
+	Artifacts  []RestArtifact `json:"artifacts"`
+}
+
+// restAPI serves the endpoints of the GitHub REST API used by actions/download-artifact to download the artifacts of
+// other runs. Requests for runs which are not stored and all other requests are forwarded to the upstream api, it
+// isn't forwarded if upstream is nil. The archives are downloaded from the urls of the v4 api signed with signingKey.
+func restAPI(router *httprouter.Router, baseDir string, rfs fs.FS, upstream *url.URL, signingKey []byte) {
+	var proxy http.Handler = http.NotFoundHandler()
+	if upstream != nil {
+		proxy = &httputil.ReverseProxy{
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(upstream)
+				r.SetXForwarded()
+			},
+		}
+		router.NotFound = proxy
+		router.HandleMethodNotAllowed = false
+	}
+
+	router.GET("/repos/:owner/:repo/actions/runs/:runId/artifacts", func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		runID, err := strconv.ParseInt(params.ByName("runId"), 10, 64)
+		if err != nil {
+			proxy.ServeHTTP(w, req)
+			return
+		}
+		if _, err := fs.Stat(rfs, safeResolve(baseDir, fmt.Sprint(runID))); err != nil {
+			proxy.ServeHTTP(w, req)
+			return
+		}
+		artifacts, err := listArtifactsV4(rfs, baseDir, runID)
+		if err != nil {
+			panic(err)
+		}
+
+		query := req.URL.Query()
+		list := []RestArtifact{}
+		for _, artifact := range artifacts {
+			if name := query.Get("name"); name == "" || name == artifact.Name {
+				list = append(list, restArtifact(req, params, runID, artifact))
+			}
+		}
+		total := len(list)
+
+		perPage, err := strconv.Atoi(query.Get("per_page"))
+		if err != nil || perPage <= 0 {
+			perPage = 30
+		}
+		perPage = min(perPage, 100)
+		page, err := strconv.Atoi(query.Get("page"))
+		if err != nil || page <= 0 {
+			page = 1
+		}
+		start := min((page-1)*perPage, total)
+		list = list[start:min(start+perPage, total)]
+
+		json, err := json.Marshal(RestArtifactList{
+			TotalCount: total,
+			Artifacts:  list,
+		})
+		if err != nil {
+			panic(err)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, err = w.Write(json)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	// the archive is downloaded from the signed url of the v4 api
+	router.GET("/repos/:owner/:repo/actions/artifacts/:artifactId/:archiveFormat", func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		id, err := strconv.ParseInt(params.ByName("artifactId"), 10, 64)
+		if err != nil || params.ByName("archiveFormat") != "zip" {
+			proxy.ServeHTTP(w, req)
+			return
+		}
+		runID, artifact, ok, err := findArtifactV4(rfs, baseDir, id)
+		if err != nil {
+			panic(err)
+		}
+		if ok {
+			if !artifact.ExpiresAt.IsZero() && artifact.ExpiresAt.Before(time.Now()) {
+				log.Errorf("Error artifact %d is expired", id)
+				w.WriteHeader(http.StatusGone)
+				return
+			}
+			routes := artifactV4Routes{prefix: ArtifactV4RouteBase, AppURL: baseURL(req), signingKey: signingKey}
+			http.Redirect(w, req, routes.buildArtifactURL("DownloadArtifact", artifact.Name, runID), http.StatusFound)
+			return
+		}
+		proxy.ServeHTTP(w, req)
+	})
+}
+
+func restArtifact(req *http.Request, params httprouter.Params, runID int64, artifact artifactV4) RestArtifact {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/actions/artifacts/%d", baseURL(req), params.ByName("owner"), params.ByName("repo"), artifact.ID)
+	rest := RestArtifact{
+		ID:                 artifact.ID,
+		Name:               artifact.Name,
+		SizeInBytes:        artifact.Size,
+		URL:                apiURL,
+		ArchiveDownloadURL: apiURL + "/zip",
+		CreatedAt:          artifact.CreatedAt,
+		UpdatedAt:          artifact.CreatedAt,
+		Digest:             artifact.Digest,
+		WorkflowRun:        RestArtifactRunRef{ID: runID},
+	}
+	if !artifact.ExpiresAt.IsZero() {
+		rest.ExpiresAt = &artifact.ExpiresAt
+		rest.Expired = artifact.ExpiresAt.Before(time.Now())
+	}
+	return rest
+}
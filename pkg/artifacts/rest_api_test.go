@@ -0,0 +1,68 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nektos/act/pkg/filestore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestAPI(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, "upstream "+req.URL.Path)
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL + "/api/v3")
+
+	storage := filestore.NewMemory()
+	router := httprouter.New()
+	RoutesV4(router, ".", storage, storage)
+	restAPI(router, ".", storage, upstreamURL, nil)
+
+	for _, name := range []string{"first", "second", "third"} {
+		created := &CreateArtifactResponse{}
+		assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "CreateArtifact", `{"workflow_run_backend_id":"5","name":"`+name+`","version":4}`, created))
+		uploadArtifactV4(t, router, created.SignedUploadUrl, name+" content")
+		if name != "third" {
+			assert.Equal(t, http.StatusOK, callArtifactV4(t, router, "FinalizeArtifact", `{"workflow_run_backend_id":"5","name":"`+name+`"}`, nil))
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/repos/owner/repo/actions/runs/5/artifacts?per_page=1&page=2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	list := RestArtifactList{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	assert.Equal(t, 2, list.TotalCount)
+	if assert.Len(t, list.Artifacts, 1) {
+		artifact := list.Artifacts[0]
+		assert.Equal(t, "second", artifact.Name)
+		assert.Equal(t, int64(2), artifact.ID)
+		assert.Equal(t, int64(5), artifact.WorkflowRun.ID)
+		assert.Equal(t, "http://localhost/repos/owner/repo/actions/artifacts/"+strconv.FormatInt(artifact.ID, 10)+"/zip", artifact.ArchiveDownloadURL)
+
+		rr = get("/repos/owner/repo/actions/artifacts/" + strconv.FormatInt(artifact.ID, 10) + "/zip")
+		assert.Equal(t, http.StatusFound, rr.Code)
+		rr = get(rr.Header().Get("Location")[len("http://localhost"):])
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "second content", rr.Body.String())
+	}
+
+	// unknown runs and artifacts and other requests are forwarded
+	assert.Equal(t, "upstream /api/v3/repos/owner/repo/actions/runs/6/artifacts", get("/repos/owner/repo/actions/runs/6/artifacts").Body.String())
+	assert.Equal(t, "upstream /api/v3/repos/owner/repo/actions/artifacts/3/zip", get("/repos/owner/repo/actions/artifacts/3/zip").Body.String())
+	assert.Equal(t, "upstream /api/v3/repos/owner/repo", get("/repos/owner/repo").Body.String())
+}
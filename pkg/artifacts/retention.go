@@ -0,0 +1,266 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nektos/act/pkg/filestore"
+	"github.com/sirupsen/logrus"
+)
+
+// Limits configures the cleanup of the artifact server
+type Limits struct {
+	// RetentionDays is the retention of artifacts uploaded without retention-days, they are kept forever if it is 0
+	RetentionDays int
+	// MaxSize is the quota in bytes for the total size of all artifacts, the oldest artifacts are evicted if it
+	// is exceeded. There is no quota if it is 0.
+	MaxSize int64
+}
+
+// metadataDir contains the metadata of the artifacts next to the run directories
+const metadataDir = ".metadata"
+
+// artifactMetadata is recorded when an artifact is created and finalized
+type artifactMetadata struct {
+	ID        int64     `json:"id,omitempty"` // assigned from the counter in lastIDFile when the artifact is created
+	RunID     string    `json:"run_id"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // zero if the retention wasn't specified on upload
+	Finalized bool      `json:"finalized"`
+	Digest    string    `json:"digest,omitempty"` // the sha256 digest of the zip file of a v4 artifact, e.g. sha256:...
+}
+
+// lastIDFile contains the id of the last created artifact
+const lastIDFile = "last_id"
+
+// artifactIDs serializes the assignment of artifact ids
+var artifactIDs sync.Mutex
+
+// nextArtifactID increments the counter of the artifact ids in the storage, it returns 0 if the storage isn't readable
+func nextArtifactID(fsys WriteFS, baseDir string) (int64, error) {
+	rfs, ok := fsys.(fs.FS)
+	if !ok {
+		return 0, nil
+	}
+	artifactIDs.Lock()
+	defer artifactIDs.Unlock()
+
+	name := safeResolve(safeResolve(baseDir, metadataDir), lastIDFile)
+	id := int64(0)
+	content, err := fs.ReadFile(rfs, name)
+	if err == nil {
+		if id, err = strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64); err != nil {
+			return 0, fmt.Errorf("invalid artifact id counter %s: %w", name, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+	id++
+
+	file, err := fsys.OpenWritable(name)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.(io.Writer).Write([]byte(strconv.FormatInt(id, 10))); err != nil {
+		file.Close()
+		return 0, err
+	}
+	return id, file.Close()
+}
+
+// artifactID returns the id recorded in the metadata of an artifact. Artifacts without a recorded id, e.g. they were
+// copied into the storage, get an id derived from their run and name above 2^52, which the counter never reaches,
+// and below 2^53 to be a safe javascript number.
+func artifactID(metadata *artifactMetadata, runID, name string) int64 {
+	if metadata != nil && metadata.ID > 0 {
+		return metadata.ID
+	}
+	h := fnv.New64a()
+	h.Write([]byte(runID + "/" + name))
+	return 1<<52 | int64(h.Sum64()&(1<<52-1))
+}
+
+func metadataPath(baseDir, runID, name string) string {
+	return safeResolve(safeResolve(safeResolve(baseDir, metadataDir), runID), name+".json")
+}
+
+// readMetadata returns nil if the artifact has no metadata
+func readMetadata(fsys fs.FS, baseDir, runID, name string) (*artifactMetadata, error) {
+	content, err := fs.ReadFile(fsys, metadataPath(baseDir, runID, name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	metadata := &artifactMetadata{}
+	if err := json.Unmarshal(content, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// recordArtifact updates the metadata of an artifact, it is created if it doesn't exist
+func recordArtifact(fsys WriteFS, baseDir, runID, name string, update func(*artifactMetadata)) error {
+	var metadata *artifactMetadata
+	if rfs, ok := fsys.(fs.FS); ok {
+		var err error
+		if metadata, err = readMetadata(rfs, baseDir, runID, name); err != nil {
+			return err
+		}
+	}
+	if metadata == nil {
+		id, err := nextArtifactID(fsys, baseDir)
+		if err != nil {
+			return err
+		}
+		metadata = &artifactMetadata{ID: id, RunID: runID, Name: name, CreatedAt: time.Now()}
+	}
+	update(metadata)
+
+	content, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	file, err := fsys.OpenWritable(metadataPath(baseDir, runID, name))
+	if err != nil {
+		return err
+	}
+	if _, err := file.(io.Writer).Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// finalizeArtifactMetadata records the size and the digest of the uploaded artifact
+func finalizeArtifactMetadata(fsys WriteFS, baseDir, runID, name string, size int64, digest string) error {
+	if rfs, ok := fsys.(fs.FS); ok {
+		if artifact, err := statArtifact(rfs, safeResolve(baseDir, runID), name); err == nil {
+			size = artifact.Size
+		}
+	}
+	return recordArtifact(fsys, baseDir, runID, name, func(metadata *artifactMetadata) {
+		metadata.Size = size
+		metadata.Finalized = true
+		metadata.Digest = digest
+	})
+}
+
+// keepUploading protects artifacts which are not finalized yet from being evicted by the quota
+const keepUploading = time.Hour
+
+type artifactGC struct {
+	storage filestore.Storage
+	limits  Limits
+	logger  logrus.FieldLogger
+
+	gcing atomic.Bool
+	gcAt  time.Time
+}
+
+// collect removes expired artifacts and evicts the oldest artifacts exceeding the quota, at most once per hour
+func (gc *artifactGC) collect() {
+	if gc.limits.RetentionDays <= 0 && gc.limits.MaxSize <= 0 {
+		return
+	}
+	if !gc.gcing.CompareAndSwap(false, true) {
+		return
+	}
+	defer gc.gcing.Store(false)
+
+	if time.Since(gc.gcAt) < time.Hour {
+		gc.logger.Debugf("skip artifact gc: %v", gc.gcAt.String())
+		return
+	}
+	gc.gcAt = time.Now()
+	gc.logger.Debugf("artifact gc: %v", gc.gcAt.String())
+
+	removed, err := cleanupArtifacts(gc.storage, gc.limits, gc.gcAt)
+	for _, artifact := range removed {
+		gc.logger.Infof("deleted artifact '%s' of run %s", artifact.Name, artifact.RunID)
+	}
+	if err != nil {
+		gc.logger.Warnf("artifact gc: %v", err)
+	}
+}
+
+// cleanupArtifacts removes the expired artifacts, then it removes the oldest artifacts until the total size is within the quota
+func cleanupArtifacts(storage filestore.Storage, limits Limits, now time.Time) ([]Artifact, error) {
+	artifacts, err := ListArtifacts(storage, "", limits.RetentionDays)
+	if err != nil {
+		return nil, err
+	}
+	removed := []Artifact{}
+
+	// Remove the expired artifacts.
+	kept := []Artifact{}
+	total := int64(0)
+	for _, artifact := range artifacts {
+		if !artifact.ExpiresAt.IsZero() && artifact.ExpiresAt.Before(now) {
+			if err := RemoveArtifact(storage, artifact); err != nil {
+				return removed, err
+			}
+			removed = append(removed, artifact)
+			continue
+		}
+		kept = append(kept, artifact)
+		total += artifact.Size
+	}
+
+	// Evict the oldest artifacts exceeding the quota.
+	if limits.MaxSize <= 0 {
+		return removed, nil
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].CreatedAt.Before(kept[j].CreatedAt)
+	})
+	for _, artifact := range kept {
+		if total <= limits.MaxSize {
+			break
+		}
+		if !artifact.finalized && now.Sub(artifact.ModTime) < keepUploading {
+			// it's most likely still uploading
+			continue
+		}
+		if err := RemoveArtifact(storage, artifact); err != nil {
+			return removed, err
+		}
+		removed = append(removed, artifact)
+		total -= artifact.Size
+	}
+	return removed, nil
+}
+
+// applyMetadata sets the creation and expiration time of an artifact, artifacts without metadata were created at their
+// last modification. Artifacts without an expiration expire after the default retention, artifacts without metadata
+// never expire since they weren't uploaded to the artifact server, e.g. they were copied into the storage.
+func (a *Artifact) applyMetadata(metadata *artifactMetadata, retentionDays int) {
+	a.CreatedAt = a.ModTime
+	if metadata == nil {
+		return
+	}
+	a.CreatedAt = metadata.CreatedAt
+	a.ExpiresAt = metadata.ExpiresAt
+	a.finalized = metadata.Finalized
+	if a.ExpiresAt.IsZero() && retentionDays > 0 {
+		a.ExpiresAt = a.CreatedAt.AddDate(0, 0, retentionDays)
+	}
+}
+
+func removeMetadata(storage filestore.RemoveFS, artifact Artifact) error {
+	return storage.RemoveAll(path.Join(metadataDir, artifact.RunID, artifact.Name+".json"))
+}
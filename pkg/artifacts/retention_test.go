@@ -0,0 +1,104 @@
+package artifacts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nektos/act/pkg/filestore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanupArtifacts(t *testing.T) {
+	storage := filestore.NewMemory()
+	now := time.Now()
+	for _, artifact := range []struct {
+		runID, name, content string
+		createdAt, expiresAt time.Time
+		finalized            bool
+	}{
+		{"1", "expired", "1234", now.Add(-3 * time.Hour), now.Add(-time.Minute), true},
+		{"1", "oldest", "1234", now.Add(-2 * time.Hour), time.Time{}, true},
+		{"2", "uploading", "1234", now.Add(-3 * time.Hour), time.Time{}, false},
+		{"2", "newest", "1234", now.Add(-time.Hour), time.Time{}, true},
+	} {
+		writeStorageFile(t, storage, artifact.runID+"/"+artifact.name+"/file.txt", artifact.content)
+		assert.NoError(t, recordArtifact(storage, ".", artifact.runID, artifact.name, func(metadata *artifactMetadata) {
+			metadata.CreatedAt = artifact.createdAt
+			metadata.ExpiresAt = artifact.expiresAt
+			metadata.Finalized = artifact.finalized
+		}))
+	}
+
+	removed, err := cleanupArtifacts(storage, Limits{MaxSize: 8}, now)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, artifact := range removed {
+		names = append(names, artifact.Name)
+	}
+	assert.Equal(t, []string{"expired", "oldest"}, names)
+
+	list, err := ListArtifacts(storage, "", 0)
+	assert.NoError(t, err)
+	names = []string{}
+	for _, artifact := range list {
+		names = append(names, artifact.Name)
+	}
+	assert.Equal(t, []string{"newest", "uploading"}, names)
+
+	// the metadata and the directory of the run are removed with the last artifact
+	metadata, err := readMetadata(storage, ".", "1", "oldest")
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+	_, err = storage.Open("1")
+	assert.Error(t, err)
+
+	// artifacts without an expiration expire after the default retention, artifacts without metadata never expire
+	writeStorageFile(t, storage, "3/copied/file.txt", "1234")
+	removed, err = cleanupArtifacts(storage, Limits{RetentionDays: 1}, now.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Len(t, removed, 2)
+	list, err = ListArtifacts(storage, "", 1)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "copied", list[0].Name)
+		assert.True(t, list[0].ExpiresAt.IsZero())
+	}
+}
+
+func TestArtifactMetadataV3(t *testing.T) {
+	storage := filestore.NewMemory()
+	router := httprouter.New()
+	uploads(router, ".", storage)
+
+	for _, tt := range []struct {
+		method, url, body string
+	}{
+		{"POST", "http://localhost/_apis/pipelines/workflows/1/artifacts", `{"Type":"actions_storage","Name":"logs","RetentionDays":2}`},
+		{"PUT", "http://localhost/upload/1?itemPath=logs/out.txt", "content"},
+		{"PATCH", "http://localhost/_apis/pipelines/workflows/1/artifacts?artifactName=logs", `{"Size":7}`},
+	} {
+		req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, tt.url)
+	}
+
+	metadata, err := readMetadata(storage, ".", "1", "logs")
+	assert.NoError(t, err)
+	if assert.NotNil(t, metadata) {
+		assert.Equal(t, int64(7), metadata.Size)
+		assert.True(t, metadata.Finalized)
+		assert.True(t, metadata.CreatedAt.AddDate(0, 0, 2).Equal(metadata.ExpiresAt))
+	}
+
+	list, err := ListArtifacts(storage, "", 90)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "logs", list[0].Name)
+		assert.True(t, metadata.ExpiresAt.Equal(list[0].ExpiresAt))
+	}
+}
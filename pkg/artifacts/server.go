@@ -2,13 +2,16 @@ package artifacts
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,6 +19,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/filestore"
 )
 
 type FileContainerResourceURL struct {
@@ -46,44 +50,22 @@ type ResponseMessage struct {
 	Message string `json:"message"`
 }
 
-type WritableFile interface {
-	io.WriteCloser
+// CreateArtifactParameters is the optional body of the request creating an artifact container
+type CreateArtifactParameters struct {
+	Name          string `json:"Name"`
+	RetentionDays int    `json:"RetentionDays"`
 }
 
-type WriteFS interface {
-	OpenWritable(name string) (WritableFile, error)
-	OpenAppendable(name string) (WritableFile, error)
-}
-
-type readWriteFSImpl struct {
+// PatchArtifactSize is the optional body of the request finalizing an artifact
+type PatchArtifactSize struct {
+	Size int64 `json:"Size"`
 }
 
-func (fwfs readWriteFSImpl) Open(name string) (fs.File, error) {
-	return os.Open(name)
-}
-
-func (fwfs readWriteFSImpl) OpenWritable(name string) (WritableFile, error) {
-	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
-		return nil, err
-	}
-	return os.OpenFile(name, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
-}
+type WritableFile = filestore.WritableFile
 
-func (fwfs readWriteFSImpl) OpenAppendable(name string) (WritableFile, error) {
-	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
-		return nil, err
-	}
-	file, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0o644)
-
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = file.Seek(0, io.SeekEnd)
-	if err != nil {
-		return nil, err
-	}
-	return file, nil
+type WriteFS interface {
+	OpenWritable(name string) (WritableFile, error)
+	OpenAppendable(name string) (WritableFile, error)
 }
 
 var gzipExtension = ".gz__"
@@ -96,8 +78,26 @@ func uploads(router *httprouter.Router, baseDir string, fsys WriteFS) {
 	router.POST("/_apis/pipelines/workflows/:runId/artifacts", func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
 		runID := params.ByName("runId")
 
+		var parameters CreateArtifactParameters
+		if req.Body != nil {
+			_ = json.NewDecoder(req.Body).Decode(&parameters)
+		}
+		if parameters.Name != "" {
+			err := recordArtifact(fsys, baseDir, runID, parameters.Name, func(metadata *artifactMetadata) {
+				metadata.CreatedAt = time.Now()
+				metadata.ExpiresAt = time.Time{}
+				metadata.Finalized = false
+				if parameters.RetentionDays > 0 {
+					metadata.ExpiresAt = metadata.CreatedAt.AddDate(0, 0, parameters.RetentionDays)
+				}
+			})
+			if err != nil {
+				panic(err)
+			}
+		}
+
 		json, err := json.Marshal(FileContainerResourceURL{
-			FileContainerResourceURL: fmt.Sprintf("http://%s/upload/%s", req.Host, runID),
+			FileContainerResourceURL: fmt.Sprintf("%s/upload/%s", baseURL(req), runID),
 		})
 		if err != nil {
 			panic(err)
@@ -160,7 +160,22 @@ func uploads(router *httprouter.Router, baseDir string, fsys WriteFS) {
 		}
 	})
 
-	router.PATCH("/_apis/pipelines/workflows/:runId/artifacts", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	router.PATCH("/_apis/pipelines/workflows/:runId/artifacts", func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		if name := req.URL.Query().Get("artifactName"); name != "" {
+			var size PatchArtifactSize
+			if req.Body != nil {
+				_ = json.NewDecoder(req.Body).Decode(&size)
+			}
+			if err := filestore.Commit(fsys, safeResolve(safeResolve(baseDir, params.ByName("runId")), name)); err != nil {
+				panic(err)
+			}
+			if err := finalizeArtifactMetadata(fsys, baseDir, params.ByName("runId"), name, size.Size, ""); err != nil {
+				panic(err)
+			}
+		} else if err := filestore.Commit(fsys, safeResolve(baseDir, params.ByName("runId"))); err != nil {
+			panic(err)
+		}
+
 		json, err := json.Marshal(ResponseMessage{
 			Message: "success",
 		})
@@ -190,7 +205,7 @@ func downloads(router *httprouter.Router, baseDir string, fsys fs.FS) {
 		for _, entry := range entries {
 			list = append(list, NamedFileContainerResourceURL{
 				Name:                     entry.Name(),
-				FileContainerResourceURL: fmt.Sprintf("http://%s/download/%s", req.Host, runID),
+				FileContainerResourceURL: fmt.Sprintf("%s/download/%s", baseURL(req), runID),
 			})
 		}
 
@@ -231,7 +246,7 @@ func downloads(router *httprouter.Router, baseDir string, fsys fs.FS) {
 				files = append(files, ContainerItem{
 					Path:            path,
 					ItemType:        "file",
-					ContentLocation: fmt.Sprintf("http://%s/artifact/%s/%s/%s", req.Host, container, itemPath, rel),
+					ContentLocation: fmt.Sprintf("%s/artifact/%s/%s/%s", baseURL(req), container, itemPath, rel),
 				})
 			}
 			return nil
@@ -275,26 +290,37 @@ func downloads(router *httprouter.Router, baseDir string, fsys fs.FS) {
 	})
 }
 
-func Serve(ctx context.Context, artifactPath string, addr string, port string) context.CancelFunc {
+// baseURL returns the scheme and host the request was sent to, the server may be behind a TLS terminating proxy
+func baseURL(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host
+}
+
+// Serve starts the artifact server, it also serves the artifacts of the GitHub REST API and forwards all other requests
+// of the REST API to upstreamAPIURL if it isn't empty. If tokenKey isn't nil, the requests have to pass a runtime
+// token signed with it, the requests of the REST API may pass apiToken instead if it isn't empty, e.g. the
+// GITHUB_TOKEN of the run. It fails if the storage at artifactPath can't be opened.
+func Serve(ctx context.Context, artifactPath string, addr string, port string, limits Limits, upstreamAPIURL, apiToken string, tokenKey []byte) (context.CancelFunc, error) {
 	serverContext, cancel := context.WithCancel(ctx)
 	logger := common.Logger(serverContext)
 
 	if artifactPath == "" {
-		return cancel
+		return cancel, nil
 	}
 
-	router := httprouter.New()
-
-	logger.Debugf("Artifacts base path '%s'", artifactPath)
-	fsys := readWriteFSImpl{}
-	uploads(router, artifactPath, fsys)
-	downloads(router, artifactPath, fsys)
-	RoutesV4(router, artifactPath, fsys, fsys)
+	handler, err := NewHandler(serverContext, artifactPath, limits, upstreamAPIURL, apiToken, tokenKey)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start the artifact server: %w", err)
+	}
 
 	server := &http.Server{
 		Addr:              fmt.Sprintf("%s:%s", addr, port),
 		ReadHeaderTimeout: 2 * time.Second,
-		Handler:           router,
+		Handler:           handler,
 	}
 
 	// run server
@@ -315,5 +341,73 @@ func Serve(ctx context.Context, artifactPath string, addr string, port string) c
 		}
 	}()
 
-	return cancel
+	return cancel, nil
+}
+
+// NewHandler returns the handler of the artifact server without listening, e.g. for a standalone server which also
+// serves the caches. The expired artifacts are collected while it handles requests.
+func NewHandler(ctx context.Context, artifactPath string, limits Limits, upstreamAPIURL, apiToken string, tokenKey []byte) (http.Handler, error) {
+	logger := common.Logger(ctx)
+	router := httprouter.New()
+
+	logger.Debugf("Artifacts base path '%s'", artifactPath)
+	storage, err := filestore.Open(artifactPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the artifact storage: %w", err)
+	}
+	uploads(router, ".", storage)
+	downloads(router, ".", storage)
+	routesV4(router, ".", storage, storage, tokenKey)
+
+	var upstream *url.URL
+	if upstreamAPIURL != "" {
+		if upstream, err = url.Parse(upstreamAPIURL); err != nil {
+			return nil, fmt.Errorf("invalid GitHub API url '%s': %w", upstreamAPIURL, err)
+		}
+	}
+	restAPI(router, ".", storage, upstream, tokenKey)
+
+	gc := &artifactGC{storage: storage, limits: limits, logger: logger}
+	go gc.collect()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if tokenKey != nil && requiresToken(req) {
+			if err := verifyToken(req, tokenKey, apiToken); err != nil {
+				logger.Errorf("Unauthorized request %s %s: %v", req.Method, req.URL.Path, err)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		router.ServeHTTP(w, req)
+		go gc.collect()
+	}), nil
+}
+
+// requiresToken reports whether a request has to pass a token, which is every request of the toolkit and of the REST
+// API. The signed urls of the v4 api are verified by their handlers.
+func requiresToken(req *http.Request) bool {
+	switch req.URL.Path {
+	case path.Join(ArtifactV4RouteBase, "UploadArtifact"), path.Join(ArtifactV4RouteBase, "DownloadArtifact"):
+		if req.URL.Query().Get("sig") != "" {
+			return false
+		}
+	}
+	for _, prefix := range []string{"/_apis/", "/upload/", "/download/", "/artifact/", "/repos/", ArtifactV4RouteBase + "/"} {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyToken checks that a request passes a runtime token signed with tokenKey. actions/download-artifact calls the
+// REST API with the GitHub token instead, so its requests may pass apiToken if it isn't empty.
+func verifyToken(req *http.Request, tokenKey []byte, apiToken string) error {
+	if apiToken != "" && strings.HasPrefix(req.URL.Path, "/repos/") {
+		// the GitHub API accepts both "token <token>" and "Bearer <token>"
+		if _, token, ok := strings.Cut(req.Header.Get("Authorization"), " "); ok && subtle.ConstantTimeCompare([]byte(token), []byte(apiToken)) == 1 {
+			return nil
+		}
+	}
+	return common.VerifyAuthorizationToken(req, tokenKey)
 }
@@ -16,7 +16,10 @@ import (
 	"github.com/julienschmidt/httprouter"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/webdav"
 
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/filestore"
 	"github.com/nektos/act/pkg/model"
 	"github.com/nektos/act/pkg/runner"
 )
@@ -252,7 +255,10 @@ func TestArtifactFlow(t *testing.T) {
 
 	ctx := context.Background()
 
-	cancel := Serve(ctx, artifactsPath, artifactsAddr, artifactsPort)
+	cancel, err := Serve(ctx, artifactsPath, artifactsAddr, artifactsPort, Limits{}, "", "", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
 	defer cancel()
 
 	platforms := map[string]string{
@@ -393,3 +399,125 @@ func TestArtifactUploadBlobUnsafePath(t *testing.T) {
 	assert.Equal("success", response.Message)
 	assert.Equal("content", string(memfs["artifact/server/path/1/some/file"].Data))
 }
+
+func TestNewHandlerRuntimeToken(t *testing.T) {
+	assert := assert.New(t)
+
+	key := []byte("run key")
+	handler, err := NewHandler(context.Background(), "memory://", Limits{}, "", "github token", key)
+	assert.NoError(err)
+
+	token, err := common.CreateCacheAuthorizationToken(1, 1, 1, common.CacheAccess{}, key)
+	assert.NoError(err)
+	otherToken, err := common.CreateCacheAuthorizationToken(1, 1, 1, common.CacheAccess{}, []byte("other key"))
+	assert.NoError(err)
+
+	serve := func(method, target, token string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(`{"workflowRunBackendId":"1","workflowJobRunBackendId":"1"}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodGet, "/_apis/pipelines/workflows/1/artifacts", ""))
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodGet, "/_apis/pipelines/workflows/1/artifacts", otherToken))
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodPost, ArtifactV4RouteBase+"/ListArtifacts", ""))
+	assert.Equal(http.StatusOK, serve(http.MethodPost, ArtifactV4RouteBase+"/ListArtifacts", token))
+	// the signed urls are verified by their handlers
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodGet, ArtifactV4RouteBase+"/DownloadArtifact?sig=forged&expires=1&artifactName=a&taskID=1", ""))
+	assert.False(requiresToken(httptest.NewRequest(http.MethodGet, ArtifactV4RouteBase+"/DownloadArtifact?sig=abc", nil)))
+	// a signature doesn't exempt other requests
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodPost, ArtifactV4RouteBase+"/ListArtifacts?sig=abc", ""))
+
+	// actions/download-artifact calls the REST API with the GitHub token, the runs which aren't stored are not found
+	rest := "/repos/nektos/act/actions/runs/1/artifacts"
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodGet, rest, ""))
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodGet, rest, "other token"))
+	assert.Equal(http.StatusNotFound, serve(http.MethodGet, rest, "github token"))
+	assert.Equal(http.StatusNotFound, serve(http.MethodGet, rest, token))
+	// the GitHub token doesn't authorize the requests of the toolkit
+	assert.Equal(http.StatusUnauthorized, serve(http.MethodPost, ArtifactV4RouteBase+"/ListArtifacts", "github token"))
+}
+
+func writeStorageFile(t *testing.T, storage filestore.Storage, name, content string) {
+	file, err := storage.OpenWritable(name)
+	assert.NoError(t, err)
+	_, err = file.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+}
+
+func TestStoragesUploadAndDownload(t *testing.T) {
+	for name, storage := range map[string]filestore.Storage{
+		"local":  filestore.NewLocal(t.TempDir()),
+		"memory": filestore.NewMemory(),
+		"webdav": newTestWebDAVStorage(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := httprouter.New()
+			uploads(router, ".", storage)
+			downloads(router, ".", storage)
+
+			req, _ := http.NewRequest("PUT", "http://localhost/upload/1?itemPath=some/file", strings.NewReader("content"))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			req, _ = http.NewRequest("GET", "http://localhost/download/1?itemPath=some", nil)
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Body.String(), `"path":"some/file"`)
+
+			req, _ = http.NewRequest("GET", "http://localhost/artifact/1/some/file", nil)
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "content", rr.Body.String())
+		})
+	}
+}
+
+func newTestWebDAVStorage(t *testing.T) filestore.Storage {
+	server := httptest.NewServer(&webdav.Handler{FileSystem: webdav.NewMemFS(), LockSystem: webdav.NewMemLS()})
+	t.Cleanup(server.Close)
+	storage, err := filestore.Open(server.URL)
+	assert.NoError(t, err)
+	return storage
+}
+
+func TestChunkedUploadStagedStorage(t *testing.T) {
+	assert := assert.New(t)
+
+	storage := newTestWebDAVStorage(t)
+	router := httprouter.New()
+	uploads(router, ".", storage)
+	downloads(router, ".", storage)
+
+	serve := func(method, target, contentRange, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentRange != "" {
+			req.Header.Set("Content-Range", contentRange)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(http.StatusOK, serve(http.MethodPut, "/upload/1?itemPath=some/file", "bytes 0-4/15", "chunk").Code)
+	assert.Equal(http.StatusOK, serve(http.MethodPut, "/upload/1?itemPath=some/file", "bytes 5-9/15", "chunk").Code)
+	assert.Equal(http.StatusOK, serve(http.MethodPut, "/upload/1?itemPath=some/file", "bytes 10-14/15", "chunk").Code)
+	assert.Equal(http.StatusOK, serve(http.MethodPatch, "/_apis/pipelines/workflows/1/artifacts?artifactName=some", "", `{"Size":15}`).Code)
+
+	rr := serve(http.MethodGet, "/artifact/1/some/file", "", "")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("chunkchunkchunk", rr.Body.String())
+}
+
+func TestServeInvalidStorage(t *testing.T) {
+	_, err := Serve(context.Background(), "ftp://host/path", "127.0.0.1", "0", Limits{}, "", "", nil)
+	assert.EqualError(t, err, "failed to start the artifact server: failed to open the artifact storage: unsupported storage 'ftp', expected a path or a file://, memory://, s3://, http:// or https:// url")
+}
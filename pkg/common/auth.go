@@ -21,29 +21,51 @@ type actionsClaims struct {
 	RunID  int64
 	JobID  int64
 	Ac     string `json:"ac"`
+	// Repository separates the caches of the repositories sharing a cache server
+	Repository string `json:"repository,omitempty"`
 }
 
-type actionsCacheScope struct {
+// CacheScope is a ref whose caches a job may access, like the scopes of the runtime token of GitHub Actions.
+// The scopes of a token are ordered by precedence, the current ref comes first.
+type CacheScope struct {
 	Scope      string
-	Permission actionsCachePermission
+	Permission CachePermission
 }
 
-type actionsCachePermission int
+type CachePermission int
 
 const (
-	actionsCachePermissionRead = 1 << iota
-	actionsCachePermissionWrite
+	CachePermissionRead CachePermission = 1 << iota
+	CachePermissionWrite
 )
 
+// CacheAccess restricts the caches a runtime token has access to
+type CacheAccess struct {
+	Repository string
+	Scopes     []CacheScope
+}
+
+// CreateAuthorizationToken creates an unsigned runtime token, the caches are not scoped
 func CreateAuthorizationToken(taskID, runID, jobID int64) (string, error) {
+	return CreateCacheAuthorizationToken(taskID, runID, jobID, CacheAccess{}, nil)
+}
+
+// CreateCacheAuthorizationToken creates a runtime token restricting the access to the caches, without scopes the
+// caches of the repository are not scoped. The token is signed with key, the servers of a run only accept tokens
+// signed with their key, see VerifyAuthorizationToken. A nil key creates an unsigned token.
+func CreateCacheAuthorizationToken(taskID, runID, jobID int64, access CacheAccess, key []byte) (string, error) {
 	now := time.Now()
 
-	ac, err := json.Marshal(&[]actionsCacheScope{
-		{
-			Scope:      "",
-			Permission: actionsCachePermissionWrite,
-		},
-	})
+	cacheScopes := access.Scopes
+	if len(cacheScopes) == 0 {
+		cacheScopes = []CacheScope{
+			{
+				Scope:      "",
+				Permission: CachePermissionWrite,
+			},
+		}
+	}
+	ac, err := json.Marshal(&cacheScopes)
 	if err != nil {
 		return "", err
 	}
@@ -53,15 +75,16 @@ func CreateAuthorizationToken(taskID, runID, jobID int64) (string, error) {
 			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)),
 			NotBefore: jwt.NewNumericDate(now),
 		},
-		Scp:    fmt.Sprintf("Actions.Results:%d:%d", runID, jobID),
-		TaskID: taskID,
-		RunID:  runID,
-		JobID:  jobID,
-		Ac:     string(ac),
+		Scp:        fmt.Sprintf("Actions.Results:%d:%d", runID, jobID),
+		TaskID:     taskID,
+		RunID:      runID,
+		JobID:      jobID,
+		Ac:         string(ac),
+		Repository: access.Repository,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	tokenString, err := token.SignedString([]byte{})
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", err
 	}
@@ -70,31 +93,87 @@ func CreateAuthorizationToken(taskID, runID, jobID int64) (string, error) {
 }
 
 func ParseAuthorizationToken(req *http.Request) (int64, error) {
+	c, err := parseAuthorizationClaims(req, nil)
+	if err != nil || c == nil {
+		return 0, err
+	}
+	return c.TaskID, nil
+}
+
+// VerifyAuthorizationToken checks that a request has a valid runtime token signed with key
+func VerifyAuthorizationToken(req *http.Request, key []byte) error {
+	c, err := parseAuthorizationClaims(req, key)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("missing runtime token")
+	}
+	return nil
+}
+
+// ParseCacheAccess returns the access to the caches of the runtime token of a request signed with key, it returns nil
+// if there is no token. It returns nil if key is nil too, the claims of a token whose signature can't be verified
+// aren't trusted.
+func ParseCacheAccess(req *http.Request, key []byte) (*CacheAccess, error) {
+	if key == nil {
+		return nil, nil
+	}
+	c, err := parseAuthorizationClaims(req, key)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	access := &CacheAccess{Repository: c.Repository}
+	if c.Ac != "" {
+		if err := json.Unmarshal([]byte(c.Ac), &access.Scopes); err != nil {
+			return nil, fmt.Errorf("invalid ac claim: %w", err)
+		}
+	}
+	return access, nil
+}
+
+func parseAuthorizationClaims(req *http.Request, key []byte) (*actionsClaims, error) {
 	h := req.Header.Get("Authorization")
 	if h == "" {
-		return 0, nil
+		return nil, nil
 	}
 
 	parts := strings.SplitN(h, " ", 2)
 	if len(parts) != 2 {
 		log.Errorf("split token failed: %s", h)
-		return 0, fmt.Errorf("split token failed")
+		return nil, fmt.Errorf("split token failed")
+	}
+
+	if key == nil {
+		// the signature can't be verified without the key, like for a server shared by several runs
+		token, _, err := jwt.NewParser().ParseUnverified(parts[1], &actionsClaims{})
+		if err != nil {
+			return nil, err
+		}
+		c, ok := token.Claims.(*actionsClaims)
+		if !ok {
+			return nil, fmt.Errorf("invalid token claim")
+		}
+		if err := jwt.NewValidator().Validate(c); err != nil {
+			return nil, err
+		}
+		return c, nil
 	}
 
 	token, err := jwt.ParseWithClaims(parts[1], &actionsClaims{}, func(t *jwt.Token) (any, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte{}, nil
+		return key, nil
 	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	c, ok := token.Claims.(*actionsClaims)
 	if !token.Valid || !ok {
-		return 0, fmt.Errorf("invalid token claim")
+		return nil, fmt.Errorf("invalid token claim")
 	}
 
-	return c.TaskID, nil
+	return c, nil
 }
@@ -32,7 +32,7 @@ func TestCreateAuthorizationToken(t *testing.T) {
 	assert.True(t, ok, "Has ac claim in jwt token")
 	ac, ok := acClaim.(string)
 	assert.True(t, ok, "ac claim is a string for buildx gha cache")
-	scopes := []actionsCacheScope{}
+	scopes := []CacheScope{}
 	err = json.Unmarshal([]byte(ac), &scopes)
 	assert.NoError(t, err, "ac claim is a json list for buildx gha cache")
 	assert.GreaterOrEqual(t, len(scopes), 1, "Expected at least one action cache scope for buildx gha cache")
@@ -60,3 +60,69 @@ func TestParseAuthorizationTokenNoAuthHeader(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, int64(0), rTaskID)
 }
+
+func TestParseCacheAccess(t *testing.T) {
+	access := CacheAccess{
+		Repository: "nektos/act",
+		Scopes: []CacheScope{
+			{Scope: "refs/heads/feature", Permission: CachePermissionRead | CachePermissionWrite},
+			{Scope: "refs/heads/main", Permission: CachePermissionRead},
+		},
+	}
+	key := []byte("run key")
+	token, err := CreateCacheAuthorizationToken(1, 1, 1, access, key)
+	assert.NoError(t, err)
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer "+token)
+	parsed, err := ParseCacheAccess(&http.Request{
+		Header: headers,
+	}, key)
+	assert.NoError(t, err)
+	assert.Equal(t, &access, parsed)
+
+	_, err = ParseCacheAccess(&http.Request{
+		Header: headers,
+	}, []byte("other key"))
+	assert.Error(t, err)
+
+	// the claims of an unverified token aren't trusted
+	unsigned, err := CreateCacheAuthorizationToken(1, 1, 1, access, nil)
+	assert.NoError(t, err)
+	headers.Set("Authorization", "Bearer "+unsigned)
+	parsed, err = ParseCacheAccess(&http.Request{
+		Header: headers,
+	}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, parsed)
+
+	parsed, err = ParseCacheAccess(&http.Request{
+		Header: http.Header{},
+	}, key)
+	assert.NoError(t, err)
+	assert.Nil(t, parsed)
+}
+
+func TestVerifyAuthorizationToken(t *testing.T) {
+	key := []byte("run key")
+	request := func(token string) *http.Request {
+		headers := http.Header{}
+		if token != "" {
+			headers.Set("Authorization", "Bearer "+token)
+		}
+		return &http.Request{Header: headers}
+	}
+
+	signed, err := CreateCacheAuthorizationToken(1, 1, 1, CacheAccess{}, key)
+	assert.NoError(t, err)
+	assert.NoError(t, VerifyAuthorizationToken(request(signed), key))
+
+	otherKey, err := CreateCacheAuthorizationToken(1, 1, 1, CacheAccess{}, []byte("other key"))
+	assert.NoError(t, err)
+	assert.Error(t, VerifyAuthorizationToken(request(otherKey), key))
+
+	unsigned, err := CreateAuthorizationToken(1, 1, 1)
+	assert.NoError(t, err)
+	assert.Error(t, VerifyAuthorizationToken(request(unsigned), key))
+
+	assert.EqualError(t, VerifyAuthorizationToken(request(""), key), "missing runtime token")
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/nektos/act/pkg/common"
 )
 
+const logPrefix = "  \U0001F433  "
+
 // NewContainerInput the input for the New function
 type NewContainerInput struct {
 	Image          string
@@ -57,6 +59,12 @@ type Container interface {
 	GetHealth(ctx context.Context) Health
 }
 
+// LogsFollower is implemented by the containers which can stream the output of their main process while they run,
+// like the service containers
+type LogsFollower interface {
+	FollowLogs() common.Executor
+}
+
 // NewDockerBuildExecutorInput the input for the NewDockerBuildExecutor function
 type NewDockerBuildExecutorInput struct {
 	ContextDir   string
@@ -499,7 +499,8 @@ func parse(flags *pflag.FlagSet, copts *containerOptions, serverOS string) (*con
 	}
 
 	usernsMode := container.UsernsMode(copts.usernsMode)
-	if !usernsMode.Valid() {
+	// act: accept the modes of podman like keep-id, which its Docker compatible API supports
+	if !usernsMode.Valid() && !isPodmanUsernsMode(copts.usernsMode) {
 		return nil, errors.Errorf("--userns: invalid USER mode")
 	}
 
@@ -1082,3 +1083,13 @@ func validateAPIVersion(c *containerConfig, serverAPIVersion string) error {
 	}
 	return nil
 }
+
+// isPodmanUsernsMode returns whether the mode is one of the user namespace modes only podman supports
+func isPodmanUsernsMode(mode string) bool {
+	name, _, _ := strings.Cut(mode, ":")
+	switch name {
+	case "keep-id", "nomap", "auto":
+		return true
+	}
+	return false
+}
@@ -642,6 +642,15 @@ func TestParseModes(t *testing.T) {
 	if !hostconfig.UTSMode.Valid() {
 		t.Fatalf("Expected a valid UTSMode, got %v", hostconfig.UTSMode)
 	}
+
+	// userns ko
+	_, _, _, err = parseRun([]string{"--userns=other", "img", "cmd"}) //nolint:dogsled
+	assert.ErrorContains(t, err, "--userns: invalid USER mode")
+
+	// userns ok, the modes of podman are passed on
+	_, hostconfig, _, err = parseRun([]string{"--userns=keep-id:uid=1001", "img", "cmd"})
+	assert.NilError(t, err)
+	assert.Equal(t, container.UsernsMode("keep-id:uid=1001"), hostconfig.UsernsMode)
 }
 
 func TestRunFlagsParseShmSize(t *testing.T) {
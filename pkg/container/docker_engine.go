@@ -0,0 +1,51 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows || netbsd))
+
+package container
+
+import (
+	"context"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/client"
+)
+
+// GetEngineInfo asks the engine behind DOCKER_HOST what it is
+func GetEngineInfo(ctx context.Context) (EngineInfo, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return EngineInfo{}, err
+	}
+	defer cli.Close()
+	return getEngineInfo(ctx, cli)
+}
+
+func getEngineInfo(ctx context.Context, cli client.APIClient) (EngineInfo, error) {
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return EngineInfo{}, err
+	}
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return EngineInfo{}, err
+	}
+	return engineInfo(version, info), nil
+}
+
+func engineInfo(version types.Version, info system.Info) EngineInfo {
+	ret := EngineInfo{Engine: EngineDocker}
+	// the compatible API of podman reports a "Podman Engine" component
+	for _, component := range version.Components {
+		if strings.HasPrefix(component.Name, "Podman") {
+			ret.Engine = EnginePodman
+		}
+	}
+	// both engines report the rootless mode like docker info
+	for _, opt := range info.SecurityOptions {
+		if strings.Contains(opt, "name=rootless") {
+			ret.Rootless = true
+		}
+	}
+	return ret
+}
@@ -0,0 +1,85 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+	"github.com/nektos/act/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineInfo(t *testing.T) {
+	docker := types.Version{Components: []types.ComponentVersion{{Name: "Engine"}, {Name: "containerd"}}}
+	podman := types.Version{Components: []types.ComponentVersion{{Name: "Podman Engine"}, {Name: "Conmon"}}}
+	rootless := system.Info{SecurityOptions: []string{"name=seccomp,profile=default", "name=rootless"}}
+
+	assert.Equal(t, EngineInfo{Engine: EngineDocker}, engineInfo(docker, system.Info{}))
+	assert.Equal(t, EngineInfo{Engine: EngineDocker, Rootless: true}, engineInfo(docker, rootless))
+	assert.Equal(t, EngineInfo{Engine: EnginePodman}, engineInfo(podman, system.Info{}))
+	assert.Equal(t, EngineInfo{Engine: EnginePodman, Rootless: true}, engineInfo(podman, rootless))
+}
+
+func TestGetEngineInfoPodman(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	// t.Setenv restores DOCKER_HOST after the test
+	t.Setenv("DOCKER_HOST", "")
+	os.Unsetenv("DOCKER_HOST")
+	socket, found := engineSocketLocation(EnginePodman)
+	if !found {
+		t.Skip("podman isn't available")
+	}
+	t.Setenv("DOCKER_HOST", socket)
+
+	info, err := GetEngineInfo(context.Background())
+	if err != nil {
+		t.Skipf("podman isn't running: %v", err)
+	}
+	require.Equal(t, EnginePodman, info.Engine)
+	assert.Equal(t, os.Getuid() != 0, info.Rootless)
+}
+
+func TestRootlessPodmanKeepsHostUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	t.Setenv("DOCKER_HOST", "")
+	os.Unsetenv("DOCKER_HOST")
+	socket, found := engineSocketLocation(EnginePodman)
+	if !found {
+		t.Skip("podman isn't available")
+	}
+	t.Setenv("DOCKER_HOST", socket)
+	ctx := context.Background()
+	if info, err := GetEngineInfo(ctx); err != nil || info.Engine != EnginePodman || !info.Rootless {
+		t.Skip("rootless podman isn't running")
+	}
+
+	workdir := t.TempDir()
+	cr := NewContainer(&NewContainerInput{
+		Image:       "docker.io/library/alpine:3",
+		Entrypoint:  []string{"tail", "-f", "/dev/null"},
+		WorkingDir:  "/work",
+		Binds:       []string{workdir + ":/work"},
+		Name:        "act-test-rootless-podman",
+		NetworkMode: "host",
+		Options:     "--user 1001:1001",
+	})
+	if err := cr.Pull(false)(ctx); err != nil {
+		t.Skipf("failed to pull the image: %v", err)
+	}
+	require.NoError(t, common.NewPipelineExecutor(cr.Create(nil, nil), cr.Start(false))(ctx))
+	defer func() {
+		_ = cr.Remove()(ctx)
+	}()
+
+	// the user of the container is the user on the host, it writes to the bound working directory
+	require.NoError(t, cr.Exec([]string{"touch", "/work/file"}, nil, "", "")(ctx))
+	assert.FileExists(t, filepath.Join(workdir, "file"))
+}
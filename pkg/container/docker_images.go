@@ -5,9 +5,13 @@ package container
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+
+	"github.com/nektos/act/pkg/common"
 )
 
 // ImageExistsLocally returns a boolean indicating if an image with the
@@ -58,3 +62,42 @@ func RemoveImage(ctx context.Context, imageName string, force bool, pruneChildre
 
 	return true, nil
 }
+
+// NewDockerImageSaveExecutor saves images of the local store into a tar archive, like `docker save`
+func NewDockerImageSaveExecutor(images []string, file string) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		logger.Infof("%sdocker save -o %s %v", logPrefix, file, images)
+
+		if common.Dryrun(ctx) {
+			return nil
+		}
+
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		imageRefs := make([]string, len(images))
+		for i, img := range images {
+			imageRefs[i] = cleanImage(ctx, img)
+		}
+
+		reader, err := cli.ImageSave(ctx, imageRefs)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, reader); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
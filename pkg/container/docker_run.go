@@ -9,12 +9,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"dario.cat/mergo"
 	"github.com/Masterminds/semver"
@@ -89,10 +92,21 @@ func (cr *containerReference) Start(attach bool) common.Executor {
 				cr.tryReadUID(),
 				cr.tryReadGID(),
 				func(ctx context.Context) error {
-					// If this fails, then folders have wrong permissions on non root container
-					if cr.UID != 0 || cr.GID != 0 {
-						_ = cr.Exec([]string{"chown", "-R", fmt.Sprintf("%d:%d", cr.UID, cr.GID), cr.input.WorkingDir}, nil, "0", "")(ctx)
+					if cr.UID == 0 && cr.GID == 0 {
+						return nil
+					}
+					// A rootless engine maps root of the container to the user on the host and the other ids to subordinate ones,
+					// chowning a bound working directory would take the files of the host away from the user
+					if isBound(cr.input.Binds, cr.input.WorkingDir) {
+						if info, err := getEngineInfo(ctx, cr.cli); err == nil && info.Rootless {
+							if !cr.keepsHostUser(ctx) {
+								common.Logger(ctx).Warnf("The user %d:%d of the container can't write to the bound working directory, the rootless %s engine maps the user on the host to root of the container. Pass a numeric --user with --container-options to podman to map the user on the host to it", cr.UID, cr.GID, info.Engine)
+							}
+							return nil
+						}
 					}
+					// If this fails, then folders have wrong permissions on non root container
+					_ = cr.Exec([]string{"chown", "-R", fmt.Sprintf("%d:%d", cr.UID, cr.GID), cr.input.WorkingDir}, nil, "0", "")(ctx)
 					return nil
 				},
 			).IfNot(common.Dryrun),
@@ -171,28 +185,111 @@ func (cr *containerReference) Remove() common.Executor {
 
 func (cr *containerReference) GetHealth(ctx context.Context) Health {
 	resp, err := cr.cli.ContainerInspect(ctx, cr.id)
-	logger := common.Logger(ctx)
 	if err != nil {
-		logger.Errorf("failed to query container health %s", err)
+		common.Logger(ctx).Errorf("failed to query container health %s", err)
 		return HealthUnHealthy
 	}
-	if resp.Config == nil || resp.Config.Healthcheck == nil || resp.State == nil || resp.State.Health == nil || len(resp.Config.Healthcheck.Test) == 1 && strings.EqualFold(resp.Config.Healthcheck.Test[0], "NONE") {
-		logger.Debugf("no container health check defined")
-		return HealthHealthy
+	return cr.health(ctx, resp)
+}
+
+// health returns the health of the inspected container, a container without health check is healthy once it accepts
+// connections on the TCP ports of its input
+func (cr *containerReference) health(ctx context.Context, resp container.InspectResponse) Health {
+	logger := common.Logger(ctx)
+	image := ""
+	if resp.Config != nil {
+		image = resp.Config.Image
+	}
+	if resp.ContainerJSONBase != nil && resp.State != nil && !resp.State.Running {
+		logger.Errorf("container %s (%s) isn't running, it exited with code %d %s", cr.input.Name, image, resp.State.ExitCode, resp.State.Error)
+		return HealthUnHealthy
+	}
+	if resp.Config == nil || resp.Config.Healthcheck == nil || resp.ContainerJSONBase == nil || resp.State == nil || resp.State.Health == nil || len(resp.Config.Healthcheck.Test) == 1 && strings.EqualFold(resp.Config.Healthcheck.Test[0], "NONE") {
+		return cr.portsHealth(ctx, resp)
 	}
 
-	logger.Infof("container health of %s (%s) is %s", cr.id, resp.Config.Image, resp.State.Health.Status)
+	logger.Infof("container health of %s (%s) is %s", cr.id, image, resp.State.Health.Status)
 	switch resp.State.Health.Status {
 	case "starting":
 		return HealthStarting
 	case "healthy":
 		return HealthHealthy
-	case "unhealthy":
-		return HealthUnHealthy
+	}
+	// the output of the probes tells why, like the docker inspect --format '{{json .State.Health}}' of the container
+	logger.Errorf("container %s (%s) is unhealthy, the last health checks %+q returned:", cr.input.Name, image, resp.Config.Healthcheck.Test)
+	for _, probe := range resp.State.Health.Log {
+		if probe != nil {
+			logger.Errorf("  exit code %d: %s", probe.ExitCode, strings.TrimSpace(probe.Output))
+		}
 	}
 	return HealthUnHealthy
 }
 
+// portsHealth waits for the container to listen on its TCP ports, which act reaches by the address of the container in its network
+func (cr *containerReference) portsHealth(ctx context.Context, resp container.InspectResponse) Health {
+	logger := common.Logger(ctx)
+	ports := make([]string, 0, len(cr.input.ExposedPorts))
+	for port := range cr.input.ExposedPorts {
+		if port.Proto() == "tcp" {
+			ports = append(ports, port.Port())
+		}
+	}
+	sort.Strings(ports)
+	addresses := []string{}
+	if resp.NetworkSettings != nil {
+		for _, port := range ports {
+			for _, endpoint := range resp.NetworkSettings.Networks {
+				if endpoint != nil && endpoint.IPAddress != "" {
+					addresses = append(addresses, net.JoinHostPort(endpoint.IPAddress, port))
+					break
+				}
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		logger.Debugf("no container health check defined")
+		return HealthHealthy
+	}
+
+	ready, err := portsReady(ctx, addresses, time.Second)
+	if err != nil {
+		// like on Docker Desktop, which doesn't route the networks of the containers to the host
+		logger.Debugf("Not waiting for the ports of container %s, act can't reach them: %v", cr.input.Name, err)
+		return HealthHealthy
+	} else if !ready {
+		logger.Infof("waiting for container %s to listen on %s", cr.input.Name, strings.Join(addresses, ", "))
+		return HealthStarting
+	}
+	return HealthHealthy
+}
+
+// FollowLogs streams the output of the container to the writers of its input until it stops
+func (cr *containerReference) FollowLogs() common.Executor {
+	return func(ctx context.Context) error {
+		logs, err := cr.cli.ContainerLogs(ctx, cr.id, container.LogsOptions{
+			ShowStdout: true,
+			ShowStderr: true,
+			Follow:     true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to follow the logs of the container: %w", err)
+		}
+		isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
+		go func() {
+			defer logs.Close()
+			if !isTerminal || os.Getenv("NORAW") != "" {
+				_, err = stdcopy.StdCopy(cr.input.Stdout, cr.input.Stderr, logs)
+			} else {
+				_, err = io.Copy(cr.input.Stdout, logs)
+			}
+			if err != nil && !errors.Is(err, context.Canceled) {
+				common.Logger(ctx).Debugf("Stopped following the logs of container %s: %v", cr.input.Name, err)
+			}
+		}()
+		return nil
+	}
+}
+
 func (cr *containerReference) ReplaceLogWriter(stdout io.Writer, stderr io.Writer) (io.Writer, io.Writer) {
 	out := cr.input.Stdout
 	err := cr.input.Stderr
@@ -259,7 +356,8 @@ func GetHostInfo(ctx context.Context) (info system.Info, err error) {
 func RunnerArch(ctx context.Context) string {
 	info, err := GetHostInfo(ctx)
 	if err != nil {
-		return ""
+		// without a daemon, like for the namespaces engine, the containers run on the host
+		return goArchToActionArch(runtime.GOARCH)
 	}
 
 	archMapper := map[string]string{
@@ -472,6 +570,9 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 		if err != nil {
 			return err
 		}
+		if hostConfig.UsernsMode == "" && isBound(hostConfig.Binds, config.WorkingDir) {
+			hostConfig.UsernsMode = cr.rootlessUsernsMode(ctx, config.User)
+		}
 
 		var networkingConfig *network.NetworkingConfig
 		logger.Debugf("input.NetworkAliases ==> %v", input.NetworkAliases)
@@ -645,6 +746,64 @@ func (cr *containerReference) tryReadID(opt string, cbk func(id int)) common.Exe
 	}
 }
 
+// isBound returns whether the path is or is inside of the target of a bind
+func isBound(binds []string, path string) bool {
+	for _, bind := range binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		// windows paths of the host contain a drive letter, the target is the last path
+		target := parts[len(parts)-1]
+		if !strings.HasPrefix(target, "/") {
+			target = parts[len(parts)-2]
+		}
+		if path == target || strings.HasPrefix(path, strings.TrimSuffix(target, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// rootlessUsernsMode returns the user namespace mapping the user on the host to the user of the container on a rootless
+// podman, so that the user of the container owns the files of a bound working directory like on a rootful engine.
+// It is empty on other engines and if the user isn't known before the container starts.
+func (cr *containerReference) rootlessUsernsMode(ctx context.Context, user string) container.UsernsMode {
+	info, err := getEngineInfo(ctx, cr.cli)
+	if err != nil || !info.Rootless || info.Engine != EnginePodman {
+		return ""
+	}
+	if user == "" {
+		if inspect, err := cr.cli.ImageInspect(ctx, cr.input.Image); err == nil && inspect.Config != nil {
+			user = inspect.Config.User
+		}
+	}
+	return keepIDUsernsMode(user)
+}
+
+// keepIDUsernsMode returns the keep-id user namespace of podman for a numeric uid[:gid] user. Root doesn't need it
+// since rootless engines map it to the user on the host, the ids of named users are only known in the container.
+func keepIDUsernsMode(user string) container.UsernsMode {
+	uid, gid, hasGID := strings.Cut(user, ":")
+	if id, err := strconv.Atoi(uid); err != nil || id == 0 {
+		return ""
+	}
+	if _, err := strconv.Atoi(gid); hasGID && err == nil {
+		return container.UsernsMode(fmt.Sprintf("keep-id:uid=%s,gid=%s", uid, gid))
+	}
+	return container.UsernsMode("keep-id:uid=" + uid)
+}
+
+// keepsHostUser returns whether podman maps the user on the host to the user of the container
+func (cr *containerReference) keepsHostUser(ctx context.Context) bool {
+	inspect, err := cr.cli.ContainerInspect(ctx, cr.id)
+	if err != nil || inspect.ContainerJSONBase == nil || inspect.HostConfig == nil {
+		return false
+	}
+	mode := string(inspect.HostConfig.UsernsMode)
+	return mode == "keep-id" || strings.HasPrefix(mode, "keep-id:")
+}
+
 func (cr *containerReference) tryReadUID() common.Executor {
 	return cr.tryReadID("-u", func(id int) { cr.UID = id })
 }
@@ -13,8 +13,11 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 	"github.com/nektos/act/pkg/common"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -84,6 +87,11 @@ func (m *mockDockerClient) CopyToContainer(ctx context.Context, id string, path
 	return args.Error(0)
 }
 
+func (m *mockDockerClient) ContainerInspect(ctx context.Context, id string) (container.InspectResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(container.InspectResponse), args.Error(1)
+}
+
 type endlessReader struct {
 	io.Reader
 }
@@ -270,3 +278,102 @@ func TestDockerCopyTarStreamErrorInMkdir(t *testing.T) {
 
 // Type assert containerReference implements ExecutionsEnvironment
 var _ ExecutionsEnvironment = &containerReference{}
+
+func TestIsBound(t *testing.T) {
+	binds := []string{"/var/run/docker.sock:/var/run/docker.sock", "/home/user/repo:/home/user/repo:z", `C:\repo:/c/repo`}
+	assert.True(t, isBound(binds, "/home/user/repo"))
+	assert.True(t, isBound(binds, "/home/user/repo/sub"))
+	assert.True(t, isBound(binds, "/c/repo"))
+	assert.False(t, isBound(binds, "/home/user/repository"))
+	assert.False(t, isBound(binds, "/github/workspace"))
+	assert.False(t, isBound(nil, "/github/workspace"))
+}
+
+func TestKeepIDUsernsMode(t *testing.T) {
+	assert.Equal(t, container.UsernsMode("keep-id:uid=1001,gid=121"), keepIDUsernsMode("1001:121"))
+	assert.Equal(t, container.UsernsMode("keep-id:uid=1001"), keepIDUsernsMode("1001"))
+	assert.Equal(t, container.UsernsMode("keep-id:uid=1001"), keepIDUsernsMode("1001:docker"))
+	assert.Equal(t, container.UsernsMode(""), keepIDUsernsMode("0:0"))
+	assert.Equal(t, container.UsernsMode(""), keepIDUsernsMode("runner"))
+	assert.Equal(t, container.UsernsMode(""), keepIDUsernsMode(""))
+}
+
+func TestDockerGetHealth(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	assert.NoError(t, closed.Close())
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+
+	running := &container.State{Running: true}
+	networks := &container.NetworkSettings{Networks: map[string]*network.EndpointSettings{"act": {IPAddress: "127.0.0.1"}}}
+	table := []struct {
+		name   string
+		resp   container.InspectResponse
+		ports  []string
+		health Health
+		log    string
+	}{
+		{
+			name:   "no health check",
+			resp:   container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{State: running}, Config: &container.Config{}},
+			health: HealthHealthy,
+		},
+		{
+			name:   "exited",
+			resp:   container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{ExitCode: 3}}, Config: &container.Config{Image: "postgres"}},
+			health: HealthUnHealthy,
+			log:    "container act-service (postgres) isn't running, it exited with code 3",
+		},
+		{
+			name: "unhealthy",
+			resp: container.InspectResponse{
+				ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true, Health: &container.Health{
+					Status: "unhealthy",
+					Log:    []*container.HealthcheckResult{{ExitCode: 1, Output: "/var/run/postgresql:5432 - no response\n"}},
+				}}},
+				Config: &container.Config{Image: "postgres", Healthcheck: &container.HealthConfig{Test: []string{"CMD-SHELL", "pg_isready"}}},
+			},
+			health: HealthUnHealthy,
+			log:    "exit code 1: /var/run/postgresql:5432 - no response",
+		},
+		{
+			name:   "listening",
+			resp:   container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{State: running}, Config: &container.Config{}, NetworkSettings: networks},
+			ports:  []string{port, "53/udp"},
+			health: HealthHealthy,
+		},
+		{
+			name:   "not listening",
+			resp:   container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{State: running}, Config: &container.Config{}, NetworkSettings: networks},
+			ports:  []string{port, closedPort},
+			health: HealthStarting,
+			log:    "127.0.0.1:" + closedPort,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger := logrus.New()
+			logger.SetOutput(buf)
+			ctx := common.WithLogger(context.Background(), logger)
+			exposedPorts, _, err := nat.ParsePortSpecs(tt.ports)
+			assert.NoError(t, err)
+
+			client := &mockDockerClient{}
+			client.On("ContainerInspect", ctx, "123").Return(tt.resp, nil)
+			cr := &containerReference{
+				id:    "123",
+				cli:   client,
+				input: &NewContainerInput{Name: "act-service", ExposedPorts: exposedPorts},
+			}
+			assert.Equal(t, tt.health, cr.GetHealth(ctx))
+			assert.Contains(t, buf.String(), tt.log)
+			client.AssertExpectations(t)
+		})
+	}
+}
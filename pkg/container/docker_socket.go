@@ -19,13 +19,80 @@ var CommonSocketLocations = []string{
 	"$HOME/.docker/run/docker.sock",
 }
 
+// PodmanSocketLocations are the sockets of the Docker compatible API of podman, the socket of a rootless podman comes first
+var PodmanSocketLocations = []string{
+	"$XDG_RUNTIME_DIR/podman/podman.sock",
+	"/run/podman/podman.sock",
+}
+
+// Engine is the container engine behind the Docker API act talks to. The engines differ in the sockets act looks for
+// and mounts into the job containers, and in the user namespaces of rootless engines, see EngineInfo. The networks
+// are created the same way for all of them.
+type Engine string
+
+const (
+	// EngineAuto uses the first engine found in the usual locations
+	EngineAuto Engine = ""
+	// EngineDocker is the Docker Engine, the sockets of podman are ignored
+	EngineDocker Engine = "docker"
+	// EnginePodman is podman with its Docker compatible API, see podman-system-service(1)
+	EnginePodman Engine = "podman"
+	// EngineNamespaces runs the job containers without a daemon in Linux namespaces, see NamespaceEnvironment
+	EngineNamespaces Engine = "namespaces"
+	// EngineKubernetes runs the jobs as pods of the cluster of the kubeconfig, see KubernetesEnvironment
+	EngineKubernetes Engine = "kubernetes"
+)
+
+// EngineInfo describes the engine behind DOCKER_HOST
+type EngineInfo struct {
+	Engine Engine
+	// Rootless is set if the engine runs as an unprivileged user, root of the containers is the user on the host then.
+	// act keeps the owner of a bound working directory then, and maps the user on the host to a numeric user of the
+	// container on podman.
+	Rootless bool
+}
+
+// ParseEngine parses the name of an engine, auto and the empty string select EngineAuto
+func ParseEngine(name string) (Engine, error) {
+	switch engine := Engine(strings.ToLower(name)); engine {
+	case EngineAuto, EngineDocker, EnginePodman, EngineNamespaces, EngineKubernetes:
+		return engine, nil
+	case "auto":
+		return EngineAuto, nil
+	default:
+		return EngineAuto, fmt.Errorf("unknown container engine '%s', expected one of auto, docker, podman, namespaces or kubernetes", name)
+	}
+}
+
 // returns socket URI or false if not found any
 func socketLocation() (string, bool) {
+	return engineSocketLocation(EngineAuto)
+}
+
+// engineSocketLocation returns the socket URI of the engine or false if not found any.
+// DOCKER_HOST is preferred for every engine, podman also honors CONTAINER_HOST like podman --remote.
+func engineSocketLocation(engine Engine) (string, bool) {
 	if dockerHost, exists := os.LookupEnv("DOCKER_HOST"); exists {
 		return dockerHost, true
 	}
 
-	for _, p := range CommonSocketLocations {
+	locations := CommonSocketLocations
+	switch engine {
+	case EngineDocker:
+		locations = nil
+		for _, p := range CommonSocketLocations {
+			if !strings.Contains(p, "podman") {
+				locations = append(locations, p)
+			}
+		}
+	case EnginePodman:
+		if containerHost, exists := os.LookupEnv("CONTAINER_HOST"); exists {
+			return containerHost, true
+		}
+		locations = PodmanSocketLocations
+	}
+
+	for _, p := range locations {
 		if _, err := os.Lstat(os.ExpandEnv(p)); err == nil {
 			if strings.HasPrefix(p, `\\.\`) {
 				return "npipe://" + filepath.ToSlash(os.ExpandEnv(p)), true
@@ -60,10 +127,15 @@ type SocketAndHost struct {
 }
 
 func GetSocketAndHost(containerSocket string) (SocketAndHost, error) {
+	return GetEngineSocketAndHost(EngineAuto, containerSocket)
+}
+
+// GetEngineSocketAndHost is GetSocketAndHost looking for the sockets of the given engine only
+func GetEngineSocketAndHost(engine Engine, containerSocket string) (SocketAndHost, error) {
 	log.Debugf("Handling container host and socket")
 
 	// Prefer DOCKER_HOST, don't override it
-	dockerHost, hasDockerHost := socketLocation()
+	dockerHost, hasDockerHost := engineSocketLocation(engine)
 	socketHost := SocketAndHost{Socket: containerSocket, Host: dockerHost}
 
 	// ** socketHost.Socket cases **
@@ -79,7 +151,7 @@ func GetSocketAndHost(containerSocket string) (SocketAndHost, error) {
 	// Set host for sanity's sake, when the socket isn't useful
 	if !hasDockerHost && (socketHost.Socket == "-" || !isDockerHostURI(socketHost.Socket) || socketHost.Socket == "") {
 		// Cases: 1B, 2B, 4B
-		socket, found := socketLocation()
+		socket, found := engineSocketLocation(engine)
 		socketHost.Host = socket
 		hasDockerHost = found
 	}
@@ -102,7 +174,7 @@ func GetSocketAndHost(containerSocket string) (SocketAndHost, error) {
 	// Set sane default socket location if user omitted it
 	if socketHost.Socket == "" {
 		// Cases: 4B
-		socket, _ := socketLocation()
+		socket, _ := engineSocketLocation(engine)
 		// socket is empty if it isn't found, so assignment here is at worst a no-op
 		log.Debugf("Defaulting container socket to default '%s'", socket)
 		socketHost.Socket = socket
@@ -148,3 +148,54 @@ func TestGetSocketAndHostOnlySocketValidButUnusualLocation(t *testing.T) {
 	assert.Nil(t, err, "Expect no error from GetSocketAndHost")
 	assert.Equal(t, socketURI, ret.Host, "Expect host to default to unusual socket")
 }
+
+func TestParseEngine(t *testing.T) {
+	for name, want := range map[string]Engine{"": EngineAuto, "auto": EngineAuto, "docker": EngineDocker, "Podman": EnginePodman, "kubernetes": EngineKubernetes} {
+		engine, err := ParseEngine(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, want, engine, name)
+	}
+	_, err := ParseEngine("containerd")
+	assert.Error(t, err)
+}
+
+func TestGetEngineSocketAndHost(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	dockerSocket := dir + "/docker.sock"
+	podmanSocket := dir + "/podman/podman.sock"
+	assert.NoError(t, os.MkdirAll(dir+"/podman", 0o755))
+	assert.NoError(t, os.WriteFile(dockerSocket, nil, 0o600))
+	assert.NoError(t, os.WriteFile(podmanSocket, nil, 0o600))
+	os.Unsetenv("DOCKER_HOST")
+	os.Unsetenv("CONTAINER_HOST")
+	originalPodmanSocketLocations := PodmanSocketLocations
+	defer func() {
+		CommonSocketLocations = originalCommonSocketLocations
+		PodmanSocketLocations = originalPodmanSocketLocations
+	}()
+	CommonSocketLocations = []string{podmanSocket, dockerSocket}
+	PodmanSocketLocations = []string{"/unusual", podmanSocket}
+
+	// Act & Assert
+	ret, err := GetEngineSocketAndHost(EngineAuto, "")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{"unix://" + podmanSocket, "unix://" + podmanSocket}, ret, "Expected the first common location")
+
+	ret, err = GetEngineSocketAndHost(EngineDocker, "")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{"unix://" + dockerSocket, "unix://" + dockerSocket}, ret, "Expected the sockets of podman to be skipped")
+
+	ret, err = GetEngineSocketAndHost(EnginePodman, "-")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{"-", "unix://" + podmanSocket}, ret, "Expected a podman socket")
+
+	t.Setenv("CONTAINER_HOST", "unix:///run/user/1000/podman/podman.sock")
+	ret, err = GetEngineSocketAndHost(EnginePodman, "")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{"unix:///run/user/1000/podman/podman.sock", "unix:///run/user/1000/podman/podman.sock"}, ret, "Expected CONTAINER_HOST to be preferred")
+
+	ret, err = GetEngineSocketAndHost(EngineDocker, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "unix://"+dockerSocket, ret.Host, "Expected CONTAINER_HOST to be ignored")
+}
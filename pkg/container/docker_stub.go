@@ -37,6 +37,13 @@ func NewDockerPullExecutor(input NewDockerPullExecutorInput) common.Executor {
 	}
 }
 
+// NewDockerImageSaveExecutor saves images of the local store into a tar archive, like `docker save`
+func NewDockerImageSaveExecutor(images []string, file string) common.Executor {
+	return func(ctx context.Context) error {
+		return errors.New("Unsupported Operation")
+	}
+}
+
 // NewContainer creates a reference to a container
 func NewContainer(input *NewContainerInput) ExecutionsEnvironment {
 	return nil
@@ -50,6 +57,10 @@ func GetHostInfo(ctx context.Context) (info system.Info, err error) {
 	return system.Info{}, nil
 }
 
+func GetEngineInfo(ctx context.Context) (EngineInfo, error) {
+	return EngineInfo{}, errors.New("Unsupported Operation")
+}
+
 func NewDockerVolumeRemoveExecutor(volume string, force bool) common.Executor {
 	return func(ctx context.Context) error {
 		return nil
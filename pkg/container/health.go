@@ -0,0 +1,81 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/pflag"
+)
+
+// HealthCheck is the health check of a service container given by its options, like docker run --health-cmd
+type HealthCheck struct {
+	// Cmd is run by /bin/sh -c in the container, the container is healthy if it exits with 0
+	Cmd         string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+	// Disabled is set by --no-healthcheck, which disables the HEALTHCHECK of the image
+	Disabled bool
+}
+
+// ParseHealthCheck parses the --health-* and --no-healthcheck options of the container options like docker run.
+// It returns nil if there are none and whether the options have others, which aren't parsed.
+func ParseHealthCheck(options string) (*HealthCheck, bool, error) {
+	args, err := shellquote.Split(options)
+	if err != nil {
+		return nil, false, fmt.Errorf("Cannot split container options: '%s': '%w'", options, err)
+	}
+	check := &HealthCheck{}
+	flags := pflag.NewFlagSet("health_flags", pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.StringVar(&check.Cmd, "health-cmd", "", "")
+	flags.DurationVar(&check.Interval, "health-interval", 0, "")
+	flags.DurationVar(&check.Timeout, "health-timeout", 0, "")
+	flags.DurationVar(&check.StartPeriod, "health-start-period", 0, "")
+	flags.IntVar(&check.Retries, "health-retries", 0, "")
+	flags.BoolVar(&check.Disabled, "no-healthcheck", false, "")
+	if err := flags.Parse(args); err != nil {
+		return nil, false, fmt.Errorf("Cannot parse container options: '%s': '%w'", options, err)
+	}
+
+	others := false
+	for _, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if name != arg && !strings.HasPrefix(name, "health-") && !strings.HasPrefix(name, "no-healthcheck") {
+			others = true
+		}
+	}
+	if flags.NFlag() == 0 {
+		return nil, others, nil
+	}
+	if check.Disabled && check.Cmd != "" {
+		return nil, others, errors.New("--no-healthcheck conflicts with --health-* options")
+	}
+	if check.Interval < 0 || check.Timeout < 0 || check.StartPeriod < 0 || check.Retries < 0 {
+		return nil, others, fmt.Errorf("the --health-* options of '%s' cannot be negative", options)
+	}
+	return check, others, nil
+}
+
+// portsReady dials the TCP addresses to check whether a container without health check listens on its ports.
+// It returns false if one of them refuses the connection and an error if one can't be reached at all.
+func portsReady(ctx context.Context, addresses []string, timeout time.Duration) (bool, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	for _, address := range addresses {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		conn.Close()
+	}
+	return true, nil
+}
@@ -0,0 +1,58 @@
+package container
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHealthCheck(t *testing.T) {
+	check, others, err := ParseHealthCheck(`--health-cmd "pg_isready -U postgres" --health-interval 10s --health-timeout=5s --health-retries 5`)
+	require.NoError(t, err)
+	assert.False(t, others)
+	assert.Equal(t, &HealthCheck{Cmd: "pg_isready -U postgres", Interval: 10 * time.Second, Timeout: 5 * time.Second, Retries: 5}, check)
+
+	check, others, err = ParseHealthCheck("--cpus 2 --no-healthcheck --privileged")
+	require.NoError(t, err)
+	assert.True(t, others, "the other options are skipped")
+	assert.Equal(t, &HealthCheck{Disabled: true}, check)
+
+	check, others, err = ParseHealthCheck("--network host")
+	require.NoError(t, err)
+	assert.True(t, others)
+	assert.Nil(t, check)
+
+	check, _, err = ParseHealthCheck("")
+	require.NoError(t, err)
+	assert.Nil(t, check)
+
+	_, _, err = ParseHealthCheck("--no-healthcheck --health-cmd true")
+	assert.ErrorContains(t, err, "conflicts")
+	_, _, err = ParseHealthCheck("--health-interval -1s")
+	assert.ErrorContains(t, err, "cannot be negative")
+}
+
+func TestPortsReady(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+
+	ctx := context.Background()
+	ready, err := portsReady(ctx, []string{listener.Addr().String()}, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+
+	ready, err = portsReady(ctx, []string{listener.Addr().String(), closed.Addr().String()}, time.Second)
+	assert.NoError(t, err)
+	assert.False(t, ready, "a refused connection means the service is starting")
+
+	_, err = portsReady(ctx, []string{"localhost:http:80"}, time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,715 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/kballard/go-shellquote"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/filecollector"
+)
+
+// KubernetesExecFunc runs a command in a container of a pod, like kubectl exec
+type KubernetesExecFunc func(ctx context.Context, namespace, pod, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) error
+
+// KubernetesConfig is the cluster the kubernetes engine runs the jobs in
+type KubernetesConfig struct {
+	Client    kubernetes.Interface
+	Namespace string
+	// Exec defaults to the exec subresource of the pods
+	Exec KubernetesExecFunc
+}
+
+// NewKubernetesConfig loads the current context of the kubeconfig like kubectl, the namespace defaults to the one of the context
+func NewKubernetesConfig(namespace string) (*KubernetesConfig, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, err
+		}
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &KubernetesConfig{
+		Client:    client,
+		Namespace: namespace,
+		Exec:      newKubernetesExec(client, restConfig),
+	}, nil
+}
+
+func newKubernetesExec(client kubernetes.Interface, restConfig *rest.Config) KubernetesExecFunc {
+	return func(ctx context.Context, namespace, pod, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+		req := client.CoreV1().RESTClient().Post().
+			Resource("pods").Namespace(namespace).Name(pod).SubResource("exec").
+			VersionedParams(&corev1.PodExecOptions{
+				Container: container,
+				Command:   command,
+				Stdin:     stdin != nil,
+				Stdout:    true,
+				Stderr:    true,
+			}, scheme.ParameterCodec)
+		executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+		if err != nil {
+			return err
+		}
+		return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+			Stdin:  stdin,
+			Stdout: stdout,
+			Stderr: stderr,
+		})
+	}
+}
+
+// KubernetesEnvironment runs a job as a pod, the service containers are sidecars of the job container.
+// The containers of the pod share the network, so the services are reachable by their names on localhost.
+type KubernetesEnvironment struct {
+	LinuxContainerEnvironmentExtensions
+	config    *KubernetesConfig
+	input     *NewContainerInput
+	services  []*NewContainerInput
+	pod       string
+	forcePull bool
+	// pollInterval is the interval of checking the state of the pod while it starts
+	pollInterval time.Duration
+	startTimeout time.Duration
+}
+
+// the name of the job container in the pod
+const kubernetesJobContainer = "job"
+
+// NewKubernetesEnvironment creates an environment running the job container and the service containers in one pod
+func NewKubernetesEnvironment(config *KubernetesConfig, input *NewContainerInput, services ...*NewContainerInput) ExecutionsEnvironment {
+	return &KubernetesEnvironment{
+		config:       config,
+		input:        input,
+		services:     services,
+		pod:          kubernetesName(input.Name),
+		pollInterval: time.Second,
+		startTimeout: 5 * time.Minute,
+	}
+}
+
+var kubernetesInvalidChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// kubernetesName turns a name into a DNS label, long names are shortened by a hash
+func kubernetesName(name string) string {
+	label := strings.Trim(kubernetesInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(label) > 63 {
+		sum := sha256.Sum256([]byte(name))
+		label = strings.TrimRight(label[:54], "-") + "-" + hex.EncodeToString(sum[:4])
+	}
+	return label
+}
+
+func (e *KubernetesEnvironment) Pull(forcePull bool) common.Executor {
+	return func(_ context.Context) error {
+		// the kubelet pulls the images
+		e.forcePull = forcePull
+		return nil
+	}
+}
+
+func (e *KubernetesEnvironment) Create(capAdd []string, capDrop []string) common.Executor {
+	return common.NewInfoExecutor("%skubernetes create pod=%s image=%s services=%d", logPrefix, e.pod, e.input.Image, len(e.services)).
+		Then(func(ctx context.Context) error {
+			pod, err := e.podSpec(ctx, capAdd, capDrop)
+			if err != nil {
+				return err
+			}
+			if e.input.Username != "" {
+				secret, err := e.registrySecret()
+				if err != nil {
+					return err
+				}
+				if _, err := e.config.Client.CoreV1().Secrets(e.config.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil && !kerrors.IsAlreadyExists(err) {
+					return fmt.Errorf("failed to create the registry secret: %w", err)
+				}
+				pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: secret.Name}}
+			}
+			_, err = e.config.Client.CoreV1().Pods(e.config.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+			if kerrors.IsAlreadyExists(err) {
+				common.Logger(ctx).Debugf("Reusing the pod %s", e.pod)
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("failed to create the pod: %w", err)
+			}
+			return nil
+		}).IfNot(common.Dryrun)
+}
+
+func (e *KubernetesEnvironment) podSpec(ctx context.Context, capAdd []string, capDrop []string) (*corev1.Pod, error) {
+	pullPolicy := corev1.PullIfNotPresent
+	if e.forcePull {
+		pullPolicy = corev1.PullAlways
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   e.pod,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "act"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	volumes := map[string]corev1.Volume{}
+	aliases := []string{}
+	inputs := append([]*NewContainerInput{e.input}, e.services...)
+	for i, input := range inputs {
+		name := kubernetesJobContainer
+		if i > 0 {
+			name = kubernetesServiceContainer(input)
+		}
+		healthCheck, otherOptions, err := ParseHealthCheck(input.Options)
+		if err != nil {
+			return nil, err
+		}
+		if otherOptions {
+			common.Logger(ctx).Warnf("The options '%s' of the container %s aren't supported by the kubernetes engine, except for the --health-* ones", input.Options, name)
+		}
+		c := corev1.Container{
+			Name:            name,
+			Image:           input.Image,
+			ImagePullPolicy: pullPolicy,
+			Command:         input.Entrypoint,
+			Args:            input.Cmd,
+			WorkingDir:      input.WorkingDir,
+			SecurityContext: &corev1.SecurityContext{},
+		}
+		if input.Privileged {
+			privileged := true
+			c.SecurityContext.Privileged = &privileged
+		}
+		if i == 0 && (len(capAdd) > 0 || len(capDrop) > 0) {
+			c.SecurityContext.Capabilities = &corev1.Capabilities{}
+			for _, capability := range capAdd {
+				c.SecurityContext.Capabilities.Add = append(c.SecurityContext.Capabilities.Add, corev1.Capability(capability))
+			}
+			for _, capability := range capDrop {
+				c.SecurityContext.Capabilities.Drop = append(c.SecurityContext.Capabilities.Drop, corev1.Capability(capability))
+			}
+		}
+		for _, kv := range input.Env {
+			k, v, _ := strings.Cut(kv, "=")
+			c.Env = append(c.Env, corev1.EnvVar{Name: k, Value: v})
+		}
+		ports := make([]string, 0, len(input.ExposedPorts))
+		for port := range input.ExposedPorts {
+			ports = append(ports, string(port))
+		}
+		sort.Strings(ports)
+		for _, p := range ports {
+			port := corev1.ContainerPort{
+				ContainerPort: int32(nat.Port(p).Int()),
+				Protocol:      corev1.Protocol(strings.ToUpper(nat.Port(p).Proto())),
+			}
+			for _, binding := range input.PortBindings[nat.Port(p)] {
+				if hostPort, err := strconv.ParseInt(binding.HostPort, 10, 32); err == nil {
+					port.HostPort = int32(hostPort)
+				}
+			}
+			c.Ports = append(c.Ports, port)
+		}
+		c.VolumeMounts = kubernetesVolumes(input, volumes)
+		if i > 0 {
+			c.ReadinessProbe = kubernetesReadinessProbe(healthCheck, c.Ports)
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, c)
+		aliases = append(aliases, input.NetworkAliases...)
+	}
+	names := make([]string, 0, len(volumes))
+	for name := range volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volumes[name])
+	}
+	if len(e.services) > 0 && len(aliases) > 0 {
+		pod.Spec.HostAliases = []corev1.HostAlias{{IP: "127.0.0.1", Hostnames: aliases}}
+	}
+	if goos, goarch, ok := strings.Cut(e.input.Platform, "/"); ok {
+		pod.Spec.NodeSelector = map[string]string{
+			"kubernetes.io/os":   goos,
+			"kubernetes.io/arch": goarch,
+		}
+	}
+	return pod, nil
+}
+
+// kubernetesServiceContainer returns the name of the container of a service in the pod
+func kubernetesServiceContainer(input *NewContainerInput) string {
+	if len(input.NetworkAliases) > 0 {
+		return kubernetesName(input.NetworkAliases[0])
+	}
+	return kubernetesName(input.Name)
+}
+
+// kubernetesReadinessProbe turns the health check of a service into the readiness probe of its container,
+// a service without health check is ready once it listens on its first TCP port
+func kubernetesReadinessProbe(check *HealthCheck, ports []corev1.ContainerPort) *corev1.Probe {
+	seconds := func(d time.Duration) int32 {
+		return int32((d + time.Second - 1) / time.Second)
+	}
+	switch {
+	case check != nil && check.Disabled:
+		return nil
+	case check != nil && check.Cmd != "":
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", check.Cmd}},
+			},
+			PeriodSeconds:    seconds(check.Interval),
+			TimeoutSeconds:   seconds(check.Timeout),
+			FailureThreshold: int32(check.Retries),
+		}
+	}
+	for _, port := range ports {
+		if port.Protocol == corev1.ProtocolTCP {
+			return &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{
+					TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(port.ContainerPort)},
+				},
+				PeriodSeconds: 1,
+			}
+		}
+	}
+	return nil
+}
+
+// kubernetesVolumes returns the mounts of the binds and the volumes of the container and adds their volumes.
+// Named and anonymous volumes are empty dirs shared by the containers of the pod, binds of the host are paths of the node.
+func kubernetesVolumes(input *NewContainerInput, volumes map[string]corev1.Volume) []corev1.VolumeMount {
+	mounts := []corev1.VolumeMount{}
+	add := func(name, source, target string, readOnly bool) {
+		name = kubernetesName(name)
+		if _, ok := volumes[name]; !ok {
+			volume := corev1.Volume{Name: name}
+			if source != "" {
+				volume.HostPath = &corev1.HostPathVolumeSource{Path: source}
+			} else {
+				volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+			}
+			volumes[name] = volume
+		}
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: target, ReadOnly: readOnly})
+	}
+	for _, bind := range input.Binds {
+		parts := strings.Split(bind, ":")
+		switch {
+		case len(parts) == 1:
+			add("anonymous"+parts[0], "", parts[0], false)
+		case strings.HasPrefix(parts[0], "/"):
+			add("host"+parts[0], parts[0], parts[1], len(parts) > 2 && strings.Contains(","+parts[2]+",", ",ro,"))
+		default:
+			add(parts[0], "", parts[1], len(parts) > 2 && strings.Contains(","+parts[2]+",", ",ro,"))
+		}
+	}
+	names := make([]string, 0, len(input.Mounts))
+	for name := range input.Mounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name, "", input.Mounts[name], false)
+	}
+	return mounts
+}
+
+func (e *KubernetesEnvironment) registrySecret() (*corev1.Secret, error) {
+	registry := "https://index.docker.io/v1/"
+	if ref := strings.SplitN(e.input.Image, "/", 2); len(ref) == 2 && strings.ContainsAny(ref[0], ".:") {
+		registry = ref[0]
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(e.input.Username + ":" + e.input.Password))
+	config, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			registry: map[string]string{"username": e.input.Username, "password": e.input.Password, "auth": auth},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   e.pod + "-registry",
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "act"},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: config},
+	}, nil
+}
+
+// Start waits for the containers of the pod to run and the services to be ready, then it streams the logs of the services
+func (e *KubernetesEnvironment) Start(_ bool) common.Executor {
+	return common.NewInfoExecutor("%skubernetes start pod=%s", logPrefix, e.pod).
+		Then(func(ctx context.Context) error {
+			sctx, cancel := context.WithTimeout(ctx, e.startTimeout)
+			defer cancel()
+			for {
+				pod, err := e.config.Client.CoreV1().Pods(e.config.Namespace).Get(sctx, e.pod, metav1.GetOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to get the pod: %w", err)
+				}
+				if running, err := podRunning(pod); err != nil {
+					e.logProbeFailures(ctx)
+					return err
+				} else if running {
+					break
+				}
+				select {
+				case <-sctx.Done():
+					e.logProbeFailures(ctx)
+					return fmt.Errorf("the pod %s didn't get ready: %w", e.pod, sctx.Err())
+				case <-time.After(e.pollInterval):
+				}
+			}
+			for _, service := range e.services {
+				e.followLogs(ctx, service)
+			}
+			return nil
+		}).IfNot(common.Dryrun)
+}
+
+// logProbeFailures logs the failed readiness probes of the services, the kubelet reports their output by events
+func (e *KubernetesEnvironment) logProbeFailures(ctx context.Context) {
+	logger := common.Logger(ctx)
+	events, err := e.config.Client.CoreV1().Events(e.config.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.name=" + e.pod,
+	})
+	if err != nil {
+		logger.Debugf("failed to list the events of the pod %s: %v", e.pod, err)
+		return
+	}
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name == e.pod && event.Reason == "Unhealthy" {
+			logger.Errorf("%s: %s", event.InvolvedObject.FieldPath, strings.TrimSpace(event.Message))
+		}
+	}
+}
+
+// followLogs streams the logs of the container of a service to the writers of its input until the pod is deleted
+func (e *KubernetesEnvironment) followLogs(ctx context.Context, service *NewContainerInput) {
+	if service.Stdout == nil {
+		return
+	}
+	name := kubernetesServiceContainer(service)
+	logs, err := e.config.Client.CoreV1().Pods(e.config.Namespace).GetLogs(e.pod, &corev1.PodLogOptions{Container: name, Follow: true}).Stream(ctx)
+	if err != nil {
+		common.Logger(ctx).Warnf("failed to follow the logs of the service %s: %v", name, err)
+		return
+	}
+	go func() {
+		defer logs.Close()
+		if _, err := io.Copy(service.Stdout, logs); err != nil && !errors.Is(err, context.Canceled) {
+			common.Logger(ctx).Debugf("Stopped following the logs of the service %s: %v", name, err)
+		}
+	}()
+}
+
+// podRunning returns whether all containers of the pod run and are ready, or an error if one of them can't start
+func podRunning(pod *corev1.Pod) (bool, error) {
+	switch pod.Status.Phase {
+	case corev1.PodFailed, corev1.PodSucceeded:
+		return false, fmt.Errorf("the pod %s has stopped: %s %s", pod.Name, pod.Status.Reason, pod.Status.Message)
+	case corev1.PodRunning:
+	default:
+		// the containers are waiting for their images
+	}
+	running := pod.Status.Phase == corev1.PodRunning && len(pod.Status.ContainerStatuses) == len(pod.Spec.Containers)
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil {
+			switch waiting.Reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CrashLoopBackOff", "CreateContainerConfigError", "CreateContainerError":
+				return false, fmt.Errorf("the container %s of the pod %s can't start: %s %s", status.Name, pod.Name, waiting.Reason, waiting.Message)
+			}
+		}
+		if terminated := status.State.Terminated; terminated != nil {
+			return false, fmt.Errorf("the container %s of the pod %s has stopped: %s %s", status.Name, pod.Name, terminated.Reason, terminated.Message)
+		}
+		running = running && status.State.Running != nil && status.Ready
+	}
+	return running, nil
+}
+
+// run runs a command in the job container
+func (e *KubernetesEnvironment) run(ctx context.Context, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	exec := e.config.Exec
+	if exec == nil {
+		return errors.New("the kubernetes engine has no exec")
+	}
+	return exec(ctx, e.config.Namespace, e.pod, kubernetesJobContainer, command, stdin, stdout, stderr)
+}
+
+func (e *KubernetesEnvironment) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	return common.NewPipelineExecutor(
+		common.NewInfoExecutor("%skubernetes exec cmd=[%s] user=%s workdir=%s", logPrefix, strings.Join(command, " "), user, workdir),
+		e.exec(command, env, user, workdir),
+	).IfNot(common.Dryrun)
+}
+
+func (e *KubernetesEnvironment) exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		if user != "" && user != "0" && user != "root" {
+			logger.Debugf("Running as the user of the container instead of %s, kubernetes can't exec as another user", user)
+		}
+		wd := e.input.WorkingDir
+		if workdir != "" {
+			if strings.HasPrefix(workdir, "/") {
+				wd = workdir
+			} else {
+				wd = fmt.Sprintf("%s/%s", e.input.WorkingDir, workdir)
+			}
+		}
+		if wd == "" {
+			wd = "/"
+		}
+		logger.Debugf("Working directory '%s'", wd)
+
+		// the exec of kubernetes has neither env nor working directory, a script passed over stdin sets them since the
+		// arguments of an exec are visible to everyone who may see the requests of the cluster
+		args := append([]string{"/bin/sh", "-s", "--"}, command...)
+		script := strings.NewReader(kubernetesExecScript(env, wd))
+
+		err := e.run(ctx, args, script, e.input.Stdout, e.input.Stderr)
+		var exitErr interface{ ExitStatus() int }
+		if errors.As(err, &exitErr) {
+			switch exitErr.ExitStatus() {
+			case 127:
+				return fmt.Errorf("exitcode '%d': command not found, please refer to https://github.com/nektos/act/issues/107 for more information", exitErr.ExitStatus())
+			default:
+				return fmt.Errorf("exitcode '%d': failure", exitErr.ExitStatus())
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to exec: %w", err)
+		}
+		return nil
+	}
+}
+
+// kubernetesShellName matches the names of the variables a shell can export
+var kubernetesShellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// kubernetesExecScript returns the script which runs its arguments in workdir with the env added to the one of the
+// container. The variables whose names a shell can't export are added by env(1).
+func kubernetesExecScript(env map[string]string, workdir string) string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	script := &strings.Builder{}
+	fmt.Fprintf(script, "cd %s || exit 1\n", shellquote.Join(workdir))
+	others := []string{}
+	for _, k := range keys {
+		if kubernetesShellName.MatchString(k) {
+			fmt.Fprintf(script, "export %s=%s\n", k, shellquote.Join(env[k]))
+		} else {
+			others = append(others, shellquote.Join(k+"="+env[k]))
+		}
+	}
+	if len(others) > 0 {
+		fmt.Fprintf(script, "exec env %s \"$@\"\n", strings.Join(others, " "))
+	} else {
+		script.WriteString("exec \"$@\"\n")
+	}
+	return script.String()
+}
+
+func (e *KubernetesEnvironment) CopyTarStream(ctx context.Context, destPath string, tarStream io.Reader) error {
+	stderr := &bytes.Buffer{}
+	err := e.run(ctx, []string{"/bin/sh", "-c", `mkdir -p "$0" && tar -xf - -C "$0"`, destPath}, tarStream, io.Discard, stderr)
+	if err != nil {
+		return fmt.Errorf("failed to copy content to the pod: %w %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (e *KubernetesEnvironment) Copy(destPath string, files ...*FileEntry) common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, file := range files {
+			hdr := &tar.Header{
+				Name: file.Name,
+				Mode: int64(file.Mode),
+				Size: int64(len(file.Body)),
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := tw.Write([]byte(file.Body)); err != nil {
+				return err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return e.CopyTarStream(ctx, destPath, &buf)
+	}).IfNot(common.Dryrun)
+}
+
+// CopyDir streams the directory to the pod by CopyTarStream, like kubectl cp
+func (e *KubernetesEnvironment) CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor {
+	return common.NewPipelineExecutor(
+		common.NewInfoExecutor("%skubernetes cp src=%s dst=%s", logPrefix, srcPath, destPath),
+		func(ctx context.Context) error {
+			logger := common.Logger(ctx)
+			srcPrefix := filepath.Dir(srcPath)
+			if !strings.HasSuffix(srcPrefix, string(filepath.Separator)) {
+				srcPrefix += string(filepath.Separator)
+			}
+			var ignorer gitignore.Matcher
+			if useGitIgnore {
+				ps, err := gitignore.ReadPatterns(polyfill.New(osfs.New(srcPath)), nil)
+				if err != nil {
+					logger.Debugf("Error loading .gitignore: %v", err)
+				}
+				ignorer = gitignore.NewMatcher(ps)
+			}
+
+			reader, writer := io.Pipe()
+			go func() {
+				tw := tar.NewWriter(writer)
+				fc := &filecollector.FileCollector{
+					Fs:        &filecollector.DefaultFs{},
+					Ignorer:   ignorer,
+					SrcPath:   srcPath,
+					SrcPrefix: srcPrefix,
+					Handler: &filecollector.TarCollector{
+						TarWriter: tw,
+					},
+				}
+				err := filepath.Walk(srcPath, fc.CollectFiles(ctx, []string{}))
+				if err == nil {
+					err = tw.Close()
+				}
+				writer.CloseWithError(err)
+			}()
+			err := e.CopyTarStream(ctx, destPath, reader)
+			// unblock the walk if the exec failed
+			reader.CloseWithError(err)
+			return err
+		},
+	).IfNot(common.Dryrun)
+}
+
+func (e *KubernetesEnvironment) GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	srcPath = path.Clean(srcPath)
+	if err := e.run(ctx, []string{"tar", "-cf", "-", "-C", path.Dir(srcPath), path.Base(srcPath)}, nil, stdout, stderr); err != nil {
+		return nil, fmt.Errorf("failed to copy content from the pod: %w %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return io.NopCloser(stdout), nil
+}
+
+func (e *KubernetesEnvironment) UpdateFromEnv(srcPath string, env *map[string]string) common.Executor {
+	return parseEnvFile(e, srcPath, env).IfNot(common.Dryrun)
+}
+
+// UpdateFromImageEnv adds the env of the image, which the main process of the job container got from the kubelet
+func (e *KubernetesEnvironment) UpdateFromImageEnv(env *map[string]string) common.Executor {
+	envMap := *env
+	return common.Executor(func(ctx context.Context) error {
+		stdout := &bytes.Buffer{}
+		if err := e.run(ctx, []string{"cat", "/proc/1/environ"}, nil, stdout, io.Discard); err != nil {
+			return fmt.Errorf("read image env: %w", err)
+		}
+		for _, kv := range strings.Split(stdout.String(), "\x00") {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			if k == "PATH" {
+				if envMap[k] == "" {
+					envMap[k] = v
+				} else {
+					envMap[k] += `:` + v
+				}
+			} else if envMap[k] == "" {
+				envMap[k] = v
+			}
+		}
+		return nil
+	}).IfNot(common.Dryrun)
+}
+
+// Remove deletes the pod and its registry secret
+func (e *KubernetesEnvironment) Remove() common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		gracePeriod := int64(0)
+		err := e.config.Client.CoreV1().Pods(e.config.Namespace).Delete(ctx, e.pod, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the pod: %w", err)
+		}
+		if e.input.Username != "" {
+			err := e.config.Client.CoreV1().Secrets(e.config.Namespace).Delete(ctx, e.pod+"-registry", metav1.DeleteOptions{})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete the registry secret: %w", err)
+			}
+		}
+		return nil
+	}).IfNot(common.Dryrun)
+}
+
+func (e *KubernetesEnvironment) Close() common.Executor {
+	return func(_ context.Context) error {
+		return nil
+	}
+}
+
+func (e *KubernetesEnvironment) ReplaceLogWriter(stdout io.Writer, stderr io.Writer) (io.Writer, io.Writer) {
+	out, err := e.input.Stdout, e.input.Stderr
+	e.input.Stdout, e.input.Stderr = stdout, stderr
+	return out, err
+}
+
+// GetHealth returns whether the containers of the pod run and the services are ready
+func (e *KubernetesEnvironment) GetHealth(ctx context.Context) Health {
+	pod, err := e.config.Client.CoreV1().Pods(e.config.Namespace).Get(ctx, e.pod, metav1.GetOptions{})
+	if err != nil {
+		common.Logger(ctx).Errorf("failed to query the pod %s: %v", e.pod, err)
+		return HealthUnHealthy
+	}
+	if running, err := podRunning(pod); err != nil {
+		return HealthUnHealthy
+	} else if !running {
+		return HealthStarting
+	}
+	return HealthHealthy
+}
@@ -0,0 +1,384 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+type kubernetesExitError int
+
+func (e kubernetesExitError) Error() string {
+	return "command terminated with non-zero exit code"
+}
+
+func (e kubernetesExitError) ExitStatus() int {
+	return int(e)
+}
+
+type kubernetesExecCall struct {
+	pod       string
+	container string
+	command   []string
+	stdin     []byte
+}
+
+func newTestKubernetesEnvironment(t *testing.T, exec KubernetesExecFunc, services ...*NewContainerInput) (*KubernetesEnvironment, *fake.Clientset, *bytes.Buffer) {
+	t.Helper()
+	client := fake.NewSimpleClientset()
+	stdout := &bytes.Buffer{}
+	env := NewKubernetesEnvironment(&KubernetesConfig{Client: client, Namespace: "ci", Exec: exec}, &NewContainerInput{
+		Name:           "act-Test-Job_1",
+		Image:          "node:20",
+		Entrypoint:     []string{"tail", "-f", "/dev/null"},
+		WorkingDir:     "/work",
+		Env:            []string{"RUNNER_OS=Linux"},
+		Binds:          []string{"/src:/work", "/cache"},
+		Mounts:         map[string]string{"act-toolcache": "/opt/hostedtoolcache"},
+		NetworkAliases: []string{"job"},
+		Platform:       "linux/arm64",
+		Stdout:         stdout,
+		Stderr:         stdout,
+	}, services...).(*KubernetesEnvironment)
+	env.pollInterval = time.Millisecond
+	return env, client, stdout
+}
+
+func TestKubernetesName(t *testing.T) {
+	assert.Equal(t, "act-test-job-1", kubernetesName("act-Test-Job_1"))
+	long := kubernetesName("act-" + strings.Repeat("a", 100))
+	assert.Len(t, long, 63)
+	assert.NotEqual(t, long, kubernetesName("act-"+strings.Repeat("a", 101)), "long names are told apart by their hash")
+}
+
+func TestKubernetesEnvironmentCreate(t *testing.T) {
+	exposedPorts, portBindings, err := nat.ParsePortSpecs([]string{"5432", "8080:80/udp"})
+	require.NoError(t, err)
+	env, client, _ := newTestKubernetesEnvironment(t, nil, &NewContainerInput{
+		Name:           "act-Test-Job_1-postgres",
+		Image:          "postgres:16",
+		Env:            []string{"POSTGRES_PASSWORD=secret"},
+		Mounts:         map[string]string{"act-toolcache": "/toolcache", "data": "/var/lib/postgresql/data"},
+		NetworkAliases: []string{"postgres"},
+		ExposedPorts:   exposedPorts,
+		PortBindings:   portBindings,
+	})
+
+	ctx := context.Background()
+	require.NoError(t, env.Pull(true)(ctx))
+	require.NoError(t, env.Create([]string{"SYS_PTRACE"}, nil)(ctx))
+
+	pod, err := client.CoreV1().Pods("ci").Get(ctx, "act-test-job-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux", "kubernetes.io/arch": "arm64"}, pod.Spec.NodeSelector)
+	assert.Equal(t, []corev1.HostAlias{{IP: "127.0.0.1", Hostnames: []string{"job", "postgres"}}}, pod.Spec.HostAliases)
+	assert.Empty(t, pod.Spec.ImagePullSecrets)
+
+	require.Len(t, pod.Spec.Containers, 2)
+	job := pod.Spec.Containers[0]
+	assert.Equal(t, "job", job.Name)
+	assert.Equal(t, "node:20", job.Image)
+	assert.Equal(t, corev1.PullAlways, job.ImagePullPolicy)
+	assert.Equal(t, []string{"tail", "-f", "/dev/null"}, job.Command)
+	assert.Equal(t, "/work", job.WorkingDir)
+	assert.Equal(t, []corev1.EnvVar{{Name: "RUNNER_OS", Value: "Linux"}}, job.Env)
+	assert.Equal(t, []corev1.Capability{"SYS_PTRACE"}, job.SecurityContext.Capabilities.Add)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "host-src", MountPath: "/work"},
+		{Name: "anonymous-cache", MountPath: "/cache"},
+		{Name: "act-toolcache", MountPath: "/opt/hostedtoolcache"},
+	}, job.VolumeMounts)
+
+	service := pod.Spec.Containers[1]
+	assert.Equal(t, "postgres", service.Name)
+	assert.Equal(t, "postgres:16", service.Image)
+	assert.Nil(t, service.SecurityContext.Capabilities, "the capabilities are the ones of the job container")
+	assert.Equal(t, []corev1.ContainerPort{
+		{ContainerPort: 5432, Protocol: corev1.ProtocolTCP},
+		{ContainerPort: 80, HostPort: 8080, Protocol: corev1.ProtocolUDP},
+	}, service.Ports)
+	require.NotNil(t, service.ReadinessProbe, "a service without health check is ready once it listens on its TCP port")
+	assert.Equal(t, intstr.FromInt32(5432), service.ReadinessProbe.TCPSocket.Port)
+	assert.Nil(t, job.ReadinessProbe)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "act-toolcache", MountPath: "/toolcache"},
+		{Name: "data", MountPath: "/var/lib/postgresql/data"},
+	}, service.VolumeMounts)
+
+	assert.Equal(t, []corev1.Volume{
+		{Name: "act-toolcache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: "anonymous-cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: "host-src", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/src"}}},
+	}, pod.Spec.Volumes, "the containers share the volumes of the same name")
+
+	// an existing pod is reused
+	require.NoError(t, env.Create(nil, nil)(ctx))
+
+	require.NoError(t, env.Remove()(ctx))
+	_, err = client.CoreV1().Pods("ci").Get(ctx, "act-test-job-1", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestKubernetesEnvironmentRegistrySecret(t *testing.T) {
+	env, client, _ := newTestKubernetesEnvironment(t, nil)
+	env.input.Image = "ghcr.io/owner/image:1"
+	env.input.Username = "user"
+	env.input.Password = "token"
+
+	ctx := context.Background()
+	require.NoError(t, env.Create(nil, nil)(ctx))
+	pod, err := client.CoreV1().Pods("ci").Get(ctx, "act-test-job-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.PullIfNotPresent, pod.Spec.Containers[0].ImagePullPolicy)
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "act-test-job-1-registry"}}, pod.Spec.ImagePullSecrets)
+	secret, err := client.CoreV1().Secrets("ci").Get(ctx, "act-test-job-1-registry", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.SecretTypeDockerConfigJson, secret.Type)
+	assert.Contains(t, string(secret.Data[corev1.DockerConfigJsonKey]), `"ghcr.io"`)
+
+	require.NoError(t, env.Remove()(ctx))
+	_, err = client.CoreV1().Secrets("ci").Get(ctx, "act-test-job-1-registry", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestKubernetesEnvironmentStart(t *testing.T) {
+	ctx := context.Background()
+	setStatus := func(t *testing.T, client *fake.Clientset, phase corev1.PodPhase, state corev1.ContainerState) {
+		pod, err := client.CoreV1().Pods("ci").Get(ctx, "act-test-job-1", metav1.GetOptions{})
+		require.NoError(t, err)
+		pod.Status.Phase = phase
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "job", State: state, Ready: state.Running != nil}}
+		_, err = client.CoreV1().Pods("ci").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		require.NoError(t, err)
+	}
+
+	t.Run("running", func(t *testing.T) {
+		env, client, _ := newTestKubernetesEnvironment(t, nil)
+		require.NoError(t, env.Create(nil, nil)(ctx))
+		assert.Equal(t, HealthStarting, env.GetHealth(ctx))
+
+		started := make(chan error)
+		go func() {
+			started <- env.Start(false)(ctx)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		setStatus(t, client, corev1.PodRunning, corev1.ContainerState{Running: &corev1.ContainerStateRunning{}})
+		select {
+		case err := <-started:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the pod didn't start")
+		}
+		assert.Equal(t, HealthHealthy, env.GetHealth(ctx))
+	})
+
+	t.Run("image pull error", func(t *testing.T) {
+		env, client, _ := newTestKubernetesEnvironment(t, nil)
+		require.NoError(t, env.Create(nil, nil)(ctx))
+		setStatus(t, client, corev1.PodPending, corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"}})
+		assert.ErrorContains(t, env.Start(false)(ctx), "ImagePullBackOff not found")
+		assert.Equal(t, HealthUnHealthy, env.GetHealth(ctx))
+	})
+}
+
+func TestKubernetesEnvironmentServices(t *testing.T) {
+	ctx := context.Background()
+	newEnv := func(t *testing.T) (*KubernetesEnvironment, *fake.Clientset, *bytes.Buffer) {
+		serviceOutput := &bytes.Buffer{}
+		env, client, _ := newTestKubernetesEnvironment(t, nil, &NewContainerInput{
+			Name:           "act-Test-Job_1-postgres",
+			Image:          "postgres:16",
+			NetworkAliases: []string{"postgres"},
+			Options:        `--health-cmd "pg_isready -U postgres" --health-interval 10s --health-timeout 500ms --health-retries 5 --cpus 2`,
+			Stdout:         serviceOutput,
+			Stderr:         serviceOutput,
+		})
+		require.NoError(t, env.Create(nil, nil)(ctx))
+		return env, client, serviceOutput
+	}
+	setStatus := func(t *testing.T, client *fake.Clientset, ready bool) {
+		pod, err := client.CoreV1().Pods("ci").Get(ctx, "act-test-job-1", metav1.GetOptions{})
+		require.NoError(t, err)
+		running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{Name: "job", State: running, Ready: true},
+			{Name: "postgres", State: running, Ready: ready},
+		}
+		_, err = client.CoreV1().Pods("ci").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		require.NoError(t, err)
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		env, client, serviceOutput := newEnv(t)
+		pod, err := client.CoreV1().Pods("ci").Get(ctx, "act-test-job-1", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, &corev1.Probe{
+			ProbeHandler:     corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", "pg_isready -U postgres"}}},
+			PeriodSeconds:    10,
+			TimeoutSeconds:   1,
+			FailureThreshold: 5,
+		}, pod.Spec.Containers[1].ReadinessProbe)
+
+		setStatus(t, client, false)
+		assert.Equal(t, HealthStarting, env.GetHealth(ctx), "the service isn't ready yet")
+		setStatus(t, client, true)
+		require.NoError(t, env.Start(false)(ctx))
+		assert.Eventually(t, func() bool {
+			return strings.Contains(serviceOutput.String(), "fake logs")
+		}, 5*time.Second, 10*time.Millisecond, "the logs of the service are streamed to its output")
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		env, client, _ := newEnv(t)
+		env.startTimeout = 50 * time.Millisecond
+		setStatus(t, client, false)
+		_, err := client.CoreV1().Events("ci").Create(ctx, &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "act-test-job-1.unhealthy"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "act-test-job-1", FieldPath: "spec.containers{postgres}"},
+			Reason:         "Unhealthy",
+			Message:        "Readiness probe failed: /var/run/postgresql:5432 - no response",
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		logger := logrus.New()
+		logger.SetOutput(buf)
+		err = env.Start(false)(common.WithLogger(ctx, logger))
+		assert.ErrorContains(t, err, "didn't get ready")
+		assert.Contains(t, buf.String(), "spec.containers{postgres}: Readiness probe failed: /var/run/postgresql:5432 - no response")
+	})
+}
+
+func TestKubernetesEnvironmentExec(t *testing.T) {
+	calls := []kubernetesExecCall{}
+	exec := func(_ context.Context, namespace, pod, container string, command []string, stdin io.Reader, stdout, _ io.Writer) error {
+		assert.Equal(t, "ci", namespace)
+		call := kubernetesExecCall{pod: pod, container: container, command: command}
+		if stdin != nil {
+			var err error
+			if call.stdin, err = io.ReadAll(stdin); err != nil {
+				return err
+			}
+		}
+		calls = append(calls, call)
+		switch command[len(command)-1] {
+		case "fail":
+			return kubernetesExitError(3)
+		case "missing":
+			return kubernetesExitError(127)
+		case "/proc/1/environ":
+			_, err := stdout.Write([]byte("PATH=/usr/bin\x00IMAGE=1\x00RUNNER_OS=image\x00"))
+			return err
+		}
+		_, err := stdout.Write([]byte("ok\n"))
+		return err
+	}
+	env, _, stdout := newTestKubernetesEnvironment(t, exec)
+	ctx := context.Background()
+
+	require.NoError(t, env.Exec([]string{"node", "--version"}, map[string]string{"B": "2", "A": "1"}, "", "sub")(ctx))
+	assert.Equal(t, "ok\n", stdout.String())
+	require.Len(t, calls, 1)
+	assert.Equal(t, "act-test-job-1", calls[0].pod)
+	assert.Equal(t, "job", calls[0].container)
+	// the env is passed over stdin rather than as arguments
+	assert.Equal(t, []string{"/bin/sh", "-s", "--", "node", "--version"}, calls[0].command)
+	assert.Equal(t, "cd /work/sub || exit 1\nexport A=1\nexport B=2\nexec \"$@\"\n", string(calls[0].stdin))
+
+	require.NoError(t, env.Exec([]string{"true"}, map[string]string{"INPUT_MY-INPUT": "it's secret"}, "", "/abs")(ctx))
+	assert.Equal(t, "cd /abs || exit 1\nexec env 'INPUT_MY-INPUT=it'\\''s secret' \"$@\"\n", string(calls[1].stdin))
+
+	assert.EqualError(t, env.Exec([]string{"fail"}, nil, "", "")(ctx), "exitcode '3': failure")
+	assert.ErrorContains(t, env.Exec([]string{"missing"}, nil, "", "")(ctx), "exitcode '127': command not found")
+
+	envMap := map[string]string{"PATH": "/opt/bin", "RUNNER_OS": "Linux"}
+	require.NoError(t, env.UpdateFromImageEnv(&envMap)(ctx))
+	assert.Equal(t, map[string]string{"PATH": "/opt/bin:/usr/bin", "IMAGE": "1", "RUNNER_OS": "Linux"}, envMap)
+
+	exec = func(context.Context, string, string, string, []string, io.Reader, io.Writer, io.Writer) error {
+		return errors.New("connection refused")
+	}
+	env.config.Exec = exec
+	assert.ErrorContains(t, env.Exec([]string{"true"}, nil, "", "")(ctx), "failed to exec: connection refused")
+}
+
+func TestKubernetesEnvironmentCopy(t *testing.T) {
+	var command []string
+	var stdin []byte
+	exec := func(_ context.Context, _, _, _ string, cmd []string, in io.Reader, stdout, _ io.Writer) error {
+		command = cmd
+		if in != nil {
+			var err error
+			stdin, err = io.ReadAll(in)
+			return err
+		}
+		// GetContainerArchive
+		tw := tar.NewWriter(stdout)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "env", Mode: 0o644, Size: 11}))
+		_, err := tw.Write([]byte("NAME=value\n"))
+		require.NoError(t, err)
+		return tw.Close()
+	}
+	readTar := func(t *testing.T, data []byte) map[string]string {
+		files := map[string]string{}
+		tr := tar.NewReader(bytes.NewReader(data))
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				return files
+			}
+			require.NoError(t, err)
+			body, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			files[hdr.Name] = string(body)
+		}
+	}
+	env, _, _ := newTestKubernetesEnvironment(t, exec)
+	ctx := context.Background()
+
+	require.NoError(t, env.Copy("/var/run/act/", &FileEntry{Name: "workflow/event.json", Mode: 0o644, Body: "{}"})(ctx))
+	assert.Equal(t, []string{"/bin/sh", "-c", `mkdir -p "$0" && tar -xf - -C "$0"`, "/var/run/act/"}, command)
+	assert.Equal(t, map[string]string{"workflow/event.json": "{}"}, readTar(t, stdin))
+
+	src := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "dir"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "dir", "file"), []byte("content"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "ignored"), []byte("ignored"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ".gitignore"), []byte("ignored\n"), 0o644))
+	require.NoError(t, env.CopyDir("/work", src+"/.", true)(ctx))
+	assert.Equal(t, "/work", command[3])
+	assert.Equal(t, map[string]string{"dir/file": "content", ".gitignore": "ignored\n"}, readTar(t, stdin))
+
+	archive, err := env.GetContainerArchive(ctx, "/tmp/env")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tar", "-cf", "-", "-C", "/tmp", "env"}, command)
+	data, err := io.ReadAll(archive)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "NAME=value\n"}, readTar(t, data))
+
+	envMap := map[string]string{}
+	require.NoError(t, env.UpdateFromEnv("/tmp/env", &envMap)(ctx))
+	assert.Equal(t, map[string]string{"NAME": "value"}, envMap)
+}
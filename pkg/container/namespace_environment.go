@@ -0,0 +1,524 @@
+//go:build linux
+
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// NamespaceEnvironment runs the steps in a root filesystem unpacked from an OCI image layout, isolated by Linux namespaces
+// instead of a container of a daemon. The sandbox is set up by unshare(1), which maps the user to root of a new user
+// namespace, so neither a daemon nor privileges are needed. The steps share the network of the host.
+type NamespaceEnvironment struct {
+	LinuxContainerEnvironmentExtensions
+	input *NewContainerInput
+	// dir keeps the root filesystem of the job, the named volumes are shared by the jobs in the parent of dir
+	dir string
+	pid int
+	// env is the env of the image and the job, the env of the steps is added to it
+	env []string
+}
+
+// NewNamespaceEnvironment creates an environment, which keeps its files in dir
+func NewNamespaceEnvironment(input *NewContainerInput, dir string) ExecutionsEnvironment {
+	return &NamespaceEnvironment{
+		input: input,
+		dir:   filepath.Join(dir, input.Name),
+	}
+}
+
+// the script of the sandbox binds the root filesystem of the host and the binds of the job, prints its pid in the
+// namespace of the host and waits to be killed, the steps enter its namespaces by nsenter(1)
+const namespaceSandboxScript = `set -e
+read -r pid _ < /proc/self/stat
+root=$1
+shift
+mount --rbind /dev "$root/dev"
+mount --rbind /sys "$root/sys"
+mount -t proc proc "$root/proc"
+while [ $# -gt 0 ]; do
+	mount --rbind "$1" "$2"
+	if [ "$3" = ro ]; then
+		mount -o remount,bind,ro "$2"
+	fi
+	shift 3
+done
+echo "$pid"
+exec sleep infinity
+`
+
+func (e *NamespaceEnvironment) rootfs() string {
+	return filepath.Join(e.dir, "rootfs")
+}
+
+func (e *NamespaceEnvironment) Pull(_ bool) common.Executor {
+	return func(_ context.Context) error {
+		layout, err := parseOCILayout(e.input.Image)
+		if err != nil {
+			return err
+		}
+		_, err = layout.manifest(e.input.Platform)
+		return err
+	}
+}
+
+func (e *NamespaceEnvironment) Create(_ []string, _ []string) common.Executor {
+	return common.NewInfoExecutor("%snamespaces create image=%s platform=%s", logPrefix, e.input.Image, e.input.Platform).
+		Then(func(ctx context.Context) error {
+			if _, err := os.Stat(e.rootfs()); err == nil {
+				common.Logger(ctx).Debugf("Reusing the root filesystem %s", e.rootfs())
+				return nil
+			}
+			layout, err := parseOCILayout(e.input.Image)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(e.rootfs(), 0o755); err != nil {
+				return err
+			}
+			config, err := layout.unpack(e.rootfs(), e.input.Platform)
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(config)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(e.dir, "config.json"), data, 0o644); err != nil {
+				return err
+			}
+			// the sandbox shares the network of the host
+			for _, name := range []string{"/etc/resolv.conf", "/etc/hosts"} {
+				if data, err := os.ReadFile(name); err == nil {
+					if err := e.writeFile(name, data, 0o644); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}).IfNot(common.Dryrun)
+}
+
+func (e *NamespaceEnvironment) Start(_ bool) common.Executor {
+	return common.NewInfoExecutor("%snamespaces run image=%s", logPrefix, e.input.Image).
+		Then(func(ctx context.Context) error {
+			e.kill()
+			config, err := e.imageConfig()
+			if err != nil {
+				return err
+			}
+			e.env = append(append([]string{}, config.Env...), e.input.Env...)
+			unshare, err := exec.LookPath("unshare")
+			if err != nil {
+				return fmt.Errorf("the namespaces engine needs unshare of util-linux: %w", err)
+			}
+			args := []string{"--user", "--map-root-user", "--mount", "--pid", "--fork", "--kill-child", "--propagation", "private",
+				"sh", "-c", namespaceSandboxScript, "sh", e.rootfs()}
+			for _, dir := range []string{"/dev", "/sys", "/proc"} {
+				if err := os.MkdirAll(filepath.Join(e.rootfs(), dir), 0o755); err != nil {
+					return err
+				}
+			}
+			binds, err := e.binds()
+			if err != nil {
+				return err
+			}
+			for _, b := range binds {
+				target, err := e.prepareBindTarget(b.source, b.target)
+				if err != nil {
+					return err
+				}
+				mode := "rw"
+				if b.readOnly {
+					mode = "ro"
+				}
+				args = append(args, b.source, target, mode)
+			}
+
+			cmd := exec.Command(unshare, args...)
+			stderr := &bytes.Buffer{}
+			cmd.Stderr = stderr
+			stdout, err := cmd.StdoutPipe()
+			if err != nil {
+				return err
+			}
+			if err := cmd.Start(); err != nil {
+				return err
+			}
+			line, err := bufio.NewReader(stdout).ReadString('\n')
+			if err != nil {
+				_ = cmd.Wait()
+				return fmt.Errorf("failed to start the sandbox: %s", strings.TrimSpace(stderr.String()))
+			}
+			if e.pid, err = strconv.Atoi(strings.TrimSpace(line)); err != nil {
+				return err
+			}
+			// reap unshare, which exits with the sandbox
+			go func() {
+				_ = cmd.Wait()
+			}()
+			common.Logger(ctx).Debugf("Started the sandbox %d", e.pid)
+			return os.WriteFile(filepath.Join(e.dir, "pid"), []byte(strconv.Itoa(e.pid)), 0o644)
+		}).IfNot(common.Dryrun)
+}
+
+// kill stops the sandbox and all the processes in it
+func (e *NamespaceEnvironment) kill() {
+	if e.pid == 0 {
+		if data, err := os.ReadFile(filepath.Join(e.dir, "pid")); err == nil {
+			e.pid, _ = strconv.Atoi(string(data))
+		}
+	}
+	// the pid may have been reused if the sandbox of a reused environment is gone
+	if cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", e.pid)); e.pid != 0 && err == nil && bytes.HasPrefix(cmdline, []byte("sleep\x00infinity")) {
+		_ = syscall.Kill(e.pid, syscall.SIGKILL)
+	}
+	e.pid = 0
+	_ = os.Remove(filepath.Join(e.dir, "pid"))
+}
+
+type namespaceBind struct {
+	source   string
+	target   string
+	readOnly bool
+}
+
+// binds returns the binds and the named volumes of the job as binds, the volumes are directories shared by the jobs
+func (e *NamespaceEnvironment) binds() ([]namespaceBind, error) {
+	binds := []namespaceBind{}
+	for i, bind := range e.input.Binds {
+		parts := strings.Split(bind, ":")
+		b := namespaceBind{source: parts[0]}
+		switch len(parts) {
+		case 1:
+			// an anonymous volume
+			b.source = filepath.Join(e.dir, "volumes", strconv.Itoa(i))
+			b.target = parts[0]
+			if err := os.MkdirAll(b.source, 0o755); err != nil {
+				return nil, err
+			}
+		default:
+			b.target = parts[1]
+			if len(parts) > 2 {
+				b.readOnly = strings.Contains(","+parts[2]+",", ",ro,")
+			}
+		}
+		binds = append(binds, b)
+	}
+	for name, target := range e.input.Mounts {
+		source := filepath.Join(e.volumesDir(), name)
+		if err := os.MkdirAll(source, 0o755); err != nil {
+			return nil, err
+		}
+		binds = append(binds, namespaceBind{source: source, target: target})
+	}
+	// the parents are bound first
+	sort.Slice(binds, func(i, j int) bool {
+		return path.Clean(binds[i].target) < path.Clean(binds[j].target)
+	})
+	return binds, nil
+}
+
+func (e *NamespaceEnvironment) volumesDir() string {
+	return filepath.Join(filepath.Dir(e.dir), "volumes")
+}
+
+// prepareBindTarget creates the target of a bind inside of the root filesystem, without following symlinks outside of it
+func (e *NamespaceEnvironment) prepareBindTarget(source, target string) (string, error) {
+	hostTarget, err := securejoin.SecureJoin(e.rootfs(), target)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		// like docker, a missing source of a bind is created as directory
+		return hostTarget, errors.Join(os.MkdirAll(source, 0o755), os.MkdirAll(hostTarget, 0o755))
+	} else if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		if err := os.MkdirAll(filepath.Dir(hostTarget), 0o755); err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(hostTarget); errors.Is(err, fs.ErrNotExist) {
+			return hostTarget, os.WriteFile(hostTarget, nil, 0o644)
+		}
+		return hostTarget, nil
+	}
+	return hostTarget, os.MkdirAll(hostTarget, 0o755)
+}
+
+// hostPath resolves a path of the sandbox to the path on the host, the paths inside of binds resolve to their sources
+func (e *NamespaceEnvironment) hostPath(p string) (string, error) {
+	p = path.Clean("/" + filepath.ToSlash(p))
+	binds, err := e.binds()
+	if err != nil {
+		return "", err
+	}
+	var match *namespaceBind
+	for i, b := range binds {
+		target := path.Clean(b.target)
+		if (p == target || strings.HasPrefix(p, strings.TrimSuffix(target, "/")+"/")) && (match == nil || len(target) > len(path.Clean(match.target))) {
+			match = &binds[i]
+		}
+	}
+	if match != nil {
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, path.Clean(match.target)), "/")
+		return securejoin.SecureJoin(match.source, rel)
+	}
+	return securejoin.SecureJoin(e.rootfs(), p)
+}
+
+func (e *NamespaceEnvironment) writeFile(name string, data []byte, perm fs.FileMode) error {
+	target, err := e.hostPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	// the file may be a symlink of the image, like /etc/resolv.conf of systemd-resolved
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(target, data, perm)
+}
+
+func (e *NamespaceEnvironment) Copy(destPath string, files ...*FileEntry) common.Executor {
+	return func(_ context.Context) error {
+		for _, f := range files {
+			if err := e.writeFile(path.Join(destPath, f.Name), []byte(f.Body), fs.FileMode(f.Mode)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (e *NamespaceEnvironment) CopyTarStream(ctx context.Context, destPath string, tarStream io.Reader) error {
+	target, err := e.hostPath(destPath)
+	if err != nil {
+		return err
+	}
+	return (&HostEnvironment{}).CopyTarStream(ctx, target, tarStream)
+}
+
+func (e *NamespaceEnvironment) CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor {
+	return func(ctx context.Context) error {
+		target, err := e.hostPath(destPath)
+		if err != nil {
+			return err
+		}
+		return (&HostEnvironment{}).CopyDir(target, srcPath, useGitIgnore)(ctx)
+	}
+}
+
+func (e *NamespaceEnvironment) GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error) {
+	target, err := e.hostPath(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	return (&HostEnvironment{}).GetContainerArchive(ctx, target)
+}
+
+func (e *NamespaceEnvironment) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	return common.NewPipelineExecutor(
+		common.NewInfoExecutor("%snamespaces exec cmd=[%s] user=%s workdir=%s", logPrefix, strings.Join(command, " "), user, workdir),
+		e.exec(command, env, user, workdir),
+	).IfNot(common.Dryrun)
+}
+
+func (e *NamespaceEnvironment) exec(command []string, env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		if e.pid == 0 {
+			return fmt.Errorf("the sandbox of %s isn't running", e.input.Name)
+		}
+		if user != "" && user != "0" && user != "root" {
+			logger.Debugf("Running as root instead of %s, the sandbox only maps the user of the host", user)
+		}
+		nsenter, err := exec.LookPath("nsenter")
+		if err != nil {
+			return fmt.Errorf("the namespaces engine needs nsenter of util-linux: %w", err)
+		}
+		chroot, err := exec.LookPath("chroot")
+		if err != nil {
+			return fmt.Errorf("the namespaces engine needs chroot: %w", err)
+		}
+
+		wd := e.input.WorkingDir
+		if workdir != "" {
+			if strings.HasPrefix(workdir, "/") {
+				wd = workdir
+			} else {
+				wd = fmt.Sprintf("%s/%s", e.input.WorkingDir, workdir)
+			}
+		}
+		if wd == "" {
+			wd = "/"
+		}
+		logger.Debugf("Working directory '%s'", wd)
+
+		envMap := map[string]string{}
+		for _, kv := range e.env {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				envMap[k] = v
+			}
+		}
+		for k, v := range env {
+			envMap[k] = v
+		}
+		if envMap["PATH"] == "" {
+			envMap["PATH"] = e.DefaultPathVariable()
+		}
+
+		// chroot runs in the mount namespace of the sandbox, so it sees the binds
+		args := append([]string{nsenter, "--target", strconv.Itoa(e.pid), "--user", "--mount", "--pid",
+			chroot, e.rootfs(), "/bin/sh", "-c", `cd "$0" && exec "$@"`, wd}, command...)
+		cmd := exec.CommandContext(ctx, nsenter)
+		cmd.Args = args
+		cmd.Env = getEnvListFromMap(envMap)
+		cmd.Stdout = e.input.Stdout
+		cmd.Stderr = e.input.Stderr
+		// nsenter forks into the pid namespace, kill the whole group on cancellation
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error {
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+
+		err = cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			switch exitErr.ExitCode() {
+			case 127:
+				return fmt.Errorf("exitcode '%d': command not found, please refer to https://github.com/nektos/act/issues/107 for more information", exitErr.ExitCode())
+			default:
+				return fmt.Errorf("exitcode '%d': failure", exitErr.ExitCode())
+			}
+		} else if err != nil {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("this step has been cancelled: %w", err)
+			default:
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (e *NamespaceEnvironment) UpdateFromEnv(srcPath string, env *map[string]string) common.Executor {
+	return parseEnvFile(e, srcPath, env).IfNot(common.Dryrun)
+}
+
+func (e *NamespaceEnvironment) imageConfig() (*specs.ImageConfig, error) {
+	data, err := os.ReadFile(filepath.Join(e.dir, "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read image config: %w", err)
+	}
+	config := &specs.ImageConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unmarshal image config: %w", err)
+	}
+	return config, nil
+}
+
+func (e *NamespaceEnvironment) UpdateFromImageEnv(env *map[string]string) common.Executor {
+	envMap := *env
+	return common.Executor(func(_ context.Context) error {
+		config, err := e.imageConfig()
+		if err != nil {
+			return err
+		}
+		for _, kv := range config.Env {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			if k == "PATH" {
+				if envMap[k] == "" {
+					envMap[k] = v
+				} else {
+					envMap[k] += `:` + v
+				}
+			} else if envMap[k] == "" {
+				envMap[k] = v
+			}
+		}
+		return nil
+	}).IfNot(common.Dryrun)
+}
+
+// Remove stops the sandbox and removes the root filesystem and the volumes of the job
+func (e *NamespaceEnvironment) Remove() common.Executor {
+	return common.Executor(func(_ context.Context) error {
+		e.kill()
+		for name := range e.input.Mounts {
+			if strings.HasPrefix(name, e.input.Name) {
+				if err := removeAllWritable(filepath.Join(e.volumesDir(), name)); err != nil {
+					return err
+				}
+			}
+		}
+		return removeAllWritable(e.dir)
+	}).IfNot(common.Dryrun)
+}
+
+// removeAllWritable removes the directory like os.RemoveAll, after making the directories of the image writable
+func removeAllWritable(dir string) error {
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(p, 0o700)
+		}
+		return nil
+	})
+	return os.RemoveAll(dir)
+}
+
+// Close stops the sandbox, a reused environment starts a new one on the root filesystem kept on the disk
+func (e *NamespaceEnvironment) Close() common.Executor {
+	return func(_ context.Context) error {
+		e.kill()
+		return nil
+	}
+}
+
+func (e *NamespaceEnvironment) ReplaceLogWriter(stdout io.Writer, stderr io.Writer) (io.Writer, io.Writer) {
+	out, err := e.input.Stdout, e.input.Stderr
+	e.input.Stdout, e.input.Stderr = stdout, stderr
+	return out, err
+}
+
+func (e *NamespaceEnvironment) GetHealth(_ context.Context) Health {
+	return HealthHealthy
+}
+
+func (e *NamespaceEnvironment) GetRunnerContext(_ context.Context) map[string]interface{} {
+	return map[string]interface{}{
+		"os":         "Linux",
+		"arch":       goArchToActionArch(runtime.GOARCH),
+		"temp":       "/tmp",
+		"tool_cache": "/opt/hostedtoolcache",
+	}
+}
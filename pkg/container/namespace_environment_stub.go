@@ -0,0 +1,8 @@
+//go:build !linux
+
+package container
+
+// NewNamespaceEnvironment returns nil, the namespaces engine needs Linux
+func NewNamespaceEnvironment(_ *NewContainerInput, _ string) ExecutionsEnvironment {
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build linux
+
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceEnvironment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	if err := exec.Command("unshare", "--user", "--map-root-user", "--mount", "--pid", "--fork", "true").Run(); err != nil {
+		t.Skipf("user namespaces aren't available: %v", err)
+	}
+
+	// the image borrows the programs of the host
+	layout := t.TempDir()
+	writeTestLayout(t, layout, "host", specs.ImageConfig{Env: []string{"PATH=/usr/local/bin:/usr/bin:/bin", "IMAGE_ENV=image"}},
+		[]testLayoutEntry{
+			{header: &tar.Header{Name: "bin", Typeflag: tar.TypeSymlink, Linkname: "usr/bin"}},
+			{header: &tar.Header{Name: "lib", Typeflag: tar.TypeSymlink, Linkname: "usr/lib"}},
+			{header: &tar.Header{Name: "lib64", Typeflag: tar.TypeSymlink, Linkname: "usr/lib64"}},
+			{header: &tar.Header{Name: "tmp/", Typeflag: tar.TypeDir, Mode: 0o1777}},
+		},
+	)
+	workdir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workdir, "input"), []byte("from the host"), 0o644))
+
+	stdout := &bytes.Buffer{}
+	dir := t.TempDir()
+	env := NewNamespaceEnvironment(&NewContainerInput{
+		Name:       "act-test",
+		Image:      "oci:" + layout + ":host",
+		WorkingDir: "/work",
+		Env:        []string{"JOB_ENV=job"},
+		Binds:      []string{"/usr:/usr:ro", workdir + ":/work"},
+		Mounts:     map[string]string{"act-test-env": "/var/run/act"},
+		Stdout:     stdout,
+		Stderr:     stdout,
+	}, dir)
+
+	ctx := context.Background()
+	require.NoError(t, env.Pull(false)(ctx))
+	require.NoError(t, env.Create(nil, nil)(ctx))
+	require.NoError(t, env.Start(false)(ctx))
+	defer func() {
+		assert.NoError(t, env.Remove()(ctx))
+		assert.NoDirExists(t, filepath.Join(dir, "act-test"))
+		assert.NoDirExists(t, filepath.Join(dir, "volumes", "act-test-env"))
+	}()
+
+	t.Run("exec", func(t *testing.T) {
+		stdout.Reset()
+		require.NoError(t, env.Exec([]string{"sh", "-c", `echo "$PWD $(id -u) $IMAGE_ENV $JOB_ENV $STEP_ENV $(cat input)"; echo out > output`}, map[string]string{"STEP_ENV": "step"}, "", "")(ctx))
+		assert.Equal(t, "/work 0 image job step from the host\n", stdout.String())
+		content, err := os.ReadFile(filepath.Join(workdir, "output"))
+		require.NoError(t, err)
+		assert.Equal(t, "out\n", string(content), "the bound working directory is the one of the host")
+
+		err = env.Exec([]string{"sh", "-c", "exit 3"}, nil, "", "")(ctx)
+		assert.ErrorContains(t, err, "exitcode '3': failure")
+
+		// the sandbox has its own processes
+		stdout.Reset()
+		require.NoError(t, env.Exec([]string{"sh", "-c", "ls /proc | grep -c '^[0-9]'"}, nil, "", "/")(ctx))
+		assert.NotEqual(t, "", stdout.String())
+		assert.Less(t, len(stdout.String()), 4)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		require.NoError(t, env.Copy("/var/run/act/", &FileEntry{Name: "workflow/event.json", Mode: 0o644, Body: "{}"})(ctx))
+		stdout.Reset()
+		require.NoError(t, env.Exec([]string{"cat", "/var/run/act/workflow/event.json"}, nil, "", "")(ctx))
+		assert.Equal(t, "{}", stdout.String())
+
+		require.NoError(t, env.Exec([]string{"sh", "-c", "echo NAME=value > /tmp/env"}, nil, "", "")(ctx))
+		archive, err := env.GetContainerArchive(ctx, "/tmp/env")
+		require.NoError(t, err)
+		defer archive.Close()
+		tr := tar.NewReader(archive)
+		_, err = tr.Next()
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		assert.Equal(t, "NAME=value\n", string(content))
+
+		envMap := map[string]string{}
+		require.NoError(t, env.UpdateFromEnv("/tmp/env", &envMap)(ctx))
+		require.NoError(t, env.UpdateFromImageEnv(&envMap)(ctx))
+		assert.Equal(t, map[string]string{"NAME": "value", "PATH": "/usr/local/bin:/usr/bin:/bin", "IMAGE_ENV": "image"}, envMap)
+	})
+}
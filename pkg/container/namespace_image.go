@@ -0,0 +1,263 @@
+//go:build linux
+
+package container
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/klauspost/compress/zstd"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ociLayout is an image in an OCI image layout directory, like skopeo copy docker://node:20 oci:./images:node-20 creates it
+type ociLayout struct {
+	dir string
+	ref string
+}
+
+// parseOCILayout parses oci:DIR[:REF] or the path of a layout directory, the ref is the org.opencontainers.image.ref.name
+// of the image in the layout and may be omitted if the layout has a single image
+func parseOCILayout(image string) (*ociLayout, error) {
+	if rest, ok := strings.CutPrefix(image, "oci:"); ok {
+		dir, ref, _ := strings.Cut(rest, ":")
+		return &ociLayout{dir: dir, ref: ref}, nil
+	}
+	if _, err := os.Stat(filepath.Join(image, specs.ImageLayoutFile)); err == nil {
+		return &ociLayout{dir: image}, nil
+	}
+	return nil, fmt.Errorf("image '%s' isn't an OCI image layout, copy it to one like skopeo copy docker://%s oci:DIR:REF and use oci:DIR:REF as image", image, image)
+}
+
+func (l *ociLayout) readBlob(desc specs.Descriptor, v interface{}) error {
+	data, err := os.ReadFile(l.blobPath(desc))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (l *ociLayout) blobPath(desc specs.Descriptor) string {
+	return filepath.Join(l.dir, "blobs", desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+}
+
+// manifest returns the manifest of the image for the platform, like linux/amd64, or the platform of the host if empty
+func (l *ociLayout) manifest(platform string) (*specs.Manifest, error) {
+	index := &specs.Index{}
+	data, err := os.ReadFile(filepath.Join(l.dir, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse the index of '%s': %w", l.dir, err)
+	}
+
+	var desc *specs.Descriptor
+	for i, m := range index.Manifests {
+		if l.ref == "" && len(index.Manifests) == 1 || m.Annotations[specs.AnnotationRefName] == l.ref {
+			desc = &index.Manifests[i]
+			break
+		}
+	}
+	if desc == nil {
+		return nil, fmt.Errorf("image '%s' not found in '%s'", l.ref, l.dir)
+	}
+
+	if desc.MediaType == specs.MediaTypeImageIndex {
+		nested := &specs.Index{}
+		if err := l.readBlob(*desc, nested); err != nil {
+			return nil, err
+		}
+		if desc = selectPlatform(nested.Manifests, platform); desc == nil {
+			return nil, fmt.Errorf("image '%s' in '%s' has no manifest for the platform '%s'", l.ref, l.dir, platform)
+		}
+	}
+
+	manifest := &specs.Manifest{}
+	if err := l.readBlob(*desc, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func selectPlatform(manifests []specs.Descriptor, platform string) *specs.Descriptor {
+	goos, goarch := runtime.GOOS, runtime.GOARCH
+	if platform != "" {
+		goos, goarch, _ = strings.Cut(platform, "/")
+		goarch, _, _ = strings.Cut(goarch, "/")
+	}
+	for i, m := range manifests {
+		if m.Platform != nil && m.Platform.OS == goos && m.Platform.Architecture == goarch {
+			return &manifests[i]
+		}
+	}
+	return nil
+}
+
+// unpack extracts the layers of the image into the root filesystem and returns the config of the image
+func (l *ociLayout) unpack(rootfs, platform string) (*specs.ImageConfig, error) {
+	manifest, err := l.manifest(platform)
+	if err != nil {
+		return nil, err
+	}
+	image := &specs.Image{}
+	if err := l.readBlob(manifest.Config, image); err != nil {
+		return nil, err
+	}
+	for _, layer := range manifest.Layers {
+		if err := l.unpackLayer(rootfs, layer); err != nil {
+			return nil, fmt.Errorf("failed to unpack layer %s: %w", layer.Digest, err)
+		}
+	}
+	return &image.Config, nil
+}
+
+func (l *ociLayout) unpackLayer(rootfs string, layer specs.Descriptor) error {
+	file, err := os.Open(l.blobPath(layer))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	switch {
+	case strings.HasSuffix(layer.MediaType, "+gzip") || strings.HasSuffix(layer.MediaType, ".gzip"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	case strings.HasSuffix(layer.MediaType, "+zstd"):
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		reader = zr
+	}
+	return extractLayer(rootfs, tar.NewReader(reader))
+}
+
+// extractLayer applies a layer to the root filesystem, the files are owned by the user, which is root in the sandbox.
+// Device nodes are skipped since the sandbox binds /dev of the host.
+func extractLayer(rootfs string, tr *tar.Reader) error {
+	// an opaque whiteout only hides the files of the lower layers
+	extracted := map[string]bool{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name := path.Clean("/" + hdr.Name)
+		dir, base := path.Split(name)
+
+		if base == ".wh..wh..opq" {
+			target, err := securejoin.SecureJoin(rootfs, dir)
+			if err != nil {
+				return err
+			}
+			entries, err := os.ReadDir(target)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			for _, entry := range entries {
+				if !extracted[path.Join(dir, entry.Name())] {
+					if err := os.RemoveAll(filepath.Join(target, entry.Name())); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		if hidden, ok := strings.CutPrefix(base, ".wh."); ok {
+			target, err := layerPath(rootfs, path.Join(dir, hidden))
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+			continue
+		}
+
+		target, err := layerPath(rootfs, name)
+		if err != nil {
+			return err
+		}
+		extracted[name] = true
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		mode := hdr.FileInfo().Mode()
+		if hdr.Typeflag != tar.TypeDir {
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			// the user has to be able to extract the next layers and to remove the root filesystem
+			if err := os.Chmod(target, mode|0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tr)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			if err := os.Chmod(target, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			source, err := securejoin.SecureJoin(rootfs, hdr.Linkname)
+			if err != nil {
+				return err
+			}
+			if err := os.Link(source, target); err != nil {
+				return err
+			}
+		case tar.TypeFifo:
+			if err := syscall.Mkfifo(target, uint32(mode.Perm())); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// layerPath resolves the parent of the file inside of the root filesystem, the file itself may be a symlink which is replaced
+func layerPath(rootfs, name string) (string, error) {
+	dir, err := securejoin.SecureJoin(rootfs, path.Dir(name))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path.Base(name)), nil
+}
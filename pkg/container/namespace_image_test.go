@@ -0,0 +1,123 @@
+//go:build linux
+
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLayoutEntry struct {
+	header *tar.Header
+	body   string
+}
+
+func writeTestBlob(t *testing.T, dir, mediaType string, data []byte) specs.Descriptor {
+	t.Helper()
+	d := digest.FromBytes(data)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "blobs", d.Algorithm().String()), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blobs", d.Algorithm().String(), d.Encoded()), data, 0o644))
+	return specs.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data))}
+}
+
+func writeTestJSONBlob(t *testing.T, dir, mediaType string, v interface{}) specs.Descriptor {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return writeTestBlob(t, dir, mediaType, data)
+}
+
+// writeTestLayout writes an OCI image layout with an image of the layers tagged with ref
+func writeTestLayout(t *testing.T, dir, ref string, config specs.ImageConfig, layers ...[]testLayoutEntry) {
+	t.Helper()
+	manifest := specs.Manifest{
+		MediaType: specs.MediaTypeImageManifest,
+		Config:    writeTestJSONBlob(t, dir, specs.MediaTypeImageConfig, specs.Image{Config: config}),
+	}
+	manifest.SchemaVersion = 2
+	for _, entries := range layers {
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gz)
+		for _, entry := range entries {
+			entry.header.Size = int64(len(entry.body))
+			require.NoError(t, tw.WriteHeader(entry.header))
+			_, err := tw.Write([]byte(entry.body))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+		manifest.Layers = append(manifest.Layers, writeTestBlob(t, dir, specs.MediaTypeImageLayerGzip, buf.Bytes()))
+	}
+	desc := writeTestJSONBlob(t, dir, specs.MediaTypeImageManifest, manifest)
+	desc.Annotations = map[string]string{specs.AnnotationRefName: ref}
+	index := specs.Index{Manifests: []specs.Descriptor{desc}}
+	index.SchemaVersion = 2
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), data, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, specs.ImageLayoutFile), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o644))
+}
+
+func TestOCILayoutUnpack(t *testing.T) {
+	dir := t.TempDir()
+	writeTestLayout(t, dir, "latest", specs.ImageConfig{Env: []string{"PATH=/usr/bin", "IMAGE=1"}},
+		[]testLayoutEntry{
+			{header: &tar.Header{Name: "etc/", Typeflag: tar.TypeDir, Mode: 0o555}},
+			{header: &tar.Header{Name: "etc/os-release", Typeflag: tar.TypeReg, Mode: 0o444}, body: "lower"},
+			{header: &tar.Header{Name: "etc/removed", Typeflag: tar.TypeReg, Mode: 0o644}, body: "removed"},
+			{header: &tar.Header{Name: "opt/old/file", Typeflag: tar.TypeReg, Mode: 0o644}, body: "old"},
+			{header: &tar.Header{Name: "bin/tool", Typeflag: tar.TypeReg, Mode: 0o755}, body: "#!/bin/sh"},
+			{header: &tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "/"}},
+		},
+		[]testLayoutEntry{
+			{header: &tar.Header{Name: "etc/os-release", Typeflag: tar.TypeReg, Mode: 0o644}, body: "upper"},
+			{header: &tar.Header{Name: "etc/.wh.removed", Typeflag: tar.TypeReg}},
+			{header: &tar.Header{Name: "opt/old/new", Typeflag: tar.TypeReg, Mode: 0o644}, body: "new"},
+			{header: &tar.Header{Name: "opt/old/.wh..wh..opq", Typeflag: tar.TypeReg}},
+			{header: &tar.Header{Name: "usr/bin/tool", Typeflag: tar.TypeLink, Linkname: "bin/tool"}},
+			{header: &tar.Header{Name: "escape/outside", Typeflag: tar.TypeReg, Mode: 0o644}, body: "inside"},
+		},
+	)
+
+	layout, err := parseOCILayout("oci:" + dir + ":latest")
+	require.NoError(t, err)
+	rootfs := filepath.Join(t.TempDir(), "rootfs")
+	config, err := layout.unpack(rootfs, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PATH=/usr/bin", "IMAGE=1"}, config.Env)
+
+	content, err := os.ReadFile(filepath.Join(rootfs, "etc/os-release"))
+	require.NoError(t, err)
+	assert.Equal(t, "upper", string(content))
+	assert.NoFileExists(t, filepath.Join(rootfs, "etc/removed"))
+	assert.NoFileExists(t, filepath.Join(rootfs, "opt/old/file"), "the opaque whiteout hides the lower layer")
+	assert.FileExists(t, filepath.Join(rootfs, "opt/old/new"), "the opaque whiteout keeps its own layer")
+	content, err = os.ReadFile(filepath.Join(rootfs, "usr/bin/tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh", string(content))
+	assert.FileExists(t, filepath.Join(rootfs, "outside"), "symlinks resolve inside of the root filesystem")
+
+	// the layout is found by its directory if it has a single image
+	layout, err = parseOCILayout(dir)
+	require.NoError(t, err)
+	_, err = layout.manifest("")
+	assert.NoError(t, err)
+
+	_, err = parseOCILayout("ubuntu:latest")
+	assert.ErrorContains(t, err, "skopeo copy docker://ubuntu:latest")
+	layout, err = parseOCILayout("oci:" + dir + ":other")
+	require.NoError(t, err)
+	_, err = layout.manifest("")
+	assert.ErrorContains(t, err, "image 'other' not found")
+}
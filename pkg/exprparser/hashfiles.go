@@ -0,0 +1,530 @@
+package exprparser
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ParseHashFilesArgs returns the patterns of the arguments of hashFiles and whether symbolic links are followed.
+// The first argument may be the option --follow-symbolic-links.
+func ParseHashFilesArgs(args []reflect.Value) ([]string, bool, error) {
+	patterns := []string{}
+	followSymlinks := false
+	for i, arg := range args {
+		if arg.Kind() != reflect.String {
+			return nil, false, fmt.Errorf("Non-string path passed to hashFiles")
+		}
+		s := arg.String()
+		if i == 0 && strings.HasPrefix(s, "--") {
+			if !strings.EqualFold(s, "--follow-symbolic-links") {
+				return nil, false, fmt.Errorf("Invalid glob option %s, available option: '--follow-symbolic-links'", s)
+			}
+			followSymlinks = true
+			continue
+		}
+		patterns = append(patterns, s)
+	}
+	return patterns, followSymlinks, nil
+}
+
+// HashFilesCache computes hashFiles like GitHub Actions and caches the digests of the hashed files.
+// A cached digest is reused as long as the size and modification time of the file don't change.
+// The zero value is not usable, use NewHashFilesCache; a nil cache hashes all files on every call.
+type HashFilesCache struct {
+	mu      sync.Mutex
+	digests map[string]fileDigest
+}
+
+type fileDigest struct {
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+}
+
+// NewHashFilesCache creates an empty HashFilesCache
+func NewHashFilesCache() *HashFilesCache {
+	return &HashFilesCache{
+		digests: map[string]fileDigest{},
+	}
+}
+
+// HashFiles returns the sha256 of the sha256 digests of all files inside of workspace which match the patterns,
+// or an empty string if no file matches.
+// The patterns use the syntax of @actions/glob, relative patterns are relative to workspace.
+// Directories named .git and directories ignored by a .gitignore file are skipped, unless they are
+// part of the literal prefix of a pattern.
+func (c *HashFilesCache) HashFiles(workspace string, patterns []string, followSymlinks bool) (string, error) {
+	workspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return "", err
+	}
+	g := &globber{
+		workspace:      splitPath(filepath.ToSlash(workspace)),
+		followSymlinks: followSymlinks,
+		ignores:        map[string][]gitignore.Pattern{},
+	}
+	for _, line := range patterns {
+		for _, p := range strings.Split(line, "\n") {
+			pattern, err := newGlobPattern(workspace, p)
+			if err != nil {
+				return "", err
+			}
+			if pattern != nil {
+				g.patterns = append(g.patterns, pattern)
+			}
+		}
+	}
+	g.addImplicitDescendants()
+
+	for _, searchPath := range g.searchPaths() {
+		if _, err := os.Lstat(searchPath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		if err := g.walk(searchPath, nil); err != nil {
+			return "", err
+		}
+	}
+	if len(g.files) == 0 {
+		return "", nil
+	}
+
+	sums, err := c.sums(g.files)
+	if err != nil {
+		return "", err
+	}
+	hasher := sha256.New()
+	for _, sum := range sums {
+		hasher.Write(sum[:])
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// sums hashes the files in parallel, the digests are returned in the order of the files
+func (c *HashFilesCache) sums(files []string) ([][sha256.Size]byte, error) {
+	sums := make([][sha256.Size]byte, len(files))
+	errs := make([]error, len(files))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				sums[i], errs[i] = c.sum(files[i])
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
+
+func (c *HashFilesCache) sum(file string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	fi, err := os.Stat(file)
+	if err != nil {
+		return sum, fmt.Errorf("Unable to stat '%s': %w", file, err)
+	}
+	if c != nil {
+		c.mu.Lock()
+		cached, ok := c.digests[file]
+		c.mu.Unlock()
+		if ok && cached.size == fi.Size() && cached.modTime.Equal(fi.ModTime()) {
+			return cached.sum, nil
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return sum, fmt.Errorf("Unable to os.Open: %w", err)
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return sum, fmt.Errorf("Unable to io.Copy: %w", err)
+	}
+	copy(sum[:], hasher.Sum(nil))
+
+	if c != nil {
+		c.mu.Lock()
+		c.digests[file] = fileDigest{size: fi.Size(), modTime: fi.ModTime(), sum: sum}
+		c.mu.Unlock()
+	}
+	return sum, nil
+}
+
+// globPattern is a pattern of @actions/glob
+type globPattern struct {
+	negate            bool
+	segments          []string // the segments of the absolute pattern
+	trailingSeparator bool     // the pattern only matches directories
+	searchPath        []string // the literal segments before the first glob segment
+}
+
+func newGlobPattern(workspace, line string) (*globPattern, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+	p := &globPattern{}
+	for strings.HasPrefix(line, "!") {
+		p.negate = !p.negate
+		line = line[1:]
+	}
+	line = filepath.ToSlash(line)
+	p.trailingSeparator = strings.HasSuffix(line, "/")
+
+	segments := splitPath(line)
+	for i, segment := range segments {
+		if literal, ok := globLiteral(segment); ok && (literal == ".." || (literal == "." && i > 0)) {
+			return nil, fmt.Errorf("Invalid pattern '%s'. Relative pathing '.' and '..' is not allowed.", line)
+		}
+	}
+	if !path.IsAbs(line) && !filepath.IsAbs(line) {
+		if len(segments) > 0 && segments[0] == "." {
+			segments = segments[1:]
+		}
+		root := splitPath(filepath.ToSlash(workspace))
+		for i := range root {
+			root[i] = globEscape(root[i])
+		}
+		segments = append(root, segments...)
+	}
+	p.segments = segments
+
+	for _, segment := range segments {
+		literal, ok := globLiteral(segment)
+		if !ok {
+			break
+		}
+		p.searchPath = append(p.searchPath, literal)
+	}
+	return p, nil
+}
+
+// match returns whether the pattern matches the file or directory
+func (p *globPattern) match(name []string) bool {
+	return matchSegments(p.segments, name, false)
+}
+
+// partialMatch returns whether descendants of the directory may match the pattern
+func (p *globPattern) partialMatch(name []string) bool {
+	return matchSegments(p.segments, name, true)
+}
+
+// matchSegments matches the path segments against the pattern segments like minimatch with the options dot, nobrace and noext.
+// If partial is set a path which matches the start of the pattern matches.
+// Like @actions/glob a trailing ** also matches the directory itself.
+func matchSegments(pattern, name []string, partial bool) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:], partial) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return partial
+		}
+		if !matchSegment(pattern[0], name[0]) {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func matchSegment(pattern, name string) bool {
+	matched, err := path.Match(strings.ReplaceAll(pattern, "[!", "[^"), name)
+	if err != nil {
+		// minimatch matches invalid patterns literally
+		literal, _ := globLiteral(pattern)
+		return literal == name
+	}
+	return matched
+}
+
+// globLiteral returns the unescaped segment if it doesn't contain a glob
+func globLiteral(segment string) (string, bool) {
+	literal := strings.Builder{}
+	for i := 0; i < len(segment); i++ {
+		switch segment[i] {
+		case '*', '?', '[':
+			return "", false
+		case '\\':
+			if runtime.GOOS != "windows" && i+1 < len(segment) {
+				i++
+			}
+		}
+		literal.WriteByte(segment[i])
+	}
+	return literal.String(), true
+}
+
+func globEscape(s string) string {
+	if runtime.GOOS == "windows" {
+		return s
+	}
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(s)
+}
+
+func splitPath(p string) []string {
+	segments := []string{}
+	for _, segment := range strings.Split(p, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func joinPath(segments []string) string {
+	return filepath.FromSlash("/" + strings.Join(segments, "/"))
+}
+
+func isPrefix(prefix, segments []string) bool {
+	if len(prefix) > len(segments) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// globber walks the search paths of the patterns like the globber of @actions/glob
+type globber struct {
+	workspace      []string // the segments of the workspace
+	followSymlinks bool
+	patterns       []*globPattern
+	ignores        map[string][]gitignore.Pattern // the patterns of the .gitignore file of a directory
+	files          []string
+}
+
+// addImplicitDescendants adds a pattern matching the descendants of every pattern, as a matched directory includes its content
+func (g *globber) addImplicitDescendants() {
+	patterns := []*globPattern{}
+	for _, p := range g.patterns {
+		patterns = append(patterns, p)
+		if p.trailingSeparator || len(p.segments) == 0 || p.segments[len(p.segments)-1] != "**" {
+			patterns = append(patterns, &globPattern{
+				negate:     p.negate,
+				segments:   append(append([]string{}, p.segments...), "**"),
+				searchPath: p.searchPath,
+			})
+		}
+	}
+	g.patterns = patterns
+}
+
+// searchPaths returns the search paths of the patterns which are not negated, omitting descendants of other search paths
+func (g *globber) searchPaths() []string {
+	result := []string{}
+	var included [][]string
+	for _, p := range g.patterns {
+		if p.negate {
+			continue
+		}
+		found := false
+		for _, other := range g.patterns {
+			if !other.negate && len(other.searchPath) < len(p.searchPath) && isPrefix(other.searchPath, p.searchPath) {
+				found = true
+				break
+			}
+		}
+		for _, other := range included {
+			if isPrefix(other, p.searchPath) && len(other) == len(p.searchPath) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			included = append(included, p.searchPath)
+			result = append(result, joinPath(p.searchPath))
+		}
+	}
+	return result
+}
+
+const (
+	matchDirectory = 1 << iota
+	matchFile
+	matchAll = matchDirectory | matchFile
+)
+
+// matchKind returns whether the patterns match the path as file and/or directory, the last matching pattern wins
+func (g *globber) matchKind(segments []string) int {
+	result := 0
+	for _, p := range g.patterns {
+		if !p.match(segments) {
+			continue
+		}
+		kind := matchAll
+		if p.trailingSeparator {
+			kind = matchDirectory
+		}
+		if p.negate {
+			result &^= kind
+		} else {
+			result |= kind
+		}
+	}
+	return result
+}
+
+func (g *globber) partialMatch(segments []string) bool {
+	for _, p := range g.patterns {
+		if !p.negate && p.partialMatch(segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// walk visits the file or directory, chain contains the real paths of the parent directories to detect cycles of symbolic links
+func (g *globber) walk(name string, chain []string) error {
+	segments := splitPath(filepath.ToSlash(name))
+	kind := g.matchKind(segments)
+	if kind == 0 && !g.partialMatch(segments) {
+		return nil
+	}
+
+	var fi os.FileInfo
+	var err error
+	if g.followSymlinks {
+		if fi, err = os.Stat(name); err != nil {
+			// broken symbolic links are omitted
+			if _, lerr := os.Lstat(name); lerr == nil && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+	} else if fi, err = os.Lstat(name); err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		if kind&matchFile != 0 && g.inWorkspace(segments) {
+			// symbolic links to directories which are not followed are neither files nor directories
+			if fi.Mode()&os.ModeSymlink != 0 {
+				if fi, err = os.Stat(name); err != nil {
+					return err
+				} else if fi.IsDir() {
+					return nil
+				}
+			}
+			g.files = append(g.files, name)
+		}
+		return nil
+	}
+	if g.skipDirectory(segments) {
+		return nil
+	}
+	if g.followSymlinks {
+		realPath, err := filepath.EvalSymlinks(name)
+		if err != nil {
+			return err
+		}
+		for _, parent := range chain {
+			if parent == realPath {
+				return nil
+			}
+		}
+		chain = append(chain, realPath)
+	}
+
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := g.walk(filepath.Join(name, entry.Name()), chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *globber) inWorkspace(segments []string) bool {
+	return len(segments) > len(g.workspace) && isPrefix(g.workspace, segments)
+}
+
+// skipDirectory returns whether the directory can't contain files of the workspace or is skipped as .git or gitignored directory.
+// Directories on a search path are never skipped.
+func (g *globber) skipDirectory(segments []string) bool {
+	for _, p := range g.patterns {
+		if !p.negate && isPrefix(segments, p.searchPath) {
+			return false
+		}
+	}
+	if !g.inWorkspace(segments) {
+		// only the workspace and its ancestors contain files of the workspace
+		return !isPrefix(segments, g.workspace)
+	}
+	if segments[len(segments)-1] == ".git" {
+		return true
+	}
+
+	// only the topmost ignored directory is skipped, the content of an ignored search path is hashed
+	parts := segments[len(g.workspace):]
+	patterns := []gitignore.Pattern{}
+	for i := range parts {
+		patterns = append(patterns, g.gitignore(parts[:i])...)
+	}
+	matcher := gitignore.NewMatcher(patterns)
+	return matcher.Match(parts, true) && (len(parts) == 1 || !matcher.Match(parts[:len(parts)-1], true))
+}
+
+// gitignore returns the patterns of the .gitignore file of a directory relative to the workspace
+func (g *globber) gitignore(domain []string) []gitignore.Pattern {
+	key := strings.Join(domain, "/")
+	if patterns, ok := g.ignores[key]; ok {
+		return patterns
+	}
+	patterns := []gitignore.Pattern{}
+	if f, err := os.Open(joinPath(append(append(append([]string{}, g.workspace...), domain...), ".gitignore"))); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+				patterns = append(patterns, gitignore.ParsePattern(line, domain))
+			}
+		}
+		f.Close()
+	}
+	g.ignores[key] = patterns
+	return patterns
+}
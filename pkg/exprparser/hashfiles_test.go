@@ -0,0 +1,108 @@
+package exprparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+}
+
+func TestHashFilesSkipsGitAndIgnoredDirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/main.go":                     "main",
+		".gitignore":                      "node_modules/\n",
+		".git/config":                     "config",
+		"node_modules/dep/index.js":       "dep",
+		"node_modules/dep/package.json":   "{}",
+		"src/node_modules/other/index.js": "other",
+	})
+	cache := NewHashFilesCache()
+
+	hashAll, err := cache.HashFiles(dir, []string{"**"}, false)
+	assert.NoError(t, err)
+	hashSources, err := cache.HashFiles(dir, []string{".gitignore", "src/main.go"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, hashSources, hashAll)
+
+	// ignored directories are hashed if they are part of the literal prefix of a pattern
+	hashDep, err := cache.HashFiles(dir, []string{"node_modules/dep/**"}, false)
+	assert.NoError(t, err)
+	hashDepFiles, err := cache.HashFiles(dir, []string{"node_modules/dep/index.js", "node_modules/dep/package.json"}, false)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hashDep)
+	assert.Equal(t, hashDepFiles, hashDep)
+}
+
+func TestHashFilesCache(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "a",
+	})
+	file := filepath.Join(dir, "a.txt")
+	cache := NewHashFilesCache()
+
+	hash, err := cache.HashFiles(dir, []string{"*.txt"}, false)
+	assert.NoError(t, err)
+	assert.Contains(t, cache.digests, file)
+
+	// the cached digest is used as long as size and modification time are unchanged
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.NoError(t, os.WriteFile(file, []byte("b"), 0o644))
+	assert.NoError(t, os.Chtimes(file, modTime, modTime))
+	cache.digests[file] = fileDigest{size: 1, modTime: modTime, sum: cache.digests[file].sum}
+	cached, err := cache.HashFiles(dir, []string{"*.txt"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, cached)
+
+	assert.NoError(t, os.Chtimes(file, time.Now(), time.Now()))
+	changed, err := cache.HashFiles(dir, []string{"*.txt"}, false)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+
+	uncached, err := (*HashFilesCache)(nil).HashFiles(dir, []string{"*.txt"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, changed, uncached)
+}
+
+func TestHashFilesInvalidPattern(t *testing.T) {
+	_, err := NewHashFilesCache().HashFiles(t.TempDir(), []string{"src/../*.go"}, false)
+	assert.EqualError(t, err, "Invalid pattern 'src/../*.go'. Relative pathing '.' and '..' is not allowed.")
+}
+
+func TestMatchSegments(t *testing.T) {
+	table := []struct {
+		pattern string
+		name    string
+		partial bool
+		matches bool
+	}{
+		{"a/*.go", "a/main.go", false, true},
+		{"a/*.go", "a/b/main.go", false, false},
+		{"a/**", "a", false, true},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**/b", "a", false, false},
+		{"a/**/b", "a", true, true},
+		{"a/b/*.go", "a/c", true, false},
+		{"*", ".hidden", false, true},
+		{"[!a]*", "abc", false, false},
+		{"[!a]*", "bc", false, true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, matchSegments(splitPath(tt.pattern), splitPath(tt.name), tt.partial))
+		})
+	}
+}
@@ -36,6 +36,8 @@ type Config struct {
 	Run        *model.Run
 	WorkingDir string
 	Context    string
+	// HashFilesCache caches the digests of the files hashed by hashFiles, it may be nil
+	HashFilesCache *HashFilesCache
 }
 
 type DefaultStatusCheck int
@@ -609,6 +611,11 @@ func (impl *interperterImpl) evaluateLogicalCompare(compareNode *actionlint.Logi
 
 //nolint:gocyclo
 func (impl *interperterImpl) evaluateFuncCall(funcCallNode *actionlint.FuncCallNode) (interface{}, error) {
+	// the arguments of case are evaluated lazily
+	if strings.EqualFold(funcCallNode.Callee, "case") {
+		return impl.evaluateCase(funcCallNode)
+	}
+
 	args := make([]reflect.Value, 0)
 
 	for _, arg := range funcCallNode.Args {
@@ -664,6 +671,31 @@ func (impl *interperterImpl) evaluateFuncCall(funcCallNode *actionlint.FuncCallN
 	case "cancelled":
 		return impl.cancelled()
 	default:
+		if fn, ok := lookupFunction(funcCallNode.Callee); ok {
+			return impl.callFunction(funcCallNode.Callee, fn, args)
+		}
 		return nil, fmt.Errorf("TODO: '%s' not implemented", funcCallNode.Callee)
 	}
 }
+
+// evaluateCase returns the value following the first predicate which is true or the last argument as default
+func (impl *interperterImpl) evaluateCase(funcCallNode *actionlint.FuncCallNode) (interface{}, error) {
+	args := funcCallNode.Args
+	if len(args) < 3 || len(args)%2 == 0 {
+		return nil, fmt.Errorf("case expects an odd number of at least 3 arguments (predicate, value, ..., default), got %d", len(args))
+	}
+	for i := 0; i < len(args)-1; i += 2 {
+		predicate, err := impl.evaluateNode(args[i])
+		if err != nil {
+			return nil, err
+		}
+		matched, ok := predicate.(bool)
+		if !ok {
+			return nil, fmt.Errorf("case: predicate %d must be a boolean, got %s", i/2+1, typeOf(reflect.ValueOf(predicate)))
+		}
+		if matched {
+			return impl.evaluateNode(args[i+1])
+		}
+	}
+	return impl.evaluateNode(args[len(args)-1])
+}
@@ -0,0 +1,187 @@
+package exprparser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/nektos/act/pkg/schema"
+)
+
+// ParamType is the type of a parameter of a Function
+type ParamType int
+
+const (
+	// ParamTypeAny accepts every value unchanged
+	ParamTypeAny ParamType = iota
+	// ParamTypeString accepts null, booleans, numbers and strings, they are converted to a string
+	ParamTypeString
+	// ParamTypeNumber accepts null, booleans, numbers and strings, they are converted to a float64
+	ParamTypeNumber
+	// ParamTypeBoolean accepts every value, it is converted to a bool by its truthiness
+	ParamTypeBoolean
+	// ParamTypeArray accepts arrays
+	ParamTypeArray
+	// ParamTypeObject accepts objects
+	ParamTypeObject
+)
+
+func (t ParamType) String() string {
+	switch t {
+	case ParamTypeString:
+		return "string"
+	case ParamTypeNumber:
+		return "number"
+	case ParamTypeBoolean:
+		return "boolean"
+	case ParamTypeArray:
+		return "array"
+	case ParamTypeObject:
+		return "object"
+	}
+	return "any"
+}
+
+// Function is an expression function which can be registered with RegisterFunction
+type Function struct {
+	// Params are the types of the parameters, the arguments are checked and converted before Call is called
+	Params []ParamType
+	// Optional is the number of trailing parameters which may be omitted
+	Optional int
+	// Variadic allows to pass the last parameter any number of times
+	Variadic bool
+	// Call is called with the converted arguments
+	Call func(args []interface{}) (interface{}, error)
+}
+
+func (f Function) arity() (int, int) {
+	minArgs := len(f.Params) - f.Optional
+	if minArgs < 0 {
+		minArgs = 0
+	}
+	if f.Variadic {
+		return minArgs, math.MaxInt32
+	}
+	return minArgs, len(f.Params)
+}
+
+var (
+	functionsMu sync.RWMutex
+	functions   = map[string]Function{}
+)
+
+var builtinFunctions = []string{
+	"contains", "startsWith", "endsWith", "format", "join", "toJSON", "fromJSON", "hashFiles",
+	"always", "success", "failure", "cancelled", "case",
+}
+
+// RegisterFunction makes a custom function available to all expressions, the name is case insensitive.
+// The builtin functions can't be replaced.
+func RegisterFunction(name string, fn Function) error {
+	for _, builtin := range builtinFunctions {
+		if strings.EqualFold(name, builtin) {
+			return fmt.Errorf("function '%s' is a builtin function", name)
+		}
+	}
+	if fn.Call == nil {
+		return fmt.Errorf("function '%s' has no implementation", name)
+	}
+
+	functionsMu.Lock()
+	defer functionsMu.Unlock()
+	functions[strings.ToLower(name)] = fn
+
+	minArgs, maxArgs := fn.arity()
+	schema.RegisterFunction(name, minArgs, maxArgs)
+	return nil
+}
+
+func lookupFunction(name string) (Function, bool) {
+	functionsMu.RLock()
+	defer functionsMu.RUnlock()
+	fn, ok := functions[strings.ToLower(name)]
+	return fn, ok
+}
+
+func (impl *interperterImpl) callFunction(name string, fn Function, args []reflect.Value) (interface{}, error) {
+	minArgs, maxArgs := fn.arity()
+	if len(args) < minArgs || len(args) > maxArgs {
+		if minArgs == maxArgs {
+			return nil, fmt.Errorf("%s expects %d arguments, got %d", name, minArgs, len(args))
+		}
+		return nil, fmt.Errorf("%s expects between %d and %d arguments, got %d", name, minArgs, maxArgs, len(args))
+	}
+
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		paramType := ParamTypeAny
+		if i < len(fn.Params) {
+			paramType = fn.Params[i]
+		} else if len(fn.Params) > 0 {
+			paramType = fn.Params[len(fn.Params)-1]
+		}
+		value, err := impl.convertArgument(arg, paramType)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d: %w", name, i+1, err)
+		}
+		converted[i] = value
+	}
+	return fn.Call(converted)
+}
+
+func (impl *interperterImpl) convertArgument(arg reflect.Value, paramType ParamType) (interface{}, error) {
+	switch paramType {
+	case ParamTypeString, ParamTypeNumber:
+		switch arg.Kind() {
+		case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Float64, reflect.String:
+		default:
+			return nil, fmt.Errorf("expected a %s, got %s", paramType, typeOf(arg))
+		}
+		if paramType == ParamTypeString {
+			return impl.coerceToString(arg).String(), nil
+		}
+		number := arg
+		if !impl.isNumber(number) {
+			number = impl.coerceToNumber(arg)
+		}
+		if number.Kind() == reflect.Int {
+			return float64(number.Int()), nil
+		}
+		return number.Float(), nil
+	case ParamTypeBoolean:
+		return IsTruthy(impl.getSafeValue(arg)), nil
+	case ParamTypeArray:
+		if arg.Kind() != reflect.Slice && arg.Kind() != reflect.Array {
+			return nil, fmt.Errorf("expected an array, got %s", typeOf(arg))
+		}
+	case ParamTypeObject:
+		if typeOf(arg) != "object" {
+			return nil, fmt.Errorf("expected an object, got %s", typeOf(arg))
+		}
+	}
+	return impl.getSafeValue(arg), nil
+}
+
+// typeOf returns the expression type of a value
+func typeOf(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return "null"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return "null"
+		}
+		return typeOf(value.Elem())
+	}
+	return "object"
+}
@@ -0,0 +1,78 @@
+package exprparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterFunction(t *testing.T) {
+	assert.NoError(t, RegisterFunction("repeat", Function{
+		Params:   []ParamType{ParamTypeString, ParamTypeNumber},
+		Optional: 1,
+		Call: func(args []interface{}) (interface{}, error) {
+			count := 2.0
+			if len(args) > 1 {
+				count = args[1].(float64)
+			}
+			return strings.Repeat(args[0].(string), int(count)), nil
+		},
+	}))
+	assert.NoError(t, RegisterFunction("sum", Function{
+		Params:   []ParamType{ParamTypeNumber},
+		Variadic: true,
+		Call: func(args []interface{}) (interface{}, error) {
+			sum := 0.0
+			for _, arg := range args {
+				sum += arg.(float64)
+			}
+			return sum, nil
+		},
+	}))
+	assert.NoError(t, RegisterFunction("keys", Function{
+		Params: []ParamType{ParamTypeObject},
+		Call: func(args []interface{}) (interface{}, error) {
+			return fmt.Sprint(args[0]), nil
+		},
+	}))
+
+	table := []struct {
+		input    string
+		expected interface{}
+		error    string
+	}{
+		{input: "repeat('ab') }}", expected: "abab"},
+		{input: "REPEAT(1, '3') }}", expected: "111"},
+		{input: "sum(1, 2, true, '3') }}", expected: 7.0},
+		{input: "keys(matrix) }}", expected: "map[os:linux]"},
+		{input: "repeat() }}", error: "repeat expects between 1 and 2 arguments, got 0"},
+		{input: "repeat(fromJSON('[]')) }}", error: "repeat: argument 1: expected a string, got array"},
+		{input: "keys(1) }}", error: "keys: argument 1: expected an object, got number"},
+	}
+
+	env := &EvaluationEnvironment{
+		Matrix: map[string]interface{}{"os": "linux"},
+	}
+	for _, tt := range table {
+		t.Run(tt.input, func(t *testing.T) {
+			output, err := NewInterpeter(env, Config{}).Evaluate(tt.input, DefaultStatusCheckNone)
+			if tt.error != "" {
+				assert.EqualError(t, err, tt.error)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, output)
+		})
+	}
+}
+
+func TestRegisterFunctionBuiltin(t *testing.T) {
+	err := RegisterFunction("toJson", Function{
+		Call: func(_ []interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	})
+	assert.EqualError(t, err, "function 'toJson' is a builtin function")
+}
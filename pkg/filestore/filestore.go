@@ -0,0 +1,194 @@
+// Package filestore provides the storages of the artifact and cache servers, like the local disk, memory,
+// S3 compatible object storages and WebDAV servers.
+package filestore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// WritableFile is a file opened for writing, its content is stored when it is closed
+type WritableFile interface {
+	io.WriteCloser
+}
+
+// WriteFS is a file system whose files can be written
+type WriteFS interface {
+	// OpenWritable opens the file truncated
+	OpenWritable(name string) (WritableFile, error)
+	// OpenAppendable opens the file to append to it, storages which can't append may stage the file until it is committed
+	OpenAppendable(name string) (WritableFile, error)
+}
+
+// RemoveFS is a WriteFS which can remove files and directories
+type RemoveFS interface {
+	// RemoveAll removes the file or the directory with all of its content, it doesn't fail if name doesn't exist
+	RemoveAll(name string) error
+}
+
+// Committer is implemented by storages which stage the files opened by OpenAppendable until they are committed,
+// the appended content can't be read before.
+type Committer interface {
+	// Commit stores the staged file name, or all staged files inside of it if it is a directory
+	Commit(name string) error
+}
+
+// Storage is a storage backend of the artifact and cache servers.
+// The names are slash separated paths relative to the root of the storage, like the names of fs.FS.
+type Storage interface {
+	fs.FS
+	WriteFS
+	RemoveFS
+}
+
+// Commit commits the staged files of name if the storage stages appended files
+func Commit(storage WriteFS, name string) error {
+	if committer, ok := storage.(Committer); ok {
+		return committer.Commit(name)
+	}
+	return nil
+}
+
+// Open opens the storage backend selected by the scheme of the url.
+// A path or a file:// url selects the local disk, memory:// an in-memory storage,
+// s3://{bucket}/{prefix} an S3 compatible storage and http(s)://{host}/{path} a WebDAV server.
+func Open(rawURL string) (Storage, error) {
+	if !strings.Contains(rawURL, "://") {
+		return NewLocal(rawURL), nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage url '%s': %w", rawURL, err)
+	}
+	switch u.Scheme {
+	case "file":
+		return NewLocal(filepath.FromSlash(u.Host + u.Path)), nil
+	case "memory":
+		return NewMemory(), nil
+	case "s3":
+		return NewS3(u)
+	case "http", "https":
+		return NewWebDAV(u)
+	}
+	return nil, fmt.Errorf("unsupported storage '%s', expected a path or a file://, memory://, s3://, http:// or https:// url", u.Scheme)
+}
+
+type localStorage struct {
+	root string
+}
+
+// NewLocal returns a Storage storing the files inside of the directory root
+func NewLocal(root string) Storage {
+	return &localStorage{root: root}
+}
+
+func (s *localStorage) path(name string) string {
+	return filepath.Join(s.root, filepath.FromSlash(name))
+}
+
+func (s *localStorage) Open(name string) (fs.File, error) {
+	return os.Open(s.path(name))
+}
+
+func (s *localStorage) OpenWritable(name string) (WritableFile, error) {
+	if err := os.MkdirAll(filepath.Dir(s.path(name)), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(s.path(name), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+}
+
+func (s *localStorage) OpenAppendable(name string) (WritableFile, error) {
+	if err := os.MkdirAll(filepath.Dir(s.path(name)), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(s.path(name), os.O_CREATE|os.O_RDWR, 0o644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *localStorage) RemoveAll(name string) error {
+	return os.RemoveAll(s.path(name))
+}
+
+type memoryStorage struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+// NewMemory returns a Storage keeping all files in memory, they are lost when the process exits
+func NewMemory() Storage {
+	return &memoryStorage{files: fstest.MapFS{}}
+}
+
+func (s *memoryStorage) Open(name string) (fs.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the written files are replaced and never modified, so open files keep their content
+	return s.files.Open(name)
+}
+
+func (s *memoryStorage) OpenWritable(name string) (WritableFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return &memoryFile{storage: s, name: name}, nil
+}
+
+func (s *memoryStorage) OpenAppendable(name string) (WritableFile, error) {
+	file, err := s.OpenWritable(name)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.files[name]; ok {
+		file.(*memoryFile).Write(existing.Data)
+	}
+	return file, nil
+}
+
+func (s *memoryStorage) RemoveAll(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for file := range s.files {
+		if name == "." || file == name || strings.HasPrefix(file, name+"/") {
+			delete(s.files, file)
+		}
+	}
+	return nil
+}
+
+// memoryFile stores its content when it is closed
+type memoryFile struct {
+	bytes.Buffer
+	storage *memoryStorage
+	name    string
+}
+
+func (f *memoryFile) Close() error {
+	f.storage.mu.Lock()
+	defer f.storage.mu.Unlock()
+	f.storage.files[f.name] = &fstest.MapFile{
+		Data:    bytes.Clone(f.Bytes()),
+		Mode:    0o644,
+		ModTime: time.Now(),
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package filestore
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// spooledFile spools the content to a temporary file and uploads it when it is closed, for storages which can't
+// stream uploads of an unknown size
+type spooledFile struct {
+	upload func(body io.Reader, size int64) error
+	spool  *os.File
+	closed bool
+}
+
+func newSpooledFile(upload func(body io.Reader, size int64) error) (*spooledFile, error) {
+	spool, err := os.CreateTemp("", "act-storage-")
+	if err != nil {
+		return nil, err
+	}
+	return &spooledFile{upload: upload, spool: spool}, nil
+}
+
+func (f *spooledFile) Write(p []byte) (int, error) {
+	return f.spool.Write(p)
+}
+
+func (f *spooledFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	defer f.discard()
+
+	size, err := f.spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return f.upload(f.spool, size)
+}
+
+func (f *spooledFile) discard() {
+	f.spool.Close()
+	os.Remove(f.spool.Name())
+}
+
+// uploadFunc uploads the content of a file of a remote storage
+type uploadFunc func(name string, body io.Reader, size int64) error
+
+// stagedFiles stages the files which are appended to on the local disk, for storages which can't append to files.
+// The existing content of a file is downloaded once when it is first appended to and the staged file is uploaded
+// once when it is committed, so a file uploaded in chunks isn't transferred again for every chunk.
+type stagedFiles struct {
+	mu    sync.Mutex
+	files map[string]*stagedFile
+}
+
+// stagedFile is locked while a chunk is appended or it is committed, so that concurrent writers don't interleave
+type stagedFile struct {
+	mu      sync.Mutex
+	spool   *os.File
+	removed bool
+}
+
+func (s *stagedFiles) openAppendable(storage fs.FS, name string) (WritableFile, error) {
+	s.mu.Lock()
+	if s.files == nil {
+		s.files = map[string]*stagedFile{}
+	}
+	staged, ok := s.files[name]
+	if !ok {
+		staged = &stagedFile{}
+		s.files[name] = staged
+	}
+	s.mu.Unlock()
+
+	staged.mu.Lock()
+	if staged.removed {
+		// committed or discarded while waiting for the lock
+		staged.mu.Unlock()
+		return s.openAppendable(storage, name)
+	}
+	if staged.spool == nil {
+		if err := staged.download(storage, name); err != nil {
+			s.take(name, staged)
+			staged.discard()
+			staged.mu.Unlock()
+			return nil, err
+		}
+	}
+	return &stagedWriter{staged: staged}, nil
+}
+
+// take removes the staged files of name and the files inside of it, or only file if it isn't nil
+func (s *stagedFiles) take(name string, file *stagedFile) map[string]*stagedFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	taken := map[string]*stagedFile{}
+	for n, staged := range s.files {
+		if (file == nil || staged == file) && (name == "." || n == name || strings.HasPrefix(n, name+"/")) {
+			taken[n] = staged
+			delete(s.files, n)
+		}
+	}
+	return taken
+}
+
+// commit uploads the staged files of name and the files inside of it
+func (s *stagedFiles) commit(name string, upload uploadFunc) error {
+	var err error
+	for n, staged := range s.take(name, nil) {
+		staged.mu.Lock()
+		err = errors.Join(err, staged.upload(n, upload))
+		staged.discard()
+		staged.mu.Unlock()
+	}
+	return err
+}
+
+// discard drops the staged files of name and the files inside of it, e.g. because they are overwritten or removed
+func (s *stagedFiles) discard(name string) {
+	for _, staged := range s.take(name, nil) {
+		staged.mu.Lock()
+		staged.discard()
+		staged.mu.Unlock()
+	}
+}
+
+func (f *stagedFile) download(storage fs.FS, name string) error {
+	spool, err := os.CreateTemp("", "act-storage-")
+	if err != nil {
+		return err
+	}
+	f.spool = spool
+	existing, err := storage.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer existing.Close()
+	_, err = io.Copy(spool, existing)
+	return err
+}
+
+func (f *stagedFile) upload(name string, upload uploadFunc) error {
+	size, err := f.spool.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := f.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return upload(name, f.spool, size)
+}
+
+func (f *stagedFile) discard() {
+	f.removed = true
+	if f.spool != nil {
+		f.spool.Close()
+		os.Remove(f.spool.Name())
+	}
+}
+
+// stagedWriter appends to a staged file, which stays locked until it is closed
+type stagedWriter struct {
+	staged *stagedFile
+	closed bool
+}
+
+func (w *stagedWriter) Write(p []byte) (int, error) {
+	return w.staged.spool.Write(p)
+}
+
+func (w *stagedWriter) Close() error {
+	if !w.closed {
+		w.closed = true
+		w.staged.mu.Unlock()
+	}
+	return nil
+}
+
+// remoteFileInfo is the file info of the files and directories of remote storages
+type remoteFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i *remoteFileInfo) Name() string       { return i.name }
+func (i *remoteFileInfo) Size() int64        { return i.size }
+func (i *remoteFileInfo) ModTime() time.Time { return i.modTime }
+func (i *remoteFileInfo) IsDir() bool        { return i.dir }
+func (i *remoteFileInfo) Sys() any           { return nil }
+func (i *remoteFileInfo) Type() fs.FileMode  { return i.Mode().Type() }
+
+func (i *remoteFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (i *remoteFileInfo) Info() (fs.FileInfo, error) {
+	return i, nil
+}
+
+type remoteFile struct {
+	io.ReadCloser
+	info remoteFileInfo
+}
+
+func (f *remoteFile) Stat() (fs.FileInfo, error) {
+	return &f.info, nil
+}
+
+type remoteDir struct {
+	info    remoteFileInfo
+	entries []fs.DirEntry
+}
+
+func (d *remoteDir) Stat() (fs.FileInfo, error) {
+	return &d.info, nil
+}
+
+func (d *remoteDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *remoteDir) Close() error {
+	return nil
+}
+
+func (d *remoteDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,339 @@
+package filestore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type s3Storage struct {
+	client       *http.Client
+	endpoint     *url.URL
+	bucket       string
+	prefix       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+
+	staged stagedFiles
+}
+
+// NewS3 returns a Storage storing the files in a bucket of an S3 compatible object storage.
+// The url has the form s3://{bucket}/{prefix}?endpoint={endpoint}&region={region}, requests use path style
+// addressing. The credentials are read from the user info of the url or the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables, requests are anonymous without credentials.
+func NewS3(u *url.URL) (Storage, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("s3 storage url '%s' has no bucket", u.Redacted())
+	}
+	s := &s3Storage{
+		client:       http.DefaultClient,
+		bucket:       u.Host,
+		prefix:       strings.Trim(u.Path, "/"),
+		region:       u.Query().Get("region"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if u.User != nil {
+		s.accessKey = u.User.Username()
+		s.secretKey, _ = u.User.Password()
+		s.sessionToken = ""
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_REGION")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+
+	endpoint := u.Query().Get("endpoint")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", s.region)
+	}
+	var err error
+	if s.endpoint, err = url.Parse(endpoint); err != nil || s.endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint '%s'", endpoint)
+	}
+	return s, nil
+}
+
+func (s *s3Storage) key(name string) string {
+	if name == "." {
+		return s.prefix
+	}
+	return strings.TrimPrefix(path.Join(s.prefix, name), "/")
+}
+
+func (s *s3Storage) do(method, key string, query url.Values, body io.Reader, contentLength int64) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = path.Join("/", s.endpoint.Path, s.bucket, key)
+	u.RawPath = awsURIEncode(u.Path, false)
+	u.RawQuery = awsCanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = contentLength
+	}
+	if s.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.sessionToken)
+	}
+	if s.accessKey != "" {
+		signAWSRequestV4(req, s.accessKey, s.secretKey, s.region, "s3", "UNSIGNED-PAYLOAD", time.Now())
+	}
+	return s.client.Do(req)
+}
+
+func s3Error(op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("s3 %s '%s' failed with status %d: %s", op, key, resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode == http.StatusNotFound {
+		return &fs.PathError{Op: op, Path: key, Err: fs.ErrNotExist}
+	}
+	return err
+}
+
+func (s *s3Storage) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name != "." {
+		resp, err := s.do(http.MethodGet, s.key(name), nil, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+			return &remoteFile{
+				ReadCloser: resp.Body,
+				info:       remoteFileInfo{name: path.Base(name), size: resp.ContentLength, modTime: modTime},
+			}, nil
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			return nil, s3Error("open", name, resp)
+		}
+	}
+
+	entries, err := s.list(name, "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &remoteDir{info: remoteFileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+type s3ListResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	CommonPrefixes []struct {
+		Prefix string
+	}
+}
+
+// list returns the entries of the directory, with an empty delimiter all files of the directory tree are returned
+func (s *s3Storage) list(name, delimiter string) ([]fs.DirEntry, error) {
+	prefix := s.key(name)
+	if prefix != "" {
+		prefix += "/"
+	}
+	entries := []fs.DirEntry{}
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, s3Error("list", name, resp)
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3 list '%s' returned an invalid response: %w", name, err)
+		}
+		for _, p := range result.CommonPrefixes {
+			entries = append(entries, &remoteFileInfo{name: strings.TrimSuffix(strings.TrimPrefix(p.Prefix, prefix), "/"), dir: true})
+		}
+		for _, c := range result.Contents {
+			entries = append(entries, &remoteFileInfo{name: strings.TrimPrefix(c.Key, prefix), size: c.Size, modTime: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func (s *s3Storage) put(name string, body io.Reader, size int64) error {
+	resp, err := s.do(http.MethodPut, s.key(name), nil, io.NopCloser(body), size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("put", name, resp)
+	}
+	return nil
+}
+
+func (s *s3Storage) OpenWritable(name string) (WritableFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	s.staged.discard(name)
+	return newSpooledFile(func(body io.Reader, size int64) error {
+		return s.put(name, body, size)
+	})
+}
+
+// OpenAppendable stages the object on the local disk until it is committed, objects can't be appended to
+func (s *s3Storage) OpenAppendable(name string) (WritableFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return s.staged.openAppendable(s, name)
+}
+
+func (s *s3Storage) Commit(name string) error {
+	return s.staged.commit(name, s.put)
+}
+
+func (s *s3Storage) RemoveAll(name string) error {
+	s.staged.discard(name)
+	entries, err := s.list(name, "")
+	if err != nil {
+		return err
+	}
+	keys := []string{s.key(name)}
+	for _, entry := range entries {
+		keys = append(keys, s.key(path.Join(name, entry.Name())))
+	}
+	for _, key := range keys {
+		resp, err := s.do(http.MethodDelete, key, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("s3 delete '%s' failed with status %d", key, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// signAWSRequestV4 signs the request with the AWS Signature Version 4, the host and all headers of the request are signed
+func signAWSRequestV4(req *http.Request, accessKey, secretKey, region, service, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	canonicalHeaders := strings.Builder{}
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// awsURIEncode encodes all characters except the unreserved characters of RFC 3986
+func awsURIEncode(s string, encodeSlash bool) string {
+	encoded := strings.Builder{}
+	for _, b := range []byte(s) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			encoded.WriteString("%" + strings.ToUpper(strconv.FormatUint(uint64(b)|0x100, 16)[1:]))
+		}
+	}
+	return encoded.String()
+}
+
+// awsCanonicalQuery returns the query sorted by name with encoded names and values
+func awsCanonicalQuery(query url.Values) string {
+	pairs := []string{}
+	for k, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
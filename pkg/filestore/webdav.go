@@ -0,0 +1,256 @@
+package filestore
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type webdavStorage struct {
+	client   *http.Client
+	base     *url.URL
+	username string
+	password string
+
+	// the collections which exist or were created
+	collections sync.Map
+	staged      stagedFiles
+}
+
+// NewWebDAV returns a Storage storing the files on a WebDAV server, http(s)://[user:password@]host/path.
+// Plain HTTP servers supporting GET, PUT and DELETE work as well, but their directories can't be listed.
+func NewWebDAV(u *url.URL) (Storage, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("webdav storage url '%s' has no host", u.Redacted())
+	}
+	base := *u
+	base.User = nil
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	base.RawPath = ""
+	s := &webdavStorage{client: http.DefaultClient, base: &base}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	return s, nil
+}
+
+func (s *webdavStorage) url(name string) string {
+	u := *s.base
+	if name != "." {
+		u.Path += "/" + name
+	}
+	return u.String()
+}
+
+func (s *webdavStorage) do(method, name string, header http.Header, body io.Reader, contentLength int64) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.url(name), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = contentLength
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	return s.client.Do(req)
+}
+
+func webdavError(op, name string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return fmt.Errorf("webdav %s '%s' failed with status %d: %s", op, name, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+type webdavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength int64  `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+const webdavPropfind = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/></prop></propfind>`
+
+// propfind returns the entries of a collection, it returns nil if name is a file or the server doesn't support WebDAV
+func (s *webdavStorage) propfind(name string) ([]fs.DirEntry, error) {
+	resp, err := s.do("PROPFIND", name, http.Header{"Depth": {"1"}, "Content-Type": {"application/xml"}}, strings.NewReader(webdavPropfind), int64(len(webdavPropfind)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusMultiStatus:
+	case http.StatusNotFound:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case http.StatusOK, http.StatusMethodNotAllowed, http.StatusNotImplemented, http.StatusBadRequest:
+		return nil, nil
+	default:
+		return nil, webdavError("propfind", name, resp)
+	}
+	var result webdavMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("webdav propfind '%s' returned an invalid response: %w", name, err)
+	}
+
+	dir := strings.TrimSuffix(s.base.Path+"/"+strings.TrimPrefix(name, "."), "/")
+	isDir := false
+	entries := []fs.DirEntry{}
+	for _, r := range result.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		p := strings.TrimSuffix(href.Path, "/")
+		info := remoteFileInfo{name: path.Base(p)}
+		for _, propstat := range r.Propstat {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+			info.dir = propstat.Prop.ResourceType.Collection != nil
+			info.size = propstat.Prop.ContentLength
+			info.modTime, _ = http.ParseTime(propstat.Prop.LastModified)
+		}
+		if p == dir {
+			isDir = info.dir
+			continue
+		}
+		if path.Dir(p) == dir {
+			entries = append(entries, &info)
+		}
+	}
+	if !isDir {
+		return nil, nil
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func (s *webdavStorage) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := s.propfind(name)
+	if err != nil {
+		return nil, err
+	}
+	if entries != nil {
+		return &remoteDir{info: remoteFileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+	}
+
+	resp, err := s.do(http.MethodGet, name, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, webdavError("open", name, resp)
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &remoteFile{
+		ReadCloser: resp.Body,
+		info:       remoteFileInfo{name: path.Base(name), size: resp.ContentLength, modTime: modTime},
+	}, nil
+}
+
+// mkcol creates the parent collections of name, failures are ignored since plain HTTP servers may create them on PUT
+func (s *webdavStorage) mkcol(name string) {
+	var parents []string
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		parents = append(parents, dir)
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		if _, ok := s.collections.Load(parents[i]); ok {
+			continue
+		}
+		resp, err := s.do("MKCOL", parents[i], nil, nil, 0)
+		if err != nil {
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusMethodNotAllowed {
+			s.collections.Store(parents[i], true)
+		}
+	}
+}
+
+func (s *webdavStorage) put(name string, body io.Reader, size int64) error {
+	s.mkcol(name)
+	resp, err := s.do(http.MethodPut, name, nil, io.NopCloser(body), size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return webdavError("put", name, resp)
+	}
+	return nil
+}
+
+func (s *webdavStorage) OpenWritable(name string) (WritableFile, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	s.staged.discard(name)
+	return newSpooledFile(func(body io.Reader, size int64) error {
+		return s.put(name, body, size)
+	})
+}
+
+// OpenAppendable stages the file on the local disk until it is committed, files can't be appended to
+func (s *webdavStorage) OpenAppendable(name string) (WritableFile, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return s.staged.openAppendable(s, name)
+}
+
+func (s *webdavStorage) Commit(name string) error {
+	return s.staged.commit(name, s.put)
+}
+
+func (s *webdavStorage) RemoveAll(name string) error {
+	s.staged.discard(name)
+	resp, err := s.do(http.MethodDelete, name, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return webdavError("delete", name, resp)
+	}
+	s.collections.Range(func(key, _ any) bool {
+		if dir := key.(string); dir == name || strings.HasPrefix(dir, name+"/") {
+			s.collections.Delete(key)
+		}
+		return true
+	})
+	return nil
+}
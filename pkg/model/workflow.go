@@ -6,6 +6,7 @@ import (
 	"io"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -23,6 +24,8 @@ type Workflow struct {
 	Env      map[string]string `yaml:"env"`
 	Jobs     map[string]*Job   `yaml:"jobs"`
 	Defaults Defaults          `yaml:"defaults"`
+
+	node *yaml.Node // the yaml the workflow was read from
 }
 
 // On events for the workflow
@@ -77,7 +80,20 @@ func (w *Workflow) UnmarshalYAML(node *yaml.Node) error {
 		return errors.Join(err, fmt.Errorf("Actions YAML Schema Validation Error detected:\nFor more information, see: https://nektosact.com/usage/schema.html"))
 	}
 	type WorkflowDefault Workflow
-	return node.Decode((*WorkflowDefault)(w))
+	if err := node.Decode((*WorkflowDefault)(w)); err != nil {
+		return err
+	}
+	w.node = node
+	return nil
+}
+
+// CheckExpressions statically checks the expressions of the jobs and steps before the workflow runs,
+// it reports unknown contexts and functions as errors and references to undefined step ids and needs as warnings
+func (w *Workflow) CheckExpressions() ([]string, error) {
+	if w.node == nil {
+		return nil, nil
+	}
+	return schema.CheckWorkflowExpressions(w.node)
 }
 
 type WorkflowDispatchInput struct {
@@ -92,6 +108,52 @@ type WorkflowDispatch struct {
 	Inputs map[string]WorkflowDispatchInput `yaml:"inputs"`
 }
 
+// Coerce converts value to the type declared by the input
+func (i WorkflowDispatchInput) Coerce(value interface{}) (interface{}, error) {
+	switch i.Type {
+	case "choice":
+		s, err := coerceInput("string", value)
+		if err != nil || s == nil {
+			return s, err
+		}
+		for _, option := range i.Options {
+			if option == s {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("'%v' is not one of the allowed options %v", s, i.Options)
+	case "environment":
+		return coerceInput("string", value)
+	default:
+		return coerceInput(i.Type, value)
+	}
+}
+
+// ValidateInputs checks the inputs of a workflow_dispatch event against the declared inputs
+func (w *WorkflowDispatch) ValidateInputs(inputs map[string]interface{}) error {
+	errs := []error{}
+	for _, name := range sortedKeys(inputs) {
+		if _, ok := w.Inputs[name]; !ok {
+			errs = append(errs, fmt.Errorf("unexpected input '%s'", name))
+		}
+	}
+	for _, name := range sortedKeys(w.Inputs) {
+		input := w.Inputs[name]
+		value, ok := inputs[name]
+		if !ok || value == nil {
+			if input.Required && input.Default == "" {
+				errs = append(errs, fmt.Errorf("input '%s' is required, but not provided", name))
+				continue
+			}
+			value = input.Default
+		}
+		if _, err := input.Coerce(value); err != nil {
+			errs = append(errs, fmt.Errorf("input '%s': %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (w *Workflow) WorkflowDispatchConfig() *WorkflowDispatch {
 	switch w.RawOn.Kind {
 	case yaml.ScalarNode:
@@ -150,6 +212,100 @@ type WorkflowCallResult struct {
 	Outputs map[string]string
 }
 
+// Coerce converts value to the type declared by the input
+func (i WorkflowCallInput) Coerce(value interface{}) (interface{}, error) {
+	return coerceInput(i.Type, value)
+}
+
+// ValidateInputs checks the `with` values of a calling job against the declared inputs.
+// The values are expected to be evaluated already.
+func (w *WorkflowCall) ValidateInputs(with map[string]interface{}) error {
+	errs := []error{}
+	for _, name := range sortedKeys(with) {
+		if _, ok := w.Inputs[name]; !ok {
+			errs = append(errs, fmt.Errorf("unexpected input '%s'", name))
+		}
+	}
+	for _, name := range sortedKeys(w.Inputs) {
+		input := w.Inputs[name]
+		value, ok := with[name]
+		if !ok || value == nil {
+			if input.Required && input.Default.IsZero() {
+				errs = append(errs, fmt.Errorf("input '%s' is required, but not provided", name))
+			}
+			// defaults may contain expressions, they are checked once evaluated
+			continue
+		}
+		if _, err := input.Coerce(value); err != nil {
+			errs = append(errs, fmt.Errorf("input '%s': %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// coerceInput converts value to an input type of boolean, number or string.
+// Values of an unknown or empty type are returned unchanged.
+func coerceInput(inputType string, value interface{}) (interface{}, error) {
+	switch inputType {
+	case "boolean":
+		switch v := value.(type) {
+		case nil:
+			return false, nil
+		case bool:
+			return v, nil
+		case string:
+			switch strings.ToLower(v) {
+			case "", "false":
+				return false, nil
+			case "true":
+				return true, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a boolean, got '%v'", value)
+	case "number":
+		switch v := value.(type) {
+		case nil:
+			return nil, nil
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case uint64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case string:
+			if v == "" {
+				return nil, nil
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a number, got '%v'", value)
+	case "string":
+		switch v := value.(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return v, nil
+		case bool, int, int64, uint64, float64:
+			return fmt.Sprintf("%v", v), nil
+		}
+		return nil, fmt.Errorf("expected a string, got '%v'", value)
+	}
+	return value, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (w *Workflow) WorkflowCallConfig() *WorkflowCall {
 	if w.RawOn.Kind != yaml.MappingNode {
 		// The callers expect for "on: workflow_call" and "on: [ workflow_call ]" a non nil return value
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -517,3 +518,115 @@ func TestReadWorkflow_WorkflowDispatchConfig(t *testing.T) {
 		Type:     "choice",
 	}, workflowDispatch.Inputs["logLevel"])
 }
+
+func TestWorkflowDispatch_ValidateInputs(t *testing.T) {
+	yaml := `
+    name: local-action-docker-url
+    on:
+        workflow_dispatch:
+            inputs:
+                logLevel:
+                    required: true
+                    default: 'warning'
+                    type: choice
+                    options:
+                    - info
+                    - warning
+                dryRun:
+                    type: boolean
+                count:
+                    type: number
+                target:
+                    type: environment
+                    required: true
+    `
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+	workflowDispatch := workflow.WorkflowDispatchConfig()
+
+	assert.NoError(t, workflowDispatch.ValidateInputs(map[string]interface{}{
+		"target": "production",
+	}))
+	assert.NoError(t, workflowDispatch.ValidateInputs(map[string]interface{}{
+		"logLevel": "info",
+		"dryRun":   "true",
+		"count":    "3",
+		"target":   "production",
+	}))
+
+	err = workflowDispatch.ValidateInputs(map[string]interface{}{
+		"logLevel": "trace",
+		"dryRun":   "yes",
+		"count":    "many",
+		"unknown":  "value",
+	})
+	assert.EqualError(t, err, strings.Join([]string{
+		"unexpected input 'unknown'",
+		"input 'count': expected a number, got 'many'",
+		"input 'dryRun': expected a boolean, got 'yes'",
+		"input 'logLevel': 'trace' is not one of the allowed options [info warning]",
+		"input 'target' is required, but not provided",
+	}, "\n"))
+}
+
+func TestWorkflowCall_ValidateInputs(t *testing.T) {
+	yaml := `
+    name: local-action-docker-url
+    on:
+        workflow_call:
+            inputs:
+                name:
+                    required: true
+                    type: string
+                flag:
+                    type: boolean
+                    default: ${{ true }}
+                count:
+                    type: number
+    `
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+	workflowCall := workflow.WorkflowCallConfig()
+
+	assert.NoError(t, workflowCall.ValidateInputs(map[string]interface{}{
+		"name":  "value",
+		"flag":  false,
+		"count": 1,
+	}))
+
+	err = workflowCall.ValidateInputs(map[string]interface{}{
+		"flag":    "maybe",
+		"count":   "1.5",
+		"unknown": "value",
+	})
+	assert.EqualError(t, err, strings.Join([]string{
+		"unexpected input 'unknown'",
+		"input 'flag': expected a boolean, got 'maybe'",
+		"input 'name' is required, but not provided",
+	}, "\n"))
+}
+
+func TestWorkflowCallInput_Coerce(t *testing.T) {
+	table := []struct {
+		inputType string
+		value     interface{}
+		expected  interface{}
+	}{
+		{"boolean", "true", true},
+		{"boolean", false, false},
+		{"boolean", nil, false},
+		{"number", "42", float64(42)},
+		{"number", 7, float64(7)},
+		{"string", 7, "7"},
+		{"string", true, "true"},
+		{"", 7, 7},
+	}
+
+	for _, tt := range table {
+		t.Run(fmt.Sprintf("%s-%v", tt.inputType, tt.value), func(t *testing.T) {
+			value, err := WorkflowCallInput{Type: tt.inputType}.Coerce(tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"io/fs"
 	"path"
+	"regexp"
 	"strings"
 	"time"
 
@@ -31,6 +32,17 @@ type GoGitActionCache struct {
 	Path string
 }
 
+// shortSHAError is returned by Fetch if the ref is the shortened version of a commit SHA, which can't be fetched
+type shortSHAError struct {
+	commit string
+}
+
+func (e *shortSHAError) Error() string {
+	return fmt.Sprintf("short SHA references are not supported, the full commit SHA is %s", e.commit)
+}
+
+var shortSHAPattern = regexp.MustCompile(`^[0-9a-f]{4,39}$`)
+
 func (c GoGitActionCache) Fetch(ctx context.Context, cacheDir, url, ref, token string) (string, error) {
 	logger := common.Logger(ctx)
 
@@ -79,6 +91,11 @@ func (c GoGitActionCache) Fetch(ctx context.Context, cacheDir, url, ref, token s
 		Force: true,
 		Depth: 1,
 	}); err != nil {
+		if shortSHAPattern.MatchString(ref) {
+			if commit := resolveShortSHA(ctx, gogitrepo, remote, auth, ref); commit != "" {
+				return "", &shortSHAError{commit: commit}
+			}
+		}
 		return "", fmt.Errorf("GoGitActionCache failed to fetch %s with ref %s at %s: %w", url, ref, gitPath, err)
 	}
 	hash, err := gogitrepo.ResolveRevision(plumbing.Revision(branchName))
@@ -89,6 +106,37 @@ func (c GoGitActionCache) Fetch(ctx context.Context, cacheDir, url, ref, token s
 	return hash.String(), nil
 }
 
+// resolveShortSHA fetches the history of the branches and tags to look up the commit of a shortened SHA,
+// it returns an empty string if the SHA isn't found
+func resolveShortSHA(ctx context.Context, gogitrepo *git.Repository, remote *git.Remote, auth transport.AuthMethod, ref string) string {
+	const prefix = "refs/action-cache-short-sha/"
+	defer func() {
+		if refs, err := gogitrepo.References(); err == nil {
+			_ = refs.ForEach(func(r *plumbing.Reference) error {
+				if strings.HasPrefix(r.Name().String(), prefix) {
+					_ = gogitrepo.Storer.RemoveReference(r.Name())
+				}
+				return nil
+			})
+		}
+	}()
+	if err := remote.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: []config.RefSpec{
+			config.RefSpec("+refs/heads/*:" + prefix + "heads/*"),
+			config.RefSpec("+refs/tags/*:" + prefix + "tags/*"),
+		},
+		Auth:  auth,
+		Force: true,
+	}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return ""
+	}
+	hash, err := gogitrepo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return ""
+	}
+	return hash.String()
+}
+
 type GitFileInfo struct {
 	name    string
 	size    int64
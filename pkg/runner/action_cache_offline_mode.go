@@ -12,12 +12,25 @@ import (
 
 type GoGitActionCacheOfflineMode struct {
 	Parent GoGitActionCache
+	// Refresh fetches refs which were resolved before again and updates them,
+	// the stored ref is only used if the fetch fails
+	Refresh bool
 }
 
 func (c GoGitActionCacheOfflineMode) Fetch(ctx context.Context, cacheDir, url, ref, token string) (string, error) {
 	logger := common.Logger(ctx)
 
 	gitPath := path.Join(c.Parent.Path, safeFilename(cacheDir)+".git")
+	refName := plumbing.ReferenceName("refs/action-cache-offline/" + ref)
+
+	if !c.Refresh {
+		if gogitrepo, err := git.PlainOpen(gitPath); err == nil {
+			if r, err := gogitrepo.Reference(refName, true); err == nil {
+				logger.Infof("GoGitActionCacheOfflineMode using cached content %s with ref %s at %s", url, ref, gitPath)
+				return r.Hash().String(), nil
+			}
+		}
+	}
 
 	logger.Infof("GoGitActionCacheOfflineMode fetch content %s with ref %s at %s", url, ref, gitPath)
 
@@ -26,7 +39,6 @@ func (c GoGitActionCacheOfflineMode) Fetch(ctx context.Context, cacheDir, url, r
 	if err != nil {
 		return "", fetchErr
 	}
-	refName := plumbing.ReferenceName("refs/action-cache-offline/" + ref)
 	r, err := gogitrepo.Reference(refName, true)
 	if fetchErr == nil {
 		if err != nil || sha != r.Hash().String() {
@@ -6,9 +6,14 @@ import (
 	"context"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //nolint:gosec
@@ -144,3 +149,60 @@ func TestActionCacheFailures(t *testing.T) {
 		})
 	}
 }
+
+func TestActionCacheOfflineModeUsesStoredRef(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := "nektos/act-test-actions"
+	repo, err := git.PlainInit(filepath.Join(dir, safeFilename(cacheDir)+".git"), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	sha := "de984ca37e4df4cb9fd9256435a3b82c4a2662b1"
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/action-cache-offline/main", plumbing.NewHash(sha))))
+
+	cache := GoGitActionCacheOfflineMode{
+		Parent: GoGitActionCache{
+			Path: dir,
+		},
+	}
+	// the url is never fetched, the stored ref is used instead
+	resolved, err := cache.Fetch(context.Background(), cacheDir, "http://127.0.0.1:1/nektos/act-test-actions", "main", "")
+	assert.NoError(t, err)
+	assert.Equal(t, sha, resolved)
+
+	cache.Refresh = true
+	resolved, err = cache.Fetch(context.Background(), cacheDir, "http://127.0.0.1:1/nektos/act-test-actions", "main", "")
+	assert.NoError(t, err, "the stored ref is used if the refresh fails")
+	assert.Equal(t, sha, resolved)
+}
+
+// commitTestRepository creates a repository at dir with a commit of files
+func commitTestRepository(t *testing.T, dir string, files map[string]string) plumbing.Hash {
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		_, err := worktree.Add(name)
+		require.NoError(t, err)
+	}
+	hash, err := worktree.Commit("commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "act", Email: "act@example.com"},
+	})
+	require.NoError(t, err)
+	return hash
+}
+
+func TestActionCacheShortSHA(t *testing.T) {
+	remote := t.TempDir()
+	hash := commitTestRepository(t, remote, map[string]string{"action.yml": "name: action\n"})
+	cache := &GoGitActionCache{
+		Path: t.TempDir(),
+	}
+
+	_, err := cache.Fetch(context.Background(), "org/repo", remote, hash.String()[:7], "")
+	var shortSHA *shortSHAError
+	require.ErrorAs(t, err, &shortSHA)
+	assert.Equal(t, hash.String(), shortSHA.commit)
+}
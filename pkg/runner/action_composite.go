@@ -75,6 +75,7 @@ func newCompositeRunContext(ctx context.Context, parent *RunContext, step action
 		Parent:           parent,
 		EventJSON:        parent.EventJSON,
 		nodeToolFullPath: parent.nodeToolFullPath,
+		hashFilesCache:   parent.hashFilesCache,
 	}
 	compositerc.ExprEval = compositerc.NewExpressionEvaluator(ctx)
 
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	goURL "net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ActionLock pins the refs of remote actions and reusable workflows to commit shas.
+// The keys are of the form {owner}/{repo}@{ref}.
+type ActionLock map[string]string
+
+const actionLockHeader = "# This file is generated by `act lock`, do not edit it manually.\n"
+
+// ReadActionLock reads an action lock file
+func ReadActionLock(file string) (ActionLock, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	lock := ActionLock{}
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("failed to read action lock file %s: %w", file, err)
+	}
+	return lock, nil
+}
+
+// Write writes the action lock file, the entries are sorted by key
+func (l ActionLock) Write(file string) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString(actionLockHeader)
+	if len(l) > 0 {
+		enc := yaml.NewEncoder(buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(map[string]string(l)); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(file, buf.Bytes(), 0o644)
+}
+
+func actionLockKey(url, ref string) string {
+	repo := url
+	if purl, err := goURL.Parse(url); err == nil && purl.Host != "" {
+		repo = purl.Path
+	}
+	return fmt.Sprintf("%s@%s", strings.Trim(repo, "/"), ref)
+}
+
+// LockedActionCache fetches the commit pinned in the ActionLock instead of the ref, refs which aren't pinned fail to fetch.
+// A ref which has been moved upstream, like a tag, still uses the pinned commit.
+type LockedActionCache struct {
+	Parent ActionCache
+	Lock   ActionLock
+}
+
+func (c *LockedActionCache) Fetch(ctx context.Context, cacheDir, url, ref, token string) (string, error) {
+	key := actionLockKey(url, ref)
+	pinned, ok := c.Lock[key]
+	if !ok {
+		return "", fmt.Errorf("%s is not pinned in the action lock file, run `act lock` to update it", key)
+	}
+	sha, err := c.Parent.Fetch(ctx, cacheDir, url, pinned, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the commit %s pinned by the action lock file for %s: %w", pinned, key, err)
+	}
+	if sha != pinned {
+		return "", fmt.Errorf("failed to fetch the commit %s pinned by the action lock file for %s, got %s", pinned, key, sha)
+	}
+	return sha, nil
+}
+
+func (c *LockedActionCache) GetTarArchive(ctx context.Context, cacheDir, sha, includePrefix string) (io.ReadCloser, error) {
+	return c.Parent.GetTarArchive(ctx, cacheDir, sha, includePrefix)
+}
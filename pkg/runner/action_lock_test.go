@@ -0,0 +1,182 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nektos/act/pkg/model"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestActionLockReadWrite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "act.lock")
+	lock := ActionLock{
+		"actions/setup-node@v4": "1111111111111111111111111111111111111111",
+		"actions/checkout@v4":   "0000000000000000000000000000000000000000",
+	}
+	assert.NoError(t, lock.Write(file))
+
+	read, err := ReadActionLock(file)
+	assert.NoError(t, err)
+	assert.Equal(t, lock, read)
+}
+
+// commitActionCache only fetches the commits it has, like a remote which moved its tags
+type commitActionCache struct {
+	fakeActionCache
+	commits map[string]bool
+}
+
+func (c *commitActionCache) Fetch(_ context.Context, _, _, ref, _ string) (string, error) {
+	if !c.commits[ref] {
+		return "", fmt.Errorf("couldn't find remote ref %s", ref)
+	}
+	return ref, nil
+}
+
+func TestLockedActionCache(t *testing.T) {
+	cache := &LockedActionCache{
+		Parent: &commitActionCache{
+			commits: map[string]bool{
+				"0000000000000000000000000000000000000000": true,
+			},
+		},
+		Lock: ActionLock{
+			"actions/checkout@v4":   "0000000000000000000000000000000000000000",
+			"actions/setup-node@v4": "1111111111111111111111111111111111111111",
+		},
+	}
+	ctx := context.Background()
+
+	sha, err := cache.Fetch(ctx, "actions/checkout", "https://github.com/actions/checkout", "v4", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "0000000000000000000000000000000000000000", sha)
+
+	_, err = cache.Fetch(ctx, "actions/setup-node", "https://github.com/actions/setup-node", "v4", "")
+	assert.EqualError(t, err, "failed to fetch the commit 1111111111111111111111111111111111111111 pinned by the action lock file for actions/setup-node@v4: couldn't find remote ref 1111111111111111111111111111111111111111")
+
+	_, err = cache.Fetch(ctx, "actions/cache", "https://github.com/actions/cache", "v4", "")
+	assert.EqualError(t, err, "actions/cache@v4 is not pinned in the action lock file, run `act lock` to update it")
+}
+
+func TestUsesWalker(t *testing.T) {
+	cache := &fakeActionCache{
+		files: map[string]string{
+			"composite/action.yml": `
+runs:
+  using: composite
+  steps:
+  - uses: org/nested@v2
+  - uses: ./local-in-composite
+`,
+			".github/workflows/reusable.yml": `
+on: workflow_call
+jobs:
+  job:
+    runs-on: ubuntu-latest
+    steps:
+    - uses: org/in-reusable@v3
+`,
+		},
+	}
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  steps:
+    runs-on: ubuntu-latest
+    steps:
+    - uses: org/repo/composite@v1
+    - uses: docker://alpine
+    - uses: org/repo/composite@v1
+  call:
+    uses: org/workflows/.github/workflows/reusable.yml@main
+`))
+	assert.NoError(t, err)
+
+	visited := []string{}
+	walker := &UsesWalker{
+		Cache:     cache,
+		Workdir:   "testdata/does-not-exist",
+		ServerURL: "https://github.com",
+		Visit: func(_ context.Context, uses RemoteUses) error {
+			visited = append(visited, uses.Key()+" "+uses.CacheDir)
+			return nil
+		},
+	}
+	assert.NoError(t, walker.Walk(context.Background(), []*model.Workflow{workflow}))
+	assert.Equal(t, []string{
+		"org/workflows@main org/workflows@main",
+		"org/in-reusable@v3 org/in-reusable",
+		"org/repo@v1 org/repo",
+		"org/nested@v2 org/nested",
+	}, visited)
+}
+
+func TestUsesWalkerImages(t *testing.T) {
+	cache := &fakeActionCache{
+		files: map[string]string{
+			"docker/action.yml": `
+runs:
+  using: docker
+  image: docker://node:20
+`,
+			"dockerfile/action.yml": `
+runs:
+  using: docker
+  image: Dockerfile
+`,
+		},
+	}
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  platform:
+    runs-on: [self-hosted, Ubuntu-Latest]
+    services:
+      redis:
+        image: redis:7
+      postgres:
+        image: postgres:16
+    steps:
+    - uses: docker://alpine
+    - uses: org/repo/docker@v1
+    - uses: org/repo/dockerfile@v1
+  container:
+    runs-on: ubuntu-latest
+    container: golang:1.22
+    steps:
+    - uses: docker://alpine
+  expression:
+    runs-on: ubuntu-latest
+    container: ${{ matrix.image }}
+`))
+	assert.NoError(t, err)
+
+	images := []string{}
+	walker := &UsesWalker{
+		Cache:     cache,
+		Workdir:   "testdata/does-not-exist",
+		ServerURL: "https://github.com",
+		Platforms: map[string]string{
+			"ubuntu-latest": "catthehacker/ubuntu:act-latest",
+		},
+		VisitImage: func(_ context.Context, image string) error {
+			images = append(images, image)
+			return nil
+		},
+	}
+	assert.NoError(t, walker.Walk(context.Background(), []*model.Workflow{workflow}))
+	assert.Equal(t, []string{
+		"golang:1.22",
+		"alpine",
+		"catthehacker/ubuntu:act-latest",
+		"postgres:16",
+		"redis:7",
+		"node:20",
+	}, images)
+}
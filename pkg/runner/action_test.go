@@ -153,7 +153,9 @@ func TestActionRunner(t *testing.T) {
 					Uses: "org/repo/path@ref",
 				},
 				RunContext: &RunContext{
-					Config: &Config{},
+					Config: &Config{
+						ActionCache: &fakeActionCache{},
+					},
 					Run: &model.Run{
 						JobID: "job",
 						Workflow: &model.Workflow{
@@ -189,7 +191,9 @@ func TestActionRunner(t *testing.T) {
 				},
 				RunContext: &RunContext{
 					ActionPath: "path",
-					Config:     &Config{},
+					Config: &Config{
+						ActionCache: &fakeActionCache{},
+					},
 					Run: &model.Run{
 						JobID: "job",
 						Workflow: &model.Workflow{
@@ -227,7 +231,7 @@ func TestActionRunner(t *testing.T) {
 			ctx := context.Background()
 
 			cm := &containerMock{}
-			cm.On("CopyDir", "/var/run/act/actions/dir/", "dir/", false).Return(func(_ context.Context) error { return nil })
+			cm.On("CopyTarStream", ctx, "/var/run/act/actions/dir/", mock.Anything).Return(nil)
 
 			envMatcher := mock.MatchedBy(func(env map[string]string) bool {
 				for k, v := range tt.expectedEnv {
@@ -60,6 +60,11 @@ func (cm *containerMock) CopyDir(destPath string, srcPath string, useGitIgnore b
 	return args.Get(0).(func(context.Context) error)
 }
 
+func (cm *containerMock) CopyTarStream(ctx context.Context, destPath string, tarStream io.Reader) error {
+	args := cm.Called(ctx, destPath, tarStream)
+	return args.Error(0)
+}
+
 func (cm *containerMock) Exec(command []string, env map[string]string, user, workdir string) common.Executor {
 	args := cm.Called(command, env, user, workdir)
 	return args.Get(0).(func(context.Context) error)
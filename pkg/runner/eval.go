@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/model"
+)
+
+// JobEvaluationEnvironment is the expression context of a job for one combination of its matrix
+type JobEvaluationEnvironment struct {
+	Matrix map[string]interface{}
+	*exprparser.EvaluationEnvironment
+}
+
+// NewJobEvaluationEnvironments creates the expression contexts a job has before its first step runs,
+// one for each matrix combination selected by Config.Matrix.
+// The values of secrets are masked and the results and outputs of the needed jobs of the workflow
+// are set from needs, needed jobs missing in needs succeeded without outputs.
+// The runner and steps contexts are empty.
+func NewJobEvaluationEnvironments(ctx context.Context, config *Config, run *model.Run, needs map[string]exprparser.Needs) ([]*JobEvaluationEnvironment, error) {
+	r, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	runner := r.(*runnerImpl)
+
+	job := run.Job()
+	if job == nil {
+		return nil, fmt.Errorf("job '%s' not found", run.JobID)
+	}
+	// the needs context is read from the needed jobs of the workflow, as after they ran
+	for _, need := range job.Needs() {
+		needed := run.Workflow.GetJob(need)
+		if needed == nil {
+			continue
+		}
+		if fixture, ok := needs[need]; ok {
+			needed.Outputs = fixture.Outputs
+			needed.Result = fixture.Result
+		} else if needed.Result == "" {
+			needed.Result = "success"
+		}
+		if needed.Outputs == nil {
+			needed.Outputs = map[string]string{}
+		}
+	}
+	if job.Strategy != nil {
+		strategyRc := runner.newRunContext(ctx, run, nil)
+		if err := strategyRc.NewExpressionEvaluator(ctx).EvaluateYamlNode(ctx, &job.Strategy.RawMatrix); err != nil {
+			return nil, fmt.Errorf("failed to evaluate matrix: %w", err)
+		}
+	}
+	matrixes, err := job.GetMatrixes()
+	if err != nil {
+		return nil, err
+	}
+	matrixes = selectMatrixes(matrixes, config.Matrix)
+	if len(matrixes) == 0 {
+		return nil, fmt.Errorf("no matrix combination of job '%s' matches %v", run.JobID, config.Matrix)
+	}
+
+	envs := make([]*JobEvaluationEnvironment, 0, len(matrixes))
+	for _, matrix := range matrixes {
+		rc := runner.newRunContext(ctx, run, matrix)
+		ee := rc.newJobEvaluationEnvironment(ctx, rc.GetEnv())
+
+		secrets := make(map[string]string, len(ee.Secrets))
+		for name := range ee.Secrets {
+			secrets[name] = "***"
+		}
+		ee.Secrets = secrets
+
+		envs = append(envs, &JobEvaluationEnvironment{
+			Matrix:                matrix,
+			EvaluationEnvironment: ee,
+		})
+	}
+	return envs, nil
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJobEvaluationEnvironments(t *testing.T) {
+	planner, err := model.NewWorkflowPlanner("testdata/evalmatrixneeds/push.yml", true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	plan, err := planner.PlanEvent("push")
+	if !assert.NoError(t, err) {
+		return
+	}
+	run := plan.Stages[1].Runs[0]
+
+	config := &Config{
+		EventName: "push",
+		Workdir:   "testdata",
+		Secrets:   map[string]string{"TOKEN": "secret"},
+		Vars:      map[string]string{"NAME": "value"},
+	}
+	needs := map[string]exprparser.Needs{
+		"prepare": {
+			Result:  "success",
+			Outputs: map[string]string{"matrix": `{"package":["a","b"]}`},
+		},
+	}
+	envs, err := NewJobEvaluationEnvironments(context.Background(), config, run, needs)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, envs, 2) {
+		return
+	}
+	assert.Equal(t, "a", envs[0].Matrix["package"])
+	assert.Equal(t, "b", envs[1].Matrix["package"])
+	for _, env := range envs {
+		assert.Equal(t, "push", env.Github.EventName)
+		assert.Equal(t, map[string]string{"TOKEN": "***"}, env.Secrets)
+		assert.Equal(t, "value", env.Vars["NAME"])
+		assert.Equal(t, needs["prepare"], env.Needs["prepare"])
+		assert.Equal(t, env.Matrix, env.EvaluationEnvironment.Matrix)
+	}
+}
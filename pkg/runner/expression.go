@@ -32,6 +32,17 @@ func (rc *RunContext) NewExpressionEvaluator(ctx context.Context) ExpressionEval
 }
 
 func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map[string]string) ExpressionEvaluator {
+	return expressionEvaluator{
+		interpreter: exprparser.NewInterpeter(rc.newJobEvaluationEnvironment(ctx, env), exprparser.Config{
+			Run:            rc.Run,
+			WorkingDir:     rc.Config.Workdir,
+			Context:        "job",
+			HashFilesCache: rc.hashFilesCache,
+		}),
+	}
+}
+
+func (rc *RunContext) newJobEvaluationEnvironment(ctx context.Context, env map[string]string) *exprparser.EvaluationEnvironment {
 	var workflowCallResult map[string]*model.WorkflowCallResult
 
 	// todo: cleanup EvaluationEnvironment creation
@@ -94,13 +105,7 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 	if rc.JobContainer != nil {
 		ee.Runner = rc.JobContainer.GetRunnerContext(ctx)
 	}
-	return expressionEvaluator{
-		interpreter: exprparser.NewInterpeter(ee, exprparser.Config{
-			Run:        rc.Run,
-			WorkingDir: rc.Config.Workdir,
-			Context:    "job",
-		}),
-	}
+	return ee
 }
 
 //go:embed hashfiles/index.js
@@ -160,9 +165,10 @@ func (rc *RunContext) newStepExpressionEvaluator(ctx context.Context, step step,
 	}
 	return expressionEvaluator{
 		interpreter: exprparser.NewInterpeter(ee, exprparser.Config{
-			Run:        rc.Run,
-			WorkingDir: rc.Config.Workdir,
-			Context:    "step",
+			Run:            rc.Run,
+			WorkingDir:     rc.Config.Workdir,
+			Context:        "step",
+			HashFilesCache: rc.hashFilesCache,
 		}),
 	}
 }
@@ -170,27 +176,23 @@ func (rc *RunContext) newStepExpressionEvaluator(ctx context.Context, step step,
 func getHashFilesFunction(ctx context.Context, rc *RunContext) func(v []reflect.Value) (interface{}, error) {
 	hashFiles := func(v []reflect.Value) (interface{}, error) {
 		if rc.JobContainer != nil {
+			patterns, followSymlink, err := exprparser.ParseHashFilesArgs(v)
+			if err != nil {
+				return "", err
+			}
+			if workspace, ok := rc.hostWorkspace(); ok {
+				return rc.hashFilesCache.HashFiles(workspace, rc.hostHashFilesPatterns(patterns), followSymlink)
+			}
+
+			// the workspace is only available inside of the container, so the files are hashed there.
+			// The Go implementation can't be used on a copy from GetContainerArchive: it would copy the whole
+			// workspace out of the container on every call and symbolic links would be resolved on the host
+			// instead of in the filesystem of the container.
 			timeed, cancel := context.WithTimeout(ctx, time.Minute)
 			defer cancel()
 			name := "workflow/hashfiles/index.js"
 			hout := &bytes.Buffer{}
 			herr := &bytes.Buffer{}
-			patterns := []string{}
-			followSymlink := false
-
-			for i, p := range v {
-				s := p.String()
-				if i == 0 {
-					if strings.HasPrefix(s, "--") {
-						if strings.EqualFold(s, "--follow-symbolic-links") {
-							followSymlink = true
-							continue
-						}
-						return "", fmt.Errorf("Invalid glob option %s, available option: '--follow-symbolic-links'", s)
-					}
-				}
-				patterns = append(patterns, s)
-			}
 			env := map[string]string{}
 			for k, v := range rc.Env {
 				env[k] = v
@@ -228,6 +230,38 @@ func getHashFilesFunction(ctx context.Context, rc *RunContext) func(v []reflect.
 	return hashFiles
 }
 
+// hostWorkspace returns the path of the workspace of the job container on the host,
+// if the files of the workspace can be read without copying them out of the container
+func (rc *RunContext) hostWorkspace() (string, bool) {
+	if _, ok := rc.JobContainer.(*container.HostEnvironment); ok {
+		return rc.JobContainer.ToContainerPath(rc.Config.Workdir), true
+	}
+	if rc.Config.BindWorkdir {
+		return rc.Config.Workdir, true
+	}
+	return "", false
+}
+
+// hostHashFilesPatterns makes absolute patterns inside of the workspace of the job container relative to the workspace
+func (rc *RunContext) hostHashFilesPatterns(patterns []string) []string {
+	workspace := rc.JobContainer.ToContainerPath(rc.Config.Workdir)
+	result := make([]string, 0, len(patterns))
+	for _, line := range patterns {
+		for _, pattern := range strings.Split(line, "\n") {
+			pattern = strings.TrimSpace(pattern)
+			negate := pattern[:len(pattern)-len(strings.TrimLeft(pattern, "!"))]
+			pattern = pattern[len(negate):]
+			if pattern == workspace {
+				pattern = "."
+			} else if rel := strings.TrimPrefix(pattern, workspace+"/"); rel != pattern {
+				pattern = "./" + rel
+			}
+			result = append(result, negate+pattern)
+		}
+	}
+	return result
+}
+
 type expressionEvaluator struct {
 	interpreter exprparser.Interpreter
 }
@@ -505,11 +539,7 @@ func getEvaluatorInputs(ctx context.Context, rc *RunContext, step step, ghc *mod
 				if value == nil {
 					value = v.Default
 				}
-				if v.Type == "boolean" {
-					inputs[k] = value == "true"
-				} else {
-					inputs[k] = value
-				}
+				inputs[k] = coerceInput(ctx, k, v, value)
 			}
 		}
 	}
@@ -524,11 +554,7 @@ func getEvaluatorInputs(ctx context.Context, rc *RunContext, step step, ghc *mod
 						common.Logger(ctx).Debugf("error decoding default value for %s: %v", k, err)
 					}
 				}
-				if v.Type == "boolean" {
-					inputs[k] = value == "true"
-				} else {
-					inputs[k] = value
-				}
+				inputs[k] = coerceInput(ctx, k, v, value)
 			}
 		}
 	}
@@ -539,18 +565,13 @@ func setupWorkflowInputs(ctx context.Context, inputs *map[string]interface{}, rc
 	if rc.caller != nil {
 		config := rc.Run.Workflow.WorkflowCallConfig()
 
+		with := rc.caller.with
+		if with == nil {
+			// evaluate using the calling RunContext (outside)
+			with, _ = evaluateReusableWorkflowWith(ctx, rc.caller.runContext)
+		}
 		for name, input := range config.Inputs {
-			value := rc.caller.runContext.Run.Job().With[name]
-
-			if value != nil {
-				node := yaml.Node{}
-				_ = node.Encode(value)
-				if rc.caller.runContext.ExprEval != nil {
-					// evaluate using the calling RunContext (outside)
-					_ = rc.caller.runContext.ExprEval.EvaluateYamlNode(ctx, &node)
-				}
-				_ = node.Decode(&value)
-			}
+			value := with[name]
 
 			if value == nil && config != nil && config.Inputs != nil {
 				def := input.Default
@@ -561,11 +582,25 @@ func setupWorkflowInputs(ctx context.Context, inputs *map[string]interface{}, rc
 				_ = def.Decode(&value)
 			}
 
-			(*inputs)[name] = value
+			(*inputs)[name] = coerceInput(ctx, name, input, value)
 		}
 	}
 }
 
+type inputCoercer interface {
+	Coerce(value interface{}) (interface{}, error)
+}
+
+// coerceInput converts value to the declared type of the input, the value is kept as is if it doesn't match
+func coerceInput(ctx context.Context, name string, input inputCoercer, value interface{}) interface{} {
+	coerced, err := input.Coerce(value)
+	if err != nil {
+		common.Logger(ctx).Debugf("error coercing input %s: %v", name, err)
+		return value
+	}
+	return coerced
+}
+
 func getWorkflowSecrets(ctx context.Context, rc *RunContext) map[string]string {
 	if rc.caller != nil {
 		job := rc.caller.runContext.Run.Job()
@@ -8,6 +8,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/nektos/act/pkg/container"
 	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 	assert "github.com/stretchr/testify/assert"
@@ -371,3 +372,35 @@ func TestRewriteSubExpressionForceFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestHashFilesHostWorkspace(t *testing.T) {
+	workdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(workdir+"/go.sum", []byte("sum"), 0o644))
+	hostWorkdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(hostWorkdir+"/go.sum", []byte("host"), 0o644))
+
+	rc := createRunContext(t)
+	rc.hashFilesCache = exprparser.NewHashFilesCache()
+	rc.Config.Workdir = workdir
+	rc.Config.BindWorkdir = true
+	rc.JobContainer = &container.HostEnvironment{Path: hostWorkdir, Workdir: workdir}
+	rc.ExprEval = rc.NewExpressionEvaluator(context.Background())
+
+	// the host environment runs in a copy of the workdir
+	expected, err := exprparser.NewHashFilesCache().HashFiles(hostWorkdir, []string{"go.sum"}, false)
+	assert.NoError(t, err)
+	for _, expression := range []string{"hashFiles('go.sum')", "hashFiles('**/go.sum')", "hashFiles('" + hostWorkdir + "/go.sum')"} {
+		out, err := rc.ExprEval.evaluate(context.Background(), expression, exprparser.DefaultStatusCheckNone)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out, expression)
+	}
+}
+
+func TestHostHashFilesPatterns(t *testing.T) {
+	rc := createRunContext(t)
+	rc.Config.Workdir = "/home/user/project"
+	rc.JobContainer = &container.HostEnvironment{Path: "/tmp/act/project", Workdir: "/home/user/project"}
+
+	assert.Equal(t, []string{"**/go.sum", "./go.mod", "!./vendor/**", ".", "/other/**"},
+		rc.hostHashFilesPatterns([]string{"**/go.sum\n/tmp/act/project/go.mod", "!/tmp/act/project/vendor/**", "/tmp/act/project", "/other/**"}))
+}
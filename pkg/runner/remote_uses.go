@@ -0,0 +1,282 @@
+package runner
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// RemoteUses is a remote action or reusable workflow referenced by a `uses` key
+type RemoteUses struct {
+	Uses     string // the value of the uses key, e.g. actions/checkout@v4
+	CacheDir string // the cache dir passed to the ActionCache
+	URL      string // the url of the repository
+	Ref      string // the ref of the uses key
+	SHA      string // the commit sha the ref resolved to
+}
+
+// Key returns the {owner}/{repo}@{ref} form of the remote repository
+func (u RemoteUses) Key() string {
+	return actionLockKey(u.URL, u.Ref)
+}
+
+// UsesWalker resolves the actions and reusable workflows used by workflows through an ActionCache.
+// Composite actions and reusable workflows are walked recursively.
+type UsesWalker struct {
+	Cache     ActionCache
+	Workdir   string // the local repository, local actions and workflows are read from it
+	ServerURL string // the url of the GitHub instance, e.g. https://github.com
+	Token     string // the token used to fetch the repositories
+
+	// Platforms maps the runs-on labels to images, like Config.Platforms
+	Platforms map[string]string
+
+	// Visit is called once for every resolved remote action and reusable workflow
+	Visit func(ctx context.Context, uses RemoteUses) error
+	// VisitImage is called once for every docker image used by jobs, services and actions.
+	// Images containing expressions are skipped.
+	VisitImage func(ctx context.Context, image string) error
+
+	seen map[string]bool
+}
+
+// usesReader reads a file of a repository, the path is relative to the root of the repository
+type usesReader func(ctx context.Context, filename string) (io.ReadCloser, error)
+
+// Walk resolves everything used by the workflows
+func (w *UsesWalker) Walk(ctx context.Context, workflows []*model.Workflow) error {
+	if w.seen == nil {
+		w.seen = map[string]bool{}
+	}
+	for _, workflow := range workflows {
+		if err := w.walkWorkflow(ctx, w.localReader(), workflow); err != nil {
+			return fmt.Errorf("%s: %w", workflow.File, err)
+		}
+	}
+	return nil
+}
+
+func (w *UsesWalker) walkWorkflow(ctx context.Context, reader usesReader, workflow *model.Workflow) error {
+	jobIDs := workflow.GetJobIDs()
+	sort.Strings(jobIDs)
+	for _, jobID := range jobIDs {
+		job := workflow.GetJob(jobID)
+		jobType, err := job.Type()
+		if err != nil {
+			return err
+		}
+		switch jobType {
+		case model.JobTypeReusableWorkflowLocal:
+			if err := w.walkReusableWorkflow(ctx, reader, strings.TrimPrefix(job.Uses, "./")); err != nil {
+				return err
+			}
+		case model.JobTypeReusableWorkflowRemote:
+			rw := newRemoteReusableWorkflow(job.Uses)
+			if rw == nil {
+				return fmt.Errorf("expected format {owner}/{repo}/.github/workflows/{filename}@{ref}. Actual '%s' Input string was not in a correct format", job.Uses)
+			}
+			rw.URL = w.ServerURL
+			cacheDir := fmt.Sprintf("%s/%s@%s", rw.Org, rw.Repo, rw.Ref)
+			remote, err := w.resolve(ctx, job.Uses, cacheDir, rw.CloneURL(), rw.Ref)
+			if err != nil || remote == nil {
+				return err
+			}
+			if err := w.walkReusableWorkflow(ctx, remote, fmt.Sprintf(".github/workflows/%s", rw.Filename)); err != nil {
+				return err
+			}
+		default:
+			if err := w.walkJobImages(ctx, job); err != nil {
+				return err
+			}
+			if err := w.walkSteps(ctx, job.Steps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *UsesWalker) walkJobImages(ctx context.Context, job *model.Job) error {
+	images := []string{}
+	if c := job.Container(); c != nil && c.Image != "" {
+		images = append(images, c.Image)
+	} else {
+		for _, label := range job.RunsOn() {
+			if image := w.Platforms[strings.ToLower(label)]; image != "" {
+				images = append(images, image)
+				break
+			}
+		}
+	}
+	serviceIDs := make([]string, 0, len(job.Services))
+	for id := range job.Services {
+		serviceIDs = append(serviceIDs, id)
+	}
+	sort.Strings(serviceIDs)
+	for _, id := range serviceIDs {
+		if service := job.Services[id]; service != nil && service.Image != "" {
+			images = append(images, service.Image)
+		}
+	}
+	for _, image := range images {
+		if err := w.visitImage(ctx, image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *UsesWalker) visitImage(ctx context.Context, image string) error {
+	if w.VisitImage == nil || strings.Contains(image, "${{") || strings.EqualFold(image, "-self-hosted") {
+		return nil
+	}
+	key := "image:" + image
+	if w.seen[key] {
+		return nil
+	}
+	w.seen[key] = true
+	return w.VisitImage(ctx, image)
+}
+
+func (w *UsesWalker) walkReusableWorkflow(ctx context.Context, reader usesReader, filename string) error {
+	f, err := reader(ctx, filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	workflow, err := model.ReadWorkflow(f)
+	if err != nil {
+		return fmt.Errorf("failed to read reusable workflow '%s': %w", filename, err)
+	}
+	workflow.File = filename
+	return w.walkWorkflow(ctx, reader, workflow)
+}
+
+func (w *UsesWalker) walkSteps(ctx context.Context, steps []*model.Step) error {
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		switch step.Type() {
+		case model.StepTypeUsesActionLocal:
+			// local actions are always relative to the workspace, even in remote composite actions
+			if err := w.walkAction(ctx, w.localReader(), strings.TrimPrefix(step.Uses, "./")); err != nil {
+				return err
+			}
+		case model.StepTypeUsesDockerURL:
+			if err := w.visitImage(ctx, strings.TrimPrefix(step.Uses, "docker://")); err != nil {
+				return err
+			}
+		case model.StepTypeUsesActionRemote:
+			ra := newRemoteAction(step.Uses)
+			if ra == nil {
+				return fmt.Errorf("Expected format {org}/{repo}[/path]@ref. Actual '%s' Input string was not in a correct format", step.Uses)
+			}
+			ra.URL = w.ServerURL
+			cacheDir := fmt.Sprintf("%s/%s", ra.Org, ra.Repo)
+			remote, err := w.resolve(ctx, step.Uses, cacheDir, ra.CloneURL(), ra.Ref)
+			if err != nil || remote == nil {
+				return err
+			}
+			if err := w.walkAction(ctx, remote, ra.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *UsesWalker) walkAction(ctx context.Context, reader usesReader, actionPath string) error {
+	var f io.ReadCloser
+	var err error
+	for _, filename := range []string{"action.yml", "action.yaml"} {
+		if f, err = reader(ctx, path.Join(actionPath, filename)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		// actions without metadata (e.g. only a Dockerfile) don't use anything
+		common.Logger(ctx).Debugf("no action metadata found in '%s': %v", actionPath, err)
+		return nil
+	}
+	defer f.Close()
+	action, err := model.ReadAction(f)
+	if err != nil {
+		return fmt.Errorf("failed to read action '%s': %w", actionPath, err)
+	}
+	if action.Runs.Using == model.ActionRunsUsingDocker && strings.HasPrefix(action.Runs.Image, "docker://") {
+		return w.visitImage(ctx, strings.TrimPrefix(action.Runs.Image, "docker://"))
+	}
+	if action.Runs.Using != model.ActionRunsUsingComposite {
+		return nil
+	}
+	steps := make([]*model.Step, len(action.Runs.Steps))
+	for i := range action.Runs.Steps {
+		steps[i] = &action.Runs.Steps[i]
+	}
+	return w.walkSteps(ctx, steps)
+}
+
+// resolve fetches the repository and returns a reader for its files, nil if it was resolved before
+func (w *UsesWalker) resolve(ctx context.Context, uses, cacheDir, url, ref string) (usesReader, error) {
+	key := fmt.Sprintf("%s@%s", cacheDir, ref)
+	if w.seen[key] {
+		return nil, nil
+	}
+	w.seen[key] = true
+
+	sha, err := w.Cache.Fetch(ctx, cacheDir, url, ref, w.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch \"%s\" version \"%s\": %w", url, ref, err)
+	}
+	if w.Visit != nil {
+		if err := w.Visit(ctx, RemoteUses{Uses: uses, CacheDir: cacheDir, URL: url, Ref: ref, SHA: sha}); err != nil {
+			return nil, err
+		}
+	}
+	return w.remoteReader(cacheDir, sha), nil
+}
+
+func (w *UsesWalker) localReader() usesReader {
+	return func(_ context.Context, filename string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(w.Workdir, filename))
+	}
+}
+
+func (w *UsesWalker) remoteReader(cacheDir, sha string) usesReader {
+	return func(ctx context.Context, filename string) (io.ReadCloser, error) {
+		archive, err := w.Cache.GetTarArchive(ctx, cacheDir, sha, filename)
+		if err != nil {
+			return nil, err
+		}
+		treader := tar.NewReader(archive)
+		header, err := treader.Next()
+		if err != nil {
+			archive.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, os.ErrNotExist
+			}
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			archive.Close()
+			return nil, fmt.Errorf("'%s' is not a regular file", filename)
+		}
+		return &readCloser{Reader: treader, Closer: archive}, nil
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
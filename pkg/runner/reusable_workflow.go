@@ -5,18 +5,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
-	"os"
 	"path"
 	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/nektos/act/pkg/common"
-	"github.com/nektos/act/pkg/common/git"
 	"github.com/nektos/act/pkg/model"
+	"gopkg.in/yaml.v3"
 )
 
 func newLocalReusableWorkflowExecutor(rc *RunContext) common.Executor {
+	if rc.caller != nil && rc.caller.source != nil {
+		// a local reusable workflow called by a remote one is read from the same repository and commit
+		return newActionCacheReusableWorkflowExecutor(rc, rc.caller.source, strings.TrimPrefix(rc.Run.Job().Uses, "./"))
+	}
 	return newReusableWorkflowExecutor(rc, rc.Config.Workdir, rc.Run.Job().Uses)
 }
 
@@ -28,31 +31,45 @@ func newRemoteReusableWorkflowExecutor(rc *RunContext) common.Executor {
 		return common.NewErrorExecutor(fmt.Errorf("expected format {owner}/{repo}/.github/workflows/{filename}@{ref}. Actual '%s' Input string was not in a correct format", uses))
 	}
 
-	// uses with safe filename makes the target directory look something like this {owner}-{repo}-.github-workflows-{filename}@{ref}
-	// instead we will just use {owner}-{repo}@{ref} as our target directory. This should also improve performance when we are using
-	// multiple reusable workflows from the same repository and ref since for each workflow we won't have to clone it again
+	// uses with safe filename makes the cache directory look something like this {owner}-{repo}-.github-workflows-{filename}@{ref}
+	// instead we will just use {owner}/{repo}@{ref} as our cache directory. This should also improve performance when we are using
+	// multiple reusable workflows from the same repository and ref since for each workflow we won't have to fetch it again
 	filename := fmt.Sprintf("%s/%s@%s", remoteReusableWorkflow.Org, remoteReusableWorkflow.Repo, remoteReusableWorkflow.Ref)
-	workflowDir := fmt.Sprintf("%s/%s", rc.ActionCacheDir(), safeFilename(filename))
-
-	if rc.Config.ActionCache != nil {
-		return newActionCacheReusableWorkflowExecutor(rc, filename, remoteReusableWorkflow)
-	}
 
-	return common.NewPipelineExecutor(
-		newMutexExecutor(cloneIfRequired(rc, *remoteReusableWorkflow, workflowDir)),
-		newReusableWorkflowExecutor(rc, workflowDir, fmt.Sprintf("./.github/workflows/%s", remoteReusableWorkflow.Filename)),
-	)
-}
-
-func newActionCacheReusableWorkflowExecutor(rc *RunContext, filename string, remoteReusableWorkflow *remoteReusableWorkflow) common.Executor {
 	return func(ctx context.Context) error {
 		ghctx := rc.getGithubContext(ctx)
 		remoteReusableWorkflow.URL = ghctx.ServerURL
-		sha, err := rc.Config.ActionCache.Fetch(ctx, filename, remoteReusableWorkflow.CloneURL(), remoteReusableWorkflow.Ref, ghctx.Token)
+		cache := rc.actionCache()
+		// parallel jobs may call the same reusable workflow, fetch it only once at a time
+		fetchLock.Lock()
+		sha, err := cache.Fetch(ctx, filename, remoteReusableWorkflow.CloneURL(), remoteReusableWorkflow.Ref, ghctx.Token)
+		fetchLock.Unlock()
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to fetch \"%s\" version \"%s\": %w", remoteReusableWorkflow.CloneURL(), remoteReusableWorkflow.Ref, err)
 		}
-		archive, err := rc.Config.ActionCache.GetTarArchive(ctx, filename, sha, fmt.Sprintf(".github/workflows/%s", remoteReusableWorkflow.Filename))
+		source := &reusableWorkflowSource{
+			cache:    cache,
+			cacheDir: filename,
+			sha:      sha,
+		}
+		return newActionCacheReusableWorkflowExecutor(rc, source, fmt.Sprintf(".github/workflows/%s", remoteReusableWorkflow.Filename))(ctx)
+	}
+}
+
+var (
+	fetchLock sync.Mutex
+)
+
+// reusableWorkflowSource is the repository and commit a remote reusable workflow was read from
+type reusableWorkflowSource struct {
+	cache    ActionCache
+	cacheDir string
+	sha      string
+}
+
+func newActionCacheReusableWorkflowExecutor(rc *RunContext, source *reusableWorkflowSource, workflow string) common.Executor {
+	return func(ctx context.Context) error {
+		archive, err := source.cache.GetTarArchive(ctx, source.cacheDir, source.sha, workflow)
 		if err != nil {
 			return err
 		}
@@ -61,7 +78,7 @@ func newActionCacheReusableWorkflowExecutor(rc *RunContext, filename string, rem
 		if _, err = treader.Next(); err != nil {
 			return err
 		}
-		planner, err := model.NewSingleWorkflowPlanner(remoteReusableWorkflow.Filename, treader)
+		planner, err := model.NewSingleWorkflowPlanner(path.Base(workflow), treader)
 		if err != nil {
 			return err
 		}
@@ -70,49 +87,20 @@ func newActionCacheReusableWorkflowExecutor(rc *RunContext, filename string, rem
 			return err
 		}
 
-		runner, err := NewReusableWorkflowRunner(rc)
+		with, err := validateReusableWorkflowInputs(ctx, rc, plan)
 		if err != nil {
 			return err
 		}
 
-		return runner.NewPlanExecutor(plan)(ctx)
-	}
-}
-
-var (
-	executorLock sync.Mutex
-)
-
-func newMutexExecutor(executor common.Executor) common.Executor {
-	return func(ctx context.Context) error {
-		executorLock.Lock()
-		defer executorLock.Unlock()
+		runner, err := newReusableWorkflowRunner(rc, source, with)
+		if err != nil {
+			return err
+		}
 
-		return executor(ctx)
+		return runner.NewPlanExecutor(plan)(ctx)
 	}
 }
 
-func cloneIfRequired(rc *RunContext, remoteReusableWorkflow remoteReusableWorkflow, targetDirectory string) common.Executor {
-	return common.NewConditionalExecutor(
-		func(_ context.Context) bool {
-			_, err := os.Stat(targetDirectory)
-			notExists := errors.Is(err, fs.ErrNotExist)
-			return notExists
-		},
-		func(ctx context.Context) error {
-			remoteReusableWorkflow.URL = rc.getGithubContext(ctx).ServerURL
-			return git.NewGitCloneExecutor(git.NewGitCloneExecutorInput{
-				URL:         remoteReusableWorkflow.CloneURL(),
-				Ref:         remoteReusableWorkflow.Ref,
-				Dir:         targetDirectory,
-				Token:       rc.Config.Token,
-				OfflineMode: rc.Config.ActionOfflineMode,
-			})(ctx)
-		},
-		nil,
-	)
-}
-
 func newReusableWorkflowExecutor(rc *RunContext, directory string, workflow string) common.Executor {
 	return func(ctx context.Context) error {
 		planner, err := model.NewWorkflowPlanner(path.Join(directory, workflow), true)
@@ -125,7 +113,12 @@ func newReusableWorkflowExecutor(rc *RunContext, directory string, workflow stri
 			return err
 		}
 
-		runner, err := NewReusableWorkflowRunner(rc)
+		with, err := validateReusableWorkflowInputs(ctx, rc, plan)
+		if err != nil {
+			return err
+		}
+
+		runner, err := newReusableWorkflowRunner(rc, nil, with)
 		if err != nil {
 			return err
 		}
@@ -134,13 +127,58 @@ func newReusableWorkflowExecutor(rc *RunContext, directory string, workflow stri
 	}
 }
 
+// validateReusableWorkflowInputs checks the evaluated `with` values of the calling job
+// against the inputs declared by the called workflow and returns them
+func validateReusableWorkflowInputs(ctx context.Context, rc *RunContext, plan *model.Plan) (map[string]interface{}, error) {
+	with, err := evaluateReusableWorkflowWith(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Stages) == 0 || len(plan.Stages[0].Runs) == 0 {
+		return with, nil
+	}
+	config := plan.Stages[0].Runs[0].Workflow.WorkflowCallConfig()
+	if err := config.ValidateInputs(with); err != nil {
+		return nil, fmt.Errorf("invalid inputs for reusable workflow '%s':\n%w", rc.Run.Job().Uses, err)
+	}
+	return with, nil
+}
+
+// evaluateReusableWorkflowWith evaluates the `with` values of the calling job, the values failing to evaluate
+// are returned as far as they are evaluated along with the error
+func evaluateReusableWorkflowWith(ctx context.Context, rc *RunContext) (map[string]interface{}, error) {
+	var errs []error
+	with := map[string]interface{}{}
+	for name, value := range rc.Run.Job().With {
+		if value != nil {
+			node := yaml.Node{}
+			_ = node.Encode(value)
+			if rc.ExprEval != nil {
+				if err := rc.ExprEval.EvaluateYamlNode(ctx, &node); err != nil {
+					errs = append(errs, fmt.Errorf("failed to evaluate input '%s' of '%s': %w", name, rc.Run.Job().Uses, err))
+				}
+			}
+			_ = node.Decode(&value)
+		}
+		with[name] = value
+	}
+	return with, errors.Join(errs...)
+}
+
 func NewReusableWorkflowRunner(rc *RunContext) (Runner, error) {
+	return newReusableWorkflowRunner(rc, nil, nil)
+}
+
+func newReusableWorkflowRunner(rc *RunContext, source *reusableWorkflowSource, with map[string]interface{}) (Runner, error) {
 	runner := &runnerImpl{
 		config:    rc.Config,
 		eventJSON: rc.EventJSON,
 		caller: &caller{
 			runContext: rc,
+			source:     source,
+			with:       with,
 		},
+		hashFilesCache: rc.hashFilesCache,
 	}
 
 	return runner.configure()
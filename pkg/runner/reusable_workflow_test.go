@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nektos/act/pkg/model"
+	assert "github.com/stretchr/testify/assert"
+)
+
+type fakeActionCache struct {
+	files    map[string]string
+	archives []string
+}
+
+func (c *fakeActionCache) Fetch(_ context.Context, _, _, ref, _ string) (string, error) {
+	return ref, nil
+}
+
+func (c *fakeActionCache) GetTarArchive(_ context.Context, cacheDir, sha, includePrefix string) (io.ReadCloser, error) {
+	c.archives = append(c.archives, cacheDir+"@"+sha+":"+includePrefix)
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if content, ok := c.files[includePrefix]; ok {
+		if err := tw.WriteHeader(&tar.Header{Name: includePrefix, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
+
+func TestNestedLocalReusableWorkflowFromRemoteSource(t *testing.T) {
+	cache := &fakeActionCache{
+		files: map[string]string{
+			".github/workflows/nested.yml": `
+on: workflow_call
+jobs:
+  skipped:
+    if: false
+    runs-on: ubuntu-latest
+    steps:
+    - run: exit 1
+`,
+		},
+	}
+
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: workflow_call
+jobs:
+  call:
+    uses: ./.github/workflows/nested.yml
+`))
+	assert.NoError(t, err)
+
+	rc := &RunContext{
+		Config: &Config{
+			Workdir: "testdata/does-not-exist",
+		},
+		Run: &model.Run{
+			JobID:    "call",
+			Workflow: workflow,
+		},
+		EventJSON: "{}",
+		caller: &caller{
+			runContext: &RunContext{Config: &Config{}},
+			source: &reusableWorkflowSource{
+				cache:    cache,
+				cacheDir: "org/repo@v1",
+				sha:      "0123456789",
+			},
+		},
+	}
+
+	err = newLocalReusableWorkflowExecutor(rc)(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"org/repo@v1@0123456789:.github/workflows/nested.yml"}, cache.archives)
+}
+
+func TestRunContextActionCache(t *testing.T) {
+	rc := &RunContext{Config: &Config{ActionCacheDir: "/cache"}}
+	assert.Equal(t, GoGitActionCache{Path: "/cache"}, rc.actionCache())
+
+	rc.Config.ActionOfflineMode = true
+	assert.Equal(t, GoGitActionCacheOfflineMode{Parent: GoGitActionCache{Path: "/cache"}}, rc.actionCache())
+
+	cache := &fakeActionCache{}
+	rc.Config.ActionCache = cache
+	assert.Equal(t, cache, rc.actionCache())
+}
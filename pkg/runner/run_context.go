@@ -53,6 +53,7 @@ type RunContext struct {
 	caller              *caller // job calling this RunContext (reusable workflows)
 	Cancelled           bool
 	nodeToolFullPath    string
+	hashFilesCache      *exprparser.HashFilesCache
 }
 
 func (rc *RunContext) AddMask(mask string) {
@@ -281,6 +282,17 @@ func (rc *RunContext) startJobContainer() common.Executor {
 		// if using service containers, will create a new network for the containers.
 		// and it will be removed after at last.
 		networkName, createAndDeleteNetwork := rc.networkName()
+		usesKubernetes := rc.Config.ContainerEngine == container.EngineKubernetes
+		if usesKubernetes {
+			// the containers of the pod share its network
+			createAndDeleteNetwork = false
+		}
+		// the kubernetes engine runs the services as sidecars in the pod of the job
+		serviceInputs := []*container.NewContainerInput{}
+
+		if rc.Config.ContainerEngine == container.EngineNamespaces && len(rc.Run.Job().Services) > 0 {
+			return fmt.Errorf("the service containers of job %s aren't supported by the namespaces engine", rc.JobName)
+		}
 
 		// add service containers
 		for serviceID, spec := range rc.Run.Job().Services {
@@ -320,7 +332,17 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			}
 
 			serviceContainerName := createContainerName(rc.jobContainerName(), serviceID)
-			c := container.NewContainer(&container.NewContainerInput{
+			// the output of the service is logged under its own prefix
+			serviceLogger := rawLogger.WithField("service", serviceID)
+			serviceLogWriter := common.NewLineWriter(func(s string) bool {
+				if rc.Config.LogOutput {
+					serviceLogger.Infof("%s", s)
+				} else {
+					serviceLogger.Debugf("%s", s)
+				}
+				return true
+			})
+			serviceInput := &container.NewContainerInput{
 				Name:           serviceContainerName,
 				WorkingDir:     ext.ToContainerPath(rc.Config.Workdir),
 				Image:          imageName,
@@ -329,8 +351,8 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				Env:            envs,
 				Mounts:         serviceMounts,
 				Binds:          serviceBinds,
-				Stdout:         logWriter,
-				Stderr:         logWriter,
+				Stdout:         serviceLogWriter,
+				Stderr:         serviceLogWriter,
 				Privileged:     rc.Config.Privileged,
 				UsernsMode:     rc.Config.UsernsMode,
 				Platform:       rc.Config.ContainerArchitecture,
@@ -339,8 +361,12 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				NetworkAliases: []string{serviceID},
 				ExposedPorts:   exposedPorts,
 				PortBindings:   portBindings,
-			})
-			rc.ServiceContainers = append(rc.ServiceContainers, c)
+			}
+			if usesKubernetes {
+				serviceInputs = append(serviceInputs, serviceInput)
+				continue
+			}
+			rc.ServiceContainers = append(rc.ServiceContainers, container.NewContainer(serviceInput))
 		}
 
 		rc.cleanUpJobContainer = func(ctx context.Context) error {
@@ -349,9 +375,13 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			}
 
 			if rc.JobContainer != nil {
+				// the namespaces and kubernetes engines remove the volumes of the job with the job container
+				removesVolumesWithContainer := func(_ context.Context) bool {
+					return rc.Config.ContainerEngine == container.EngineNamespaces || usesKubernetes
+				}
 				return rc.JobContainer.Remove().IfNot(reuseJobContainer).
-					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false)).IfNot(reuseJobContainer).
-					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false)).IfNot(reuseJobContainer).
+					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false).IfNot(removesVolumesWithContainer)).IfNot(reuseJobContainer).
+					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false).IfNot(removesVolumesWithContainer)).IfNot(reuseJobContainer).
 					Then(func(ctx context.Context) error {
 						if len(rc.ServiceContainers) > 0 {
 							logger.Infof("Cleaning up services for job %s", rc.JobName)
@@ -382,7 +412,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			jobContainerNetwork = "host"
 		}
 
-		rc.JobContainer = container.NewContainer(&container.NewContainerInput{
+		rc.JobContainer = rc.newJobContainer(&container.NewContainerInput{
 			Cmd:            nil,
 			Entrypoint:     []string{"tail", "-f", "/dev/null"},
 			WorkingDir:     ext.ToContainerPath(rc.Config.Workdir),
@@ -401,7 +431,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			UsernsMode:     rc.Config.UsernsMode,
 			Platform:       rc.Config.ContainerArchitecture,
 			Options:        rc.options(ctx),
-		})
+		}, serviceInputs...)
 		if rc.JobContainer == nil {
 			return errors.New("Failed to create job container")
 		}
@@ -540,6 +570,30 @@ func (rc *RunContext) UpdateExtraPath(ctx context.Context, githubEnvPath string)
 	return nil
 }
 
+// newJobContainer creates the job container with the engine of the config, the services are only given to the kubernetes engine
+func (rc *RunContext) newJobContainer(input *container.NewContainerInput, services ...*container.NewContainerInput) container.ExecutionsEnvironment {
+	switch rc.Config.ContainerEngine {
+	case container.EngineNamespaces:
+		return container.NewNamespaceEnvironment(input, filepath.Join(rc.ActionCacheDir(), "namespaces"))
+	case container.EngineKubernetes:
+		if rc.Config.Kubernetes == nil {
+			return nil
+		}
+		return container.NewKubernetesEnvironment(rc.Config.Kubernetes, input, services...)
+	}
+	return container.NewContainer(input)
+}
+
+// checkStepContainer fails a step which runs in a container of its own like docker:// steps and Docker actions, the
+// namespaces and kubernetes engines have no Docker daemon to run it next to the job container
+func (rc *RunContext) checkStepContainer(uses string) error {
+	switch rc.Config.ContainerEngine {
+	case container.EngineNamespaces, container.EngineKubernetes:
+		return fmt.Errorf("'%s' runs in a container of its own, which the %s engine doesn't support, use the docker or podman engine", uses, rc.Config.ContainerEngine)
+	}
+	return nil
+}
+
 // stopJobContainer removes the job container (if it exists) and its volume (if it exists)
 func (rc *RunContext) stopJobContainer() common.Executor {
 	return func(ctx context.Context) error {
@@ -568,12 +622,25 @@ func (rc *RunContext) startServiceContainers(_ string) common.Executor {
 				c.Pull(false),
 				c.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
 				c.Start(false),
+				followServiceLogs(c),
 			))
 		}
 		return common.NewParallelExecutor(len(execs), execs...)(ctx)
 	}
 }
 
+// followServiceLogs streams the output of the service container while the job runs, if its engine supports it
+func followServiceLogs(c container.ExecutionsEnvironment) common.Executor {
+	return func(ctx context.Context) error {
+		if follower, ok := c.(container.LogsFollower); ok {
+			if err := follower.FollowLogs()(ctx); err != nil {
+				common.Logger(ctx).Warnf("Not showing the output of the service container: %v", err)
+			}
+		}
+		return nil
+	}
+}
+
 func (rc *RunContext) waitForServiceContainer(c container.ExecutionsEnvironment) common.Executor {
 	return func(ctx context.Context) error {
 		sctx, cancel := context.WithTimeout(ctx, time.Minute*5)
@@ -638,6 +705,22 @@ func (rc *RunContext) ActionCacheDir() string {
 	return filepath.Join(xdgCache, "act")
 }
 
+// actionCache returns the configured ActionCache, or a git based one in the action cache dir
+func (rc *RunContext) actionCache() ActionCache {
+	if rc.Config.ActionCache != nil {
+		return rc.Config.ActionCache
+	}
+	cache := GoGitActionCache{
+		Path: rc.ActionCacheDir(),
+	}
+	if rc.Config.ActionOfflineMode {
+		return GoGitActionCacheOfflineMode{
+			Parent: cache,
+		}
+	}
+	return cache
+}
+
 // Interpolate outputs after a job is done
 func (rc *RunContext) interpolateOutputs() common.Executor {
 	return func(ctx context.Context) error {
@@ -1047,7 +1130,10 @@ func (rc *RunContext) withGithubEnv(ctx context.Context, github *model.GithubCon
 	env["GITHUB_GRAPHQL_URL"] = github.GraphQLURL
 
 	if rc.Config.ArtifactServerPath != "" {
-		setActionRuntimeVars(rc, env)
+		setActionRuntimeVars(rc, github, env)
+	} else if env["ACTIONS_RESULTS_URL"] != "" || env["ACTIONS_CACHE_URL"] != "" {
+		// the cache server requires a runtime token, the cache service v2 is served at the results url
+		setActionRuntimeToken(rc, github, env)
 	}
 
 	for _, platformName := range rc.runsOnPlatformNames(ctx) {
@@ -1065,25 +1151,62 @@ func (rc *RunContext) withGithubEnv(ctx context.Context, github *model.GithubCon
 	return env
 }
 
-func setActionRuntimeVars(rc *RunContext, env map[string]string) {
+func setActionRuntimeVars(rc *RunContext, github *model.GithubContext, env map[string]string) {
 	actionsRuntimeURL := os.Getenv("ACTIONS_RUNTIME_URL")
 	if actionsRuntimeURL == "" {
 		actionsRuntimeURL = fmt.Sprintf("http://%s:%s/", rc.Config.ArtifactServerAddr, rc.Config.ArtifactServerPort)
 	}
 	env["ACTIONS_RUNTIME_URL"] = actionsRuntimeURL
-	env["ACTIONS_RESULTS_URL"] = actionsRuntimeURL
+	// the results url may be set to the cache server, which forwards the requests of the artifact service
+	if env["ACTIONS_RESULTS_URL"] == "" {
+		env["ACTIONS_RESULTS_URL"] = actionsRuntimeURL
+	}
+	setActionRuntimeToken(rc, github, env)
+}
 
+func setActionRuntimeToken(rc *RunContext, github *model.GithubContext, env map[string]string) {
 	actionsRuntimeToken := os.Getenv("ACTIONS_RUNTIME_TOKEN")
 	if actionsRuntimeToken == "" {
 		runID := int64(1)
 		if rid, ok := rc.Config.Env["GITHUB_RUN_ID"]; ok {
 			runID, _ = strconv.ParseInt(rid, 10, 64)
 		}
-		actionsRuntimeToken, _ = common.CreateAuthorizationToken(runID, runID, runID)
+		actionsRuntimeToken, _ = common.CreateCacheAuthorizationToken(runID, runID, runID, common.CacheAccess{
+			Repository: github.Repository,
+			Scopes:     cacheScopes(github, rc.Config.DefaultBranch),
+		}, rc.Config.RuntimeTokenKey)
 	}
 	env["ACTIONS_RUNTIME_TOKEN"] = actionsRuntimeToken
 }
 
+// cacheScopes returns the refs whose caches the job may restore like on GitHub, the caches of the current ref come
+// first, followed by those of the base ref of a pull request and of the default branch. The default branch of the
+// repository in the event takes precedence over defaultBranch. Caches are saved to the current ref only.
+func cacheScopes(github *model.GithubContext, defaultBranch string) []common.CacheScope {
+	scopes := []common.CacheScope{}
+	add := func(ref string, permission common.CachePermission) {
+		if ref == "refs/heads/" {
+			return
+		}
+		for _, scope := range scopes {
+			if scope.Scope == ref {
+				return
+			}
+		}
+		scopes = append(scopes, common.CacheScope{Scope: ref, Permission: permission})
+	}
+	if github.Ref == "" {
+		return nil
+	}
+	add(github.Ref, common.CachePermissionRead|common.CachePermissionWrite)
+	add("refs/heads/"+github.BaseRef, common.CachePermissionRead)
+	if branch, ok := nestedMapLookup(github.Event, "repository", "default_branch").(string); ok && branch != "" {
+		defaultBranch = branch
+	}
+	add("refs/heads/"+defaultBranch, common.CachePermissionRead)
+	return scopes
+}
+
 func (rc *RunContext) handleCredentials(ctx context.Context) (string, string, error) {
 	// TODO: remove below 2 lines when we can release act with breaking changes
 	username := rc.Config.Secrets["DOCKER_USERNAME"]
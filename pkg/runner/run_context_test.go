@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"regexp"
 	"runtime"
@@ -11,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 
@@ -687,7 +689,7 @@ func TestSetRuntimeVariables(t *testing.T) {
 	}
 	v := "http://myhost:8000/"
 	env := map[string]string{}
-	setActionRuntimeVars(rc, env)
+	setActionRuntimeVars(rc, &model.GithubContext{}, env)
 
 	assert.Equal(t, v, env["ACTIONS_RESULTS_URL"])
 	assert.Equal(t, v, env["ACTIONS_RUNTIME_URL"])
@@ -711,7 +713,7 @@ func TestSetRuntimeVariablesWithRunID(t *testing.T) {
 	}
 	v := "http://myhost:8000/"
 	env := map[string]string{}
-	setActionRuntimeVars(rc, env)
+	setActionRuntimeVars(rc, &model.GithubContext{}, env)
 
 	assert.Equal(t, v, env["ACTIONS_RESULTS_URL"])
 	assert.Equal(t, v, env["ACTIONS_RUNTIME_URL"])
@@ -726,3 +728,99 @@ func TestSetRuntimeVariablesWithRunID(t *testing.T) {
 	assert.True(t, ok, "scp claim exists")
 	assert.Equal(t, "Actions.Results:45:45", scp, "contains expected scp claim")
 }
+
+func TestSetRuntimeTokenSigned(t *testing.T) {
+	key := []byte("run key")
+	rc := &RunContext{
+		Config: &Config{
+			RuntimeTokenKey: key,
+		},
+	}
+	env := map[string]string{}
+	setActionRuntimeToken(rc, &model.GithubContext{}, env)
+
+	req := &http.Request{Header: http.Header{}}
+	req.Header.Set("Authorization", "Bearer "+env["ACTIONS_RUNTIME_TOKEN"])
+	assert.NoError(t, common.VerifyAuthorizationToken(req, key))
+	assert.Error(t, common.VerifyAuthorizationToken(req, []byte("other key")))
+}
+
+func TestGithubEnvRuntimeTokenForCacheServer(t *testing.T) {
+	key := []byte("run key")
+	rc := &RunContext{
+		Config: &Config{
+			RuntimeTokenKey: key,
+		},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"job1": {},
+				},
+			},
+		},
+	}
+	env := rc.withGithubEnv(context.Background(), &model.GithubContext{}, map[string]string{
+		"ACTIONS_CACHE_URL": "http://myhost:8000/",
+	})
+
+	assert.Empty(t, env["ACTIONS_RESULTS_URL"])
+	req := &http.Request{Header: http.Header{}}
+	req.Header.Set("Authorization", "Bearer "+env["ACTIONS_RUNTIME_TOKEN"])
+	assert.NoError(t, common.VerifyAuthorizationToken(req, key))
+}
+
+func TestCacheScopes(t *testing.T) {
+	readWrite := common.CachePermissionRead | common.CachePermissionWrite
+	event := map[string]interface{}{
+		"repository": map[string]interface{}{
+			"default_branch": "main",
+		},
+	}
+	for _, tt := range []struct {
+		github        *model.GithubContext
+		defaultBranch string
+		want          []common.CacheScope
+	}{
+		{&model.GithubContext{}, "", nil},
+		{&model.GithubContext{Ref: "refs/heads/main", Event: event}, "", []common.CacheScope{
+			{Scope: "refs/heads/main", Permission: readWrite},
+		}},
+		{&model.GithubContext{Ref: "refs/heads/feature", Event: event}, "master", []common.CacheScope{
+			{Scope: "refs/heads/feature", Permission: readWrite},
+			{Scope: "refs/heads/main", Permission: common.CachePermissionRead},
+		}},
+		// --defaultbranch is used if the event has no repository
+		{&model.GithubContext{Ref: "refs/heads/feature"}, "master", []common.CacheScope{
+			{Scope: "refs/heads/feature", Permission: readWrite},
+			{Scope: "refs/heads/master", Permission: common.CachePermissionRead},
+		}},
+		{&model.GithubContext{Ref: "refs/heads/feature"}, "", []common.CacheScope{
+			{Scope: "refs/heads/feature", Permission: readWrite},
+		}},
+		{&model.GithubContext{Ref: "refs/pull/1/merge", BaseRef: "release", Event: event}, "", []common.CacheScope{
+			{Scope: "refs/pull/1/merge", Permission: readWrite},
+			{Scope: "refs/heads/release", Permission: common.CachePermissionRead},
+			{Scope: "refs/heads/main", Permission: common.CachePermissionRead},
+		}},
+	} {
+		assert.Equal(t, tt.want, cacheScopes(tt.github, tt.defaultBranch), tt.github.Ref)
+	}
+}
+
+func TestSetRuntimeVariablesKeepResultsURL(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			ArtifactServerAddr: "myhost",
+			ArtifactServerPort: "8000",
+		},
+	}
+	env := map[string]string{
+		"ACTIONS_RESULTS_URL": "http://cachehost:9000/",
+	}
+	setActionRuntimeVars(rc, &model.GithubContext{}, env)
+
+	assert.Equal(t, "http://cachehost:9000/", env["ACTIONS_RESULTS_URL"])
+	assert.Equal(t, "http://myhost:8000/", env["ACTIONS_RUNTIME_URL"])
+	assert.NotEmpty(t, env["ACTIONS_RUNTIME_TOKEN"])
+}
@@ -9,6 +9,8 @@ import (
 
 	docker_container "github.com/docker/docker/api/types/container"
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 	log "github.com/sirupsen/logrus"
 )
@@ -45,6 +47,8 @@ type Config struct {
 	UsernsMode                         string                       // user namespace to use
 	ContainerArchitecture              string                       // Desired OS/architecture platform for running containers
 	ContainerDaemonSocket              string                       // Path to Docker daemon socket
+	ContainerEngine                    container.Engine             // the engine of the job containers, container.EngineNamespaces runs them without a daemon
+	Kubernetes                         *container.KubernetesConfig  // the cluster of container.EngineKubernetes
 	ContainerOptions                   string                       // Options for the job container
 	UseGitIgnore                       bool                         // controls if paths in .gitignore should not be copied into container, default true
 	GitHubInstance                     string                       // GitHub instance to use, default "github.com"
@@ -54,6 +58,7 @@ type Config struct {
 	ArtifactServerPath                 string                       // the path where the artifact server stores uploads
 	ArtifactServerAddr                 string                       // the address the artifact server binds to
 	ArtifactServerPort                 string                       // the port the artifact server binds to
+	RuntimeTokenKey                    []byte                       // signs the ACTIONS_RUNTIME_TOKEN of the jobs, the servers of the run only accept tokens signed with it
 	NoSkipCheckout                     bool                         // do not skip actions/checkout
 	RemoteName                         string                       // remote name in local git repo config
 	ReplaceGheActionWithGithubCom      []string                     // Use actions from GitHub Enterprise instance to GitHub
@@ -65,18 +70,22 @@ type Config struct {
 
 type caller struct {
 	runContext *RunContext
+	source     *reusableWorkflowSource // the repository of a remote reusable workflow, nil for the local repository
+	with       map[string]interface{}  // the evaluated `with` of the calling job, nil if not evaluated yet
 }
 
 type runnerImpl struct {
-	config    *Config
-	eventJSON string
-	caller    *caller // the job calling this runner (caller of a reusable workflow)
+	config         *Config
+	eventJSON      string
+	caller         *caller                    // the job calling this runner (caller of a reusable workflow)
+	hashFilesCache *exprparser.HashFilesCache // shared by all jobs of the run
 }
 
 // New Creates a new Runner
 func New(runnerConfig *Config) (Runner, error) {
 	runner := &runnerImpl{
-		config: runnerConfig,
+		config:         runnerConfig,
+		hashFilesCache: exprparser.NewHashFilesCache(),
 	}
 
 	return runner.configure()
@@ -109,6 +118,11 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 	maxJobNameLen := 0
 
 	stagePipeline := make([]common.Executor, 0)
+	if runner.caller == nil && runner.config.EventName == "workflow_dispatch" {
+		stagePipeline = append(stagePipeline, func(ctx context.Context) error {
+			return validateWorkflowDispatchInputs(ctx, plan, runner.eventJSON)
+		})
+	}
 	log.Debugf("Plan Stages: %v", plan.Stages)
 
 	for i := range plan.Stages {
@@ -207,6 +221,45 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 	return common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan))
 }
 
+// validateWorkflowDispatchInputs checks the inputs of the event against the inputs declared
+// by each workflow of the plan. The workflows of a plan may declare different inputs, so the
+// inputs a workflow doesn't declare are only warned about.
+func validateWorkflowDispatchInputs(ctx context.Context, plan *model.Plan, eventJSON string) error {
+	var event struct {
+		Inputs map[string]interface{} `json:"inputs"`
+	}
+	if err := json.Unmarshal([]byte(eventJSON), &event); err != nil {
+		return fmt.Errorf("failed to read inputs of the workflow_dispatch event: %w", err)
+	}
+
+	validated := map[*model.Workflow]bool{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if validated[run.Workflow] {
+				continue
+			}
+			validated[run.Workflow] = true
+
+			config := run.Workflow.WorkflowDispatchConfig()
+			if config == nil {
+				continue
+			}
+			inputs := map[string]interface{}{}
+			for name, value := range event.Inputs {
+				if _, ok := config.Inputs[name]; ok {
+					inputs[name] = value
+				} else {
+					common.Logger(ctx).Warnf("The input '%s' isn't declared by workflow '%s'", name, run.Workflow.File)
+				}
+			}
+			if err := config.ValidateInputs(inputs); err != nil {
+				return fmt.Errorf("invalid inputs for workflow '%s':\n%w", run.Workflow.File, err)
+			}
+		}
+	}
+	return nil
+}
+
 func handleFailure(plan *model.Plan) common.Executor {
 	return func(_ context.Context) error {
 		for _, stage := range plan.Stages {
@@ -241,12 +294,13 @@ func selectMatrixes(originalMatrixes []map[string]interface{}, targetMatrixValue
 
 func (runner *runnerImpl) newRunContext(ctx context.Context, run *model.Run, matrix map[string]interface{}) *RunContext {
 	rc := &RunContext{
-		Config:      runner.config,
-		Run:         run,
-		EventJSON:   runner.eventJSON,
-		StepResults: make(map[string]*model.StepResult),
-		Matrix:      matrix,
-		caller:      runner.caller,
+		Config:         runner.config,
+		Run:            run,
+		EventJSON:      runner.eventJSON,
+		StepResults:    make(map[string]*model.StepResult),
+		Matrix:         matrix,
+		caller:         runner.caller,
+		hashFilesCache: runner.hashFilesCache,
 	}
 	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
 	rc.Name = rc.ExprEval.Interpolate(ctx, run.String())
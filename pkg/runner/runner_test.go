@@ -221,6 +221,23 @@ type TestConfig struct {
 	LocalRepositories map[string]string `yaml:"local-repositories"`
 }
 
+func TestValidateWorkflowDispatchInputs(t *testing.T) {
+	planner, err := model.NewWorkflowPlanner("testdata/workflow_dispatch", true)
+	assert.NoError(t, err)
+
+	plan, err := planner.PlanEvent("workflow_dispatch")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, validateWorkflowDispatchInputs(ctx, plan, `{"inputs":{"required":"value","boolean":"true"}}`))
+	assert.NoError(t, validateWorkflowDispatchInputs(ctx, plan, `{"inputs":{"required":"value","other":"value"}}`), "inputs of other workflows are only warned about")
+
+	err = validateWorkflowDispatchInputs(ctx, plan, `{"inputs":{"boolean":"yes","other":"value"}}`)
+	assert.NotContains(t, err.Error(), "'other'")
+	assert.ErrorContains(t, err, "input 'boolean': expected a boolean, got 'yes'")
+	assert.ErrorContains(t, err, "input 'required' is required, but not provided")
+}
+
 func TestRunEvent(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -738,6 +755,8 @@ func TestRunActionInputs(t *testing.T) {
 	}
 
 	inputs := map[string]string{
+		"NAME":       "name",
+		"SOME_VALUE": "value",
 		"SOME_INPUT": "input",
 	}
 
@@ -4,16 +4,18 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 
 	"github.com/nektos/act/pkg/common"
-	"github.com/nektos/act/pkg/common/git"
 	"github.com/nektos/act/pkg/model"
 )
 
@@ -31,16 +33,27 @@ func (sarm *stepActionRemoteMocks) runAction(step actionStep, actionDir string,
 	return args.Get(0).(func(context.Context) error)
 }
 
+// fetchRecorder records the repositories fetched through the action cache
+type fetchRecorder struct {
+	fakeActionCache
+	fetched []string
+}
+
+func (c *fetchRecorder) Fetch(ctx context.Context, cacheDir, url, ref, token string) (string, error) {
+	c.fetched = append(c.fetched, fmt.Sprintf("%s@%s token=%s", url, ref, token))
+	return c.fakeActionCache.Fetch(ctx, cacheDir, url, ref, token)
+}
+
 func TestStepActionRemote(t *testing.T) {
 	table := []struct {
 		name      string
 		stepModel *model.Step
 		result    *model.StepResult
 		mocks     struct {
-			env    bool
-			cloned bool
-			read   bool
-			run    bool
+			env     bool
+			fetched bool
+			read    bool
+			run     bool
 		}
 		runError error
 	}{
@@ -56,15 +69,15 @@ func TestStepActionRemote(t *testing.T) {
 				Outputs:    map[string]string{},
 			},
 			mocks: struct {
-				env    bool
-				cloned bool
-				read   bool
-				run    bool
+				env     bool
+				fetched bool
+				read    bool
+				run     bool
 			}{
-				env:    true,
-				cloned: true,
-				read:   true,
-				run:    true,
+				env:     true,
+				fetched: true,
+				read:    true,
+				run:     true,
 			},
 		},
 		{
@@ -80,15 +93,15 @@ func TestStepActionRemote(t *testing.T) {
 				Outputs:    map[string]string{},
 			},
 			mocks: struct {
-				env    bool
-				cloned bool
-				read   bool
-				run    bool
+				env     bool
+				fetched bool
+				read    bool
+				run     bool
 			}{
-				env:    true,
-				cloned: true,
-				read:   true,
-				run:    false,
+				env:     true,
+				fetched: true,
+				read:    true,
+				run:     false,
 			},
 		},
 		{
@@ -103,15 +116,15 @@ func TestStepActionRemote(t *testing.T) {
 				Outputs:    map[string]string{},
 			},
 			mocks: struct {
-				env    bool
-				cloned bool
-				read   bool
-				run    bool
+				env     bool
+				fetched bool
+				read    bool
+				run     bool
 			}{
-				env:    true,
-				cloned: true,
-				read:   true,
-				run:    true,
+				env:     true,
+				fetched: true,
+				read:    true,
+				run:     true,
 			},
 			runError: errors.New("error"),
 		},
@@ -124,23 +137,13 @@ func TestStepActionRemote(t *testing.T) {
 			cm := &containerMock{}
 			sarm := &stepActionRemoteMocks{}
 
-			clonedAction := false
-
-			origStepAtionRemoteNewCloneExecutor := stepActionRemoteNewCloneExecutor
-			stepActionRemoteNewCloneExecutor = func(_ git.NewGitCloneExecutorInput) common.Executor {
-				return func(_ context.Context) error {
-					clonedAction = true
-					return nil
-				}
-			}
-			defer (func() {
-				stepActionRemoteNewCloneExecutor = origStepAtionRemoteNewCloneExecutor
-			})()
+			cache := &fetchRecorder{}
 
 			sar := &stepActionRemote{
 				RunContext: &RunContext{
 					Config: &Config{
 						GitHubInstance: "github.com",
+						ActionCache:    cache,
 					},
 					Run: &model.Run{
 						JobID: "1",
@@ -166,7 +169,7 @@ func TestStepActionRemote(t *testing.T) {
 			}
 
 			if tt.mocks.read {
-				sarm.On("readAction", sar.Step, suffixMatcher("act/remote-action@v1"), "", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
+				sarm.On("readAction", sar.Step, "v1", "", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
 			}
 			if tt.mocks.run {
 				sarm.On("runAction", sar, suffixMatcher("act/remote-action@v1"), newRemoteAction(sar.Step.Uses)).Return(func(_ context.Context) error { return tt.runError })
@@ -196,7 +199,7 @@ func TestStepActionRemote(t *testing.T) {
 			}
 
 			assert.Equal(t, tt.runError, err)
-			assert.Equal(t, tt.mocks.cloned, clonedAction)
+			assert.Equal(t, tt.mocks.fetched, len(cache.fetched) == 1)
 			assert.Equal(t, tt.result, sar.RunContext.StepResults["step"])
 
 			sarm.AssertExpectations(t)
@@ -222,25 +225,15 @@ func TestStepActionRemotePre(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 
-			clonedAction := false
+			cache := &fetchRecorder{}
 			sarm := &stepActionRemoteMocks{}
 
-			origStepAtionRemoteNewCloneExecutor := stepActionRemoteNewCloneExecutor
-			stepActionRemoteNewCloneExecutor = func(_ git.NewGitCloneExecutorInput) common.Executor {
-				return func(_ context.Context) error {
-					clonedAction = true
-					return nil
-				}
-			}
-			defer (func() {
-				stepActionRemoteNewCloneExecutor = origStepAtionRemoteNewCloneExecutor
-			})()
-
 			sar := &stepActionRemote{
 				Step: tt.stepModel,
 				RunContext: &RunContext{
 					Config: &Config{
-						GitHubInstance: "https://github.com",
+						GitHubInstance: "github.com",
+						ActionCache:    cache,
 					},
 					Run: &model.Run{
 						JobID: "1",
@@ -254,18 +247,12 @@ func TestStepActionRemotePre(t *testing.T) {
 				readAction: sarm.readAction,
 			}
 
-			suffixMatcher := func(suffix string) interface{} {
-				return mock.MatchedBy(func(actionDir string) bool {
-					return strings.HasSuffix(actionDir, suffix)
-				})
-			}
-
-			sarm.On("readAction", sar.Step, suffixMatcher("org-repo-path@ref"), "path", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
+			sarm.On("readAction", sar.Step, "ref", "path", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
 
 			err := sar.pre()(ctx)
 
 			assert.Nil(t, err)
-			assert.Equal(t, true, clonedAction)
+			assert.Equal(t, []string{"https://github.com/org/repo@ref token="}, cache.fetched)
 
 			sarm.AssertExpectations(t)
 		})
@@ -289,28 +276,16 @@ func TestStepActionRemotePreThroughAction(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 
-			clonedAction := false
+			cache := &fetchRecorder{}
 			sarm := &stepActionRemoteMocks{}
 
-			origStepAtionRemoteNewCloneExecutor := stepActionRemoteNewCloneExecutor
-			stepActionRemoteNewCloneExecutor = func(input git.NewGitCloneExecutorInput) common.Executor {
-				return func(_ context.Context) error {
-					if input.URL == "https://github.com/org/repo" {
-						clonedAction = true
-					}
-					return nil
-				}
-			}
-			defer (func() {
-				stepActionRemoteNewCloneExecutor = origStepAtionRemoteNewCloneExecutor
-			})()
-
 			sar := &stepActionRemote{
 				Step: tt.stepModel,
 				RunContext: &RunContext{
 					Config: &Config{
 						GitHubInstance:                "https://enterprise.github.com",
 						ReplaceGheActionWithGithubCom: []string{"org/repo"},
+						ActionCache:                   cache,
 					},
 					Run: &model.Run{
 						JobID: "1",
@@ -324,18 +299,12 @@ func TestStepActionRemotePreThroughAction(t *testing.T) {
 				readAction: sarm.readAction,
 			}
 
-			suffixMatcher := func(suffix string) interface{} {
-				return mock.MatchedBy(func(actionDir string) bool {
-					return strings.HasSuffix(actionDir, suffix)
-				})
-			}
-
-			sarm.On("readAction", sar.Step, suffixMatcher("org-repo-path@ref"), "path", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
+			sarm.On("readAction", sar.Step, "ref", "path", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
 
 			err := sar.pre()(ctx)
 
 			assert.Nil(t, err)
-			assert.Equal(t, true, clonedAction)
+			assert.Equal(t, []string{"https://github.com/org/repo@ref token="}, cache.fetched)
 
 			sarm.AssertExpectations(t)
 		})
@@ -359,22 +328,9 @@ func TestStepActionRemotePreThroughActionToken(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 
-			clonedAction := false
+			cache := &fetchRecorder{}
 			sarm := &stepActionRemoteMocks{}
 
-			origStepAtionRemoteNewCloneExecutor := stepActionRemoteNewCloneExecutor
-			stepActionRemoteNewCloneExecutor = func(input git.NewGitCloneExecutorInput) common.Executor {
-				return func(_ context.Context) error {
-					if input.URL == "https://github.com/org/repo" && input.Token == "PRIVATE_ACTIONS_TOKEN_ON_GITHUB" {
-						clonedAction = true
-					}
-					return nil
-				}
-			}
-			defer (func() {
-				stepActionRemoteNewCloneExecutor = origStepAtionRemoteNewCloneExecutor
-			})()
-
 			sar := &stepActionRemote{
 				Step: tt.stepModel,
 				RunContext: &RunContext{
@@ -382,6 +338,7 @@ func TestStepActionRemotePreThroughActionToken(t *testing.T) {
 						GitHubInstance:                     "https://enterprise.github.com",
 						ReplaceGheActionWithGithubCom:      []string{"org/repo"},
 						ReplaceGheActionTokenWithGithubCom: "PRIVATE_ACTIONS_TOKEN_ON_GITHUB",
+						ActionCache:                        cache,
 					},
 					Run: &model.Run{
 						JobID: "1",
@@ -395,24 +352,59 @@ func TestStepActionRemotePreThroughActionToken(t *testing.T) {
 				readAction: sarm.readAction,
 			}
 
-			suffixMatcher := func(suffix string) interface{} {
-				return mock.MatchedBy(func(actionDir string) bool {
-					return strings.HasSuffix(actionDir, suffix)
-				})
-			}
-
-			sarm.On("readAction", sar.Step, suffixMatcher("org-repo-path@ref"), "path", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
+			sarm.On("readAction", sar.Step, "ref", "path", mock.Anything, mock.Anything).Return(&model.Action{}, nil)
 
 			err := sar.pre()(ctx)
 
 			assert.Nil(t, err)
-			assert.Equal(t, true, clonedAction)
+			assert.Equal(t, []string{"https://github.com/org/repo@ref token=PRIVATE_ACTIONS_TOKEN_ON_GITHUB"}, cache.fetched)
 
 			sarm.AssertExpectations(t)
 		})
 	}
 }
 
+func TestStepActionRemoteOfflineMode(t *testing.T) {
+	ctx := context.Background()
+	remote := t.TempDir()
+	commitTestRepository(t, remote, map[string]string{"action.yml": "name: prefetched\nruns:\n  using: node20\n  main: index.js\n"})
+	actionCacheDir := t.TempDir()
+
+	// act prefetch stores the resolved refs for the offline mode
+	prefetch := &GoGitActionCacheOfflineMode{
+		Parent:  GoGitActionCache{Path: actionCacheDir},
+		Refresh: true,
+	}
+	_, err := prefetch.Fetch(ctx, "org/repo", remote, "master", "")
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(remote))
+
+	sar := &stepActionRemote{
+		Step: &model.Step{
+			Uses: "org/repo@master",
+		},
+		RunContext: &RunContext{
+			Config: &Config{
+				GitHubInstance:    "github.com",
+				ActionCacheDir:    actionCacheDir,
+				ActionOfflineMode: true,
+			},
+			Run: &model.Run{
+				JobID: "1",
+				Workflow: &model.Workflow{
+					Jobs: map[string]*model.Job{
+						"1": {},
+					},
+				},
+			},
+		},
+		readAction: readActionImpl,
+	}
+
+	require.NoError(t, sar.prepareActionExecutor()(ctx))
+	assert.Equal(t, "prefetched", sar.action.Name)
+}
+
 func TestStepActionRemotePost(t *testing.T) {
 	table := []struct {
 		name               string
@@ -103,6 +103,22 @@ func TestStepDockerMain(t *testing.T) {
 	cm.AssertExpectations(t)
 }
 
+func TestStepDockerEngineWithoutDaemon(t *testing.T) {
+	for _, engine := range []container.Engine{container.EngineNamespaces, container.EngineKubernetes} {
+		sd := &stepDocker{
+			RunContext: &RunContext{
+				Config: &Config{ContainerEngine: engine},
+			},
+			Step: &model.Step{
+				ID:   "1",
+				Uses: "docker://node:14",
+			},
+		}
+		err := sd.runUsesContainer()(context.Background())
+		assert.ErrorContains(t, err, "'docker://node:14' runs in a container of its own, which the "+string(engine)+" engine doesn't support")
+	}
+}
+
 func TestStepDockerPrePost(t *testing.T) {
 	ctx := context.Background()
 	sd := &stepDocker{}
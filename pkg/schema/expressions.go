@@ -0,0 +1,240 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+	"gopkg.in/yaml.v3"
+)
+
+// CheckWorkflowExpressions statically checks the expressions of the if, with, env and run keys
+// of all jobs and steps of a workflow before it runs.
+// Contexts and functions are checked like the schema does, references to steps and needs are
+// additionally checked against the step ids declared before and the needs of the job.
+// Undefined steps and needs evaluate to null on GitHub, they are returned as warnings instead of errors.
+func CheckWorkflowExpressions(node *yaml.Node) ([]string, error) {
+	if node != nil && node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		node = node.Content[0]
+	}
+	jobs := mappingValue(node, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	s := GetWorkflowSchema()
+	var warnings []string
+	var allErrors error
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		jobID := jobs.Content[i].Value
+		job := jobs.Content[i+1]
+		if job.Kind != yaml.MappingNode {
+			continue
+		}
+		c := &expressionChecker{
+			schema: s,
+			needs:  map[string]bool{},
+		}
+		if needs := mappingValue(job, "needs"); needs != nil {
+			if needs.Kind == yaml.ScalarNode {
+				c.needs[strings.ToLower(needs.Value)] = true
+			}
+			for _, need := range needs.Content {
+				c.needs[strings.ToLower(need.Value)] = true
+			}
+		}
+
+		path := "jobs." + jobID
+		allErrors = errors.Join(allErrors,
+			c.check(mappingValue(job, "if"), path+".if", "job-if"),
+			c.checkMapping(mappingValue(job, "env"), path+".env", "job-env"),
+			c.checkMapping(mappingValue(job, "with"), path+".with", "workflow-job-with"))
+
+		steps := mappingValue(job, "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		// the steps context only contains the steps which ran before
+		c.stepIDs = map[string]bool{}
+		for j, step := range steps.Content {
+			if step.Kind != yaml.MappingNode {
+				continue
+			}
+			path := fmt.Sprintf("%s.steps[%d]", path, j)
+			allErrors = errors.Join(allErrors,
+				c.check(mappingValue(step, "if"), path+".if", "step-if"),
+				c.checkMapping(mappingValue(step, "with"), path+".with", "step-with"),
+				c.checkMapping(mappingValue(step, "env"), path+".env", "step-env"),
+				c.check(mappingValue(step, "run"), path+".run", "string-steps-context"))
+			if id := mappingValue(step, "id"); id != nil && !strings.Contains(id.Value, "${{") {
+				c.stepIDs[strings.ToLower(id.Value)] = true
+			}
+		}
+		warnings = append(warnings, c.warnings...)
+	}
+	return warnings, allErrors
+}
+
+type expressionChecker struct {
+	schema   *Schema
+	needs    map[string]bool
+	stepIDs  map[string]bool
+	warnings []string
+}
+
+func (c *expressionChecker) checkMapping(node *yaml.Node, path, definition string) error {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return c.check(node, path, definition)
+	}
+	var allErrors error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		allErrors = errors.Join(allErrors, c.check(node.Content[i+1], path+"."+node.Content[i].Value, definition))
+	}
+	return allErrors
+}
+
+// check checks all expressions of a scalar, keys with an is-expression definition don't need the ${{ }} syntax
+func (c *expressionChecker) check(node *yaml.Node, path, definition string) error {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return nil
+	}
+	def := c.schema.GetDefinition(definition)
+	n := &Node{
+		Definition: definition,
+		Schema:     c.schema,
+		Context:    def.Context,
+	}
+
+	val := node.Value
+	if !strings.Contains(val, "${{") {
+		if def.String == nil || !def.String.IsExpression || val == "" {
+			return nil
+		}
+		_, err := c.checkExpression(n, node, path, val+"}}", 0)
+		return err
+	}
+
+	var allErrors error
+	offset := 0
+	for {
+		i := strings.Index(val[offset:], "${{")
+		if i == -1 {
+			return allErrors
+		}
+		offset += i + 3
+		length, err := c.checkExpression(n, node, path, val[offset:], offset)
+		allErrors = errors.Join(allErrors, err)
+		if length < 0 {
+			return allErrors
+		}
+		offset += length
+	}
+}
+
+// checkExpression checks the expression at the start of expression and returns its length including the closing }},
+// offset is the position of expression in the value of node. The length is -1 if the expression can't be parsed.
+func (c *expressionChecker) checkExpression(n *Node, node *yaml.Node, path, expression string, offset int) (int, error) {
+	errorf := func(pos int, format string, args ...interface{}) error {
+		line, column := valuePosition(node, offset+pos)
+		return fmt.Errorf("Line: %v Column %v: %s: %s", line, column, path, fmt.Sprintf(format, args...))
+	}
+
+	lexer := actionlint.NewExprLexer(expression)
+	exprNode, parseErr := actionlint.NewExprParser().Parse(lexer)
+	if parseErr != nil {
+		return -1, errorf(parseErr.Offset, "Failed to parse: %s", parseErr.Message)
+	}
+
+	allErrors := n.checkContextAndFunctions(exprNode, func(node actionlint.ExprNode, format string, args ...interface{}) error {
+		return errorf(node.Token().Offset, format, args...)
+	})
+	actionlint.VisitExprNode(exprNode, func(node, _ actionlint.ExprNode, entering bool) {
+		if !entering {
+			return
+		}
+		var err error
+		switch node := node.(type) {
+		case *actionlint.ObjectDerefNode:
+			err = c.checkReference(node.Receiver, node.Property, errorf)
+		case *actionlint.IndexAccessNode:
+			if index, ok := node.Index.(*actionlint.StringNode); ok {
+				err = c.checkReference(node.Operand, index.Value, errorf)
+			}
+		}
+		if err != nil {
+			c.warnings = append(c.warnings, err.Error())
+		}
+	})
+	return lexer.Offset(), allErrors
+}
+
+// checkReference checks the step id of steps.<id> and the job id of needs.<id>
+func (c *expressionChecker) checkReference(receiver actionlint.ExprNode, property string, errorf func(int, string, ...interface{}) error) error {
+	variable, ok := receiver.(*actionlint.VariableNode)
+	if !ok {
+		return nil
+	}
+	switch strings.ToLower(variable.Name) {
+	case "steps":
+		if c.stepIDs != nil && !c.stepIDs[strings.ToLower(property)] {
+			return errorf(variable.Token().Offset, "Undefined step id %s, the steps context only contains steps with an id which run before", property)
+		}
+	case "needs":
+		if !c.needs[strings.ToLower(property)] {
+			if len(c.needs) == 0 {
+				return errorf(variable.Token().Offset, "Undefined needs %s, the job has no needs", property)
+			}
+			return errorf(variable.Token().Offset, "Undefined needs %s, the job needs %s", property, strings.Join(sortedKeys(c.needs), ", "))
+		}
+	}
+	return nil
+}
+
+// valuePosition converts an offset in the value of a scalar to a position in the file.
+// The columns of block scalars are relative to their indentation.
+func valuePosition(node *yaml.Node, offset int) (int, int) {
+	if offset > len(node.Value) {
+		offset = len(node.Value)
+	}
+	prefix := node.Value[:offset]
+	newlines := strings.Count(prefix, "\n")
+	column := offset - strings.LastIndex(prefix, "\n")
+	switch node.Style {
+	case yaml.LiteralStyle, yaml.FoldedStyle:
+		return node.Line + 1 + newlines, column
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		if newlines == 0 {
+			column++
+		}
+	}
+	if newlines == 0 {
+		return node.Line, node.Column + column - 1
+	}
+	return node.Line + newlines, column
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheckWorkflowExpressions(t *testing.T) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+    - id: version
+      run: echo ${{ steps.version.outputs.value }}
+    - if: steps.version.outputs.value == '1' && steps['missing'].outcome
+      with:
+        value: ${{ needs.build.result }}
+      env:
+        ID: ${{ github.run_id }} ${{ secret.TOKEN }}
+      run: |
+        echo ${{ steps.version.outputs.value }}
+        echo ${{ startsWith(github.ref) }}
+  test:
+    needs: build
+    if: needs.build.result == 'success' && toJSON(steps)
+    runs-on: ubuntu-latest
+    env:
+      VERSION: ${{ needs.build.outputs.version }} ${{ unknown(1) }}
+    steps:
+    - run: exit 0
+`), &node)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	warnings, err := CheckWorkflowExpressions(&node)
+	assert.Equal(t, []string{
+		"Line: 7 Column 21: jobs.build.steps[0].run: Undefined step id version, the steps context only contains steps with an id which run before",
+		"Line: 8 Column 49: jobs.build.steps[1].if: Undefined step id missing, the steps context only contains steps with an id which run before",
+		"Line: 10 Column 20: jobs.build.steps[1].with.value: Undefined needs build, the job has no needs",
+	}, warnings)
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.Equal(t, []string{
+		"Line: 12 Column 38: jobs.build.steps[1].env.ID: Unknown Variable Access secret",
+		"Line: 15 Column 10: jobs.build.steps[1].run: Missing parameters for startsWith expected >= 2 got 1",
+		"Line: 18 Column 51: jobs.test.if: Unknown Variable Access steps",
+		"Line: 21 Column 55: jobs.test.env.VERSION: Unknown Function Call unknown",
+	}, strings.Split(err.Error(), "\n"))
+}
+
+func TestCheckWorkflowExpressionsValid(t *testing.T) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.value }}
+    steps:
+    - id: version
+      run: echo "value=1" >> $GITHUB_OUTPUT
+    - if: ${{ success() && steps.version.outputs.value == '1' }}
+      run: echo ${{ steps.version.outputs.value }} ${{ hashFiles('**/go.sum') }}
+  test:
+    needs: [build]
+    if: always() && needs.build.outputs.version
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo ${{ needs['build'].outputs.version }}
+`), &node)
+	if !assert.NoError(t, err) {
+		return
+	}
+	warnings, err := CheckWorkflowExpressions(&node)
+	assert.Empty(t, warnings)
+	assert.NoError(t, err)
+}
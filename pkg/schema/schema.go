@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/rhysd/actionlint"
 	"gopkg.in/yaml.v3"
@@ -134,6 +135,14 @@ func (s *Node) checkSingleExpression(exprNode actionlint.ExprNode) error {
 		}
 	}
 
+	return s.checkContextAndFunctions(exprNode, func(_ actionlint.ExprNode, format string, args ...interface{}) error {
+		return fmt.Errorf(format, args...)
+	})
+}
+
+// checkContextAndFunctions checks the variables and function calls of an expression against the context of the node,
+// errorf creates the error of an expression node, so that callers can add its position.
+func (s *Node) checkContextAndFunctions(exprNode actionlint.ExprNode, errorf func(node actionlint.ExprNode, format string, args ...interface{}) error) error {
 	funcs := s.GetFunctions()
 
 	var err error
@@ -142,15 +151,15 @@ func (s *Node) checkSingleExpression(exprNode actionlint.ExprNode) error {
 			for _, v := range *funcs {
 				if strings.EqualFold(funcCallNode.Callee, v.name) {
 					if v.min > len(funcCallNode.Args) {
-						err = errors.Join(err, fmt.Errorf("Missing parameters for %s expected >= %v got %v", funcCallNode.Callee, v.min, len(funcCallNode.Args)))
+						err = errors.Join(err, errorf(node, "Missing parameters for %s expected >= %v got %v", funcCallNode.Callee, v.min, len(funcCallNode.Args)))
 					}
 					if v.max < len(funcCallNode.Args) {
-						err = errors.Join(err, fmt.Errorf("Too many parameters for %s expected <= %v got %v", funcCallNode.Callee, v.max, len(funcCallNode.Args)))
+						err = errors.Join(err, errorf(node, "Too many parameters for %s expected <= %v got %v", funcCallNode.Callee, v.max, len(funcCallNode.Args)))
 					}
 					return
 				}
 			}
-			err = errors.Join(err, fmt.Errorf("Unknown Function Call %s", funcCallNode.Callee))
+			err = errors.Join(err, errorf(node, "Unknown Function Call %s", funcCallNode.Callee))
 		}
 		if varNode, ok := node.(*actionlint.VariableNode); entering && ok {
 			for _, v := range s.Context {
@@ -158,14 +167,33 @@ func (s *Node) checkSingleExpression(exprNode actionlint.ExprNode) error {
 					return
 				}
 			}
-			err = errors.Join(err, fmt.Errorf("Unknown Variable Access %s", varNode.Name))
+			err = errors.Join(err, errorf(node, "Unknown Variable Access %s", varNode.Name))
 		}
 	})
 	return err
 }
 
+var (
+	customFunctionsMu sync.RWMutex
+	customFunctions   []FunctionInfo
+)
+
+// RegisterFunction makes a custom function available in all expressions which allow a context
+func RegisterFunction(name string, minArgs, maxArgs int) {
+	customFunctionsMu.Lock()
+	defer customFunctionsMu.Unlock()
+	for i, v := range customFunctions {
+		if strings.EqualFold(v.name, name) {
+			customFunctions[i] = FunctionInfo{name: name, min: minArgs, max: maxArgs}
+			return
+		}
+	}
+	AddFunction(&customFunctions, name, minArgs, maxArgs)
+}
+
 func (s *Node) GetFunctions() *[]FunctionInfo {
 	funcs := &[]FunctionInfo{}
+	AddFunction(funcs, "case", 3, math.MaxInt32)
 	AddFunction(funcs, "contains", 2, 2)
 	AddFunction(funcs, "endsWith", 2, 2)
 	AddFunction(funcs, "format", 1, 255)
@@ -173,6 +201,9 @@ func (s *Node) GetFunctions() *[]FunctionInfo {
 	AddFunction(funcs, "startsWith", 2, 2)
 	AddFunction(funcs, "toJson", 1, 1)
 	AddFunction(funcs, "fromJson", 1, 1)
+	customFunctionsMu.RLock()
+	*funcs = append(*funcs, customFunctions...)
+	customFunctionsMu.RUnlock()
 	for _, v := range s.Context {
 		i := strings.Index(v, "(")
 		if i == -1 {
@@ -90,3 +90,25 @@ jobs:
 	}).UnmarshalYAML(&node)
 	assert.NoError(t, err)
 }
+
+func TestCaseAndRegisteredFunctions(t *testing.T) {
+	RegisterFunction("myFunction", 1, 1)
+
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`
+on: push
+jobs:
+  job-with-condition:
+    runs-on: self-hosted
+    steps:
+    - run: echo ${{ case(github.event_name == 'push', 'a', 'b') }} ${{ myfunction('x') }}
+`), &node)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = (&Node{
+		Definition: "workflow-root-strict",
+		Schema:     GetWorkflowSchema(),
+	}).UnmarshalYAML(&node)
+	assert.NoError(t, err)
+}